@@ -0,0 +1,22 @@
+package authentication
+
+import "context"
+
+// Client describes who is calling, for throttling and the auth audit trail.
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+type clientKey struct{}
+
+// WithClient attaches the caller's details to ctx.
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext returns the caller's details, or a zero Client outside a request.
+func ClientFromContext(ctx context.Context) Client {
+	client, _ := ctx.Value(clientKey{}).(Client)
+	return client
+}
@@ -1,70 +1,79 @@
 package authentication
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
-	"github.com/golang-jwt/jwt/v5"
+	"log"
 	"os"
+	"strings"
 	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const issuer = "trading-insights"
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
 )
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID      uint     `json:"user_id"`
+	Email       string   `json:"email"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Set only when the request authenticated with an API key; never part of a JWT.
+	APIKeyID uint     `json:"-"`
+	Scopes   []string `json:"-"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a new JWT token for a user
-func GenerateToken(userID uint, email string) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", fmt.Errorf("JWT_SECRET environment variable not set")
-	}
+// AccessTokenTTL reads ACCESS_TOKEN_TTL (a Go duration), defaulting to 15 minutes.
+func AccessTokenTTL() time.Duration {
+	return durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
 
-	// Token expires in 24 hours
-	expirationTime := time.Now().Add(24 * time.Hour)
+// RefreshTokenTTL reads REFRESH_TOKEN_TTL (a Go duration), defaulting to 30 days.
+func RefreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
 
-	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "trading-insights",
-		},
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return def
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secret))
-	if err != nil {
-		return "", err
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		log.Printf("Ignoring invalid %s %q", key, raw)
+		return def
 	}
-
-	return tokenString, nil
+	return d
 }
 
-// ValidateToken validates and parses a JWT token
-func ValidateToken(tokenString string) (*Claims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, fmt.Errorf("JWT_SECRET environment variable not set")
-	}
-
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
-
+// NewRefreshToken returns an opaque refresh token and the hash to store in its place.
+func NewRefreshToken() (string, string, error) {
+	token, err := randomToken(32)
 	if err != nil {
-		return nil, err
+		return "", "", fmt.Errorf("generate refresh token: %w", err)
 	}
+	return token, HashRefreshToken(token), nil
+}
 
-	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
-	}
+// HashRefreshToken is the lookup key for a refresh token; the raw value is never stored.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
 
-	return claims, nil
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
@@ -0,0 +1,311 @@
+package authentication
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const rsaKeyBits = 2048
+
+// SigningKey is one asymmetric key in the keyring, identified by the kid header of the tokens
+// it signs.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	signer    crypto.Signer
+}
+
+// GenerateSigningKey creates a fresh key for alg (RS256 or EdDSA) with a random kid.
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	alg, err := NormalizeAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+	var signer crypto.Signer
+	switch alg {
+	case AlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generate %s key: %w", alg, err)
+	}
+	kid, err := randomToken(12)
+	if err != nil {
+		return nil, fmt.Errorf("generate key id: %w", err)
+	}
+	return &SigningKey{ID: kid, Algorithm: alg, CreatedAt: time.Now().UTC(), signer: signer}, nil
+}
+
+// ParseSigningKey restores a key stored with MarshalPrivateKey.
+func ParseSigningKey(id, alg string, privatePEM []byte, createdAt time.Time) (*SigningKey, error) {
+	alg, err := NormalizeAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(privatePEM)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM block", id)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+	var signer crypto.Signer
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if alg == AlgRS256 {
+			signer = key
+		}
+	case ed25519.PrivateKey:
+		if alg == AlgEdDSA {
+			signer = key
+		}
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("key %s: %T does not match algorithm %s", id, parsed, alg)
+	}
+	return &SigningKey{ID: id, Algorithm: alg, CreatedAt: createdAt, signer: signer}, nil
+}
+
+// MarshalPrivateKey encodes the private key as PKCS#8 PEM for storage.
+func (k *SigningKey) MarshalPrivateKey() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.signer)
+	if err != nil {
+		return nil, fmt.Errorf("marshal key %s: %w", k.ID, err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// NormalizeAlgorithm accepts RS256 or EdDSA in any case.
+func NormalizeAlgorithm(alg string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(alg)) {
+	case "", strings.ToUpper(AlgRS256):
+		return AlgRS256, nil
+	case strings.ToUpper(AlgEdDSA):
+		return AlgEdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported signing algorithm %q; use RS256 or EdDSA", alg)
+	}
+}
+
+// keyReloadTimeout bounds a reload triggered by a token with an unknown kid.
+const keyReloadTimeout = 5 * time.Second
+
+// Keyring signs access tokens with its newest key and verifies tokens signed by any key it
+// still holds, so tokens issued before a rotation stay valid until their key is pruned.
+type Keyring struct {
+	mu   sync.RWMutex
+	keys []*SigningKey // newest first
+
+	reloadMu    sync.Mutex
+	reload      func(ctx context.Context) error
+	reloadEvery time.Duration
+	lastReload  time.Time
+}
+
+func NewKeyring(keys ...*SigningKey) *Keyring {
+	k := &Keyring{}
+	k.Replace(keys)
+	return k
+}
+
+// Replace swaps in a new key set, e.g. after a rotation or a reload from storage.
+func (k *Keyring) Replace(keys []*SigningKey) {
+	sorted := append([]*SigningKey(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.After(sorted[j].CreatedAt) })
+	k.mu.Lock()
+	k.keys = sorted
+	k.mu.Unlock()
+}
+
+// ReloadOnUnknownKey makes ValidateToken call reload and retry once when a token names a kid
+// the keyring does not hold, e.g. a key another instance has just rotated in. Reloads run at
+// most once per minInterval, so tokens with made-up kids cannot hammer the key store.
+func (k *Keyring) ReloadOnUnknownKey(reload func(ctx context.Context) error, minInterval time.Duration) {
+	k.reloadMu.Lock()
+	k.reload = reload
+	k.reloadEvery = minInterval
+	k.reloadMu.Unlock()
+}
+
+// reloadKeys runs the reload hook unless it ran within the last interval, and reports whether
+// it did.
+func (k *Keyring) reloadKeys() bool {
+	k.reloadMu.Lock()
+	defer k.reloadMu.Unlock()
+	if k.reload == nil || time.Since(k.lastReload) < k.reloadEvery {
+		return false
+	}
+	k.lastReload = time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), keyReloadTimeout)
+	defer cancel()
+	if err := k.reload(ctx); err != nil {
+		log.Printf("Failed to reload signing keys: %v", err)
+		return false
+	}
+	return true
+}
+
+// Keys returns the current keys, newest first.
+func (k *Keyring) Keys() []*SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return append([]*SigningKey(nil), k.keys...)
+}
+
+// GenerateToken issues a short-lived access token for a user, signed with the newest key and
+// tagged with its kid. The role's permissions are embedded so handlers need no user lookup.
+// Every token carries a unique jti so it can be revoked before it expires; the returned claims
+// hold the jti and expiry.
+func (k *Keyring) GenerateToken(userID uint, email, role string) (string, *Claims, error) {
+	keys := k.Keys()
+	if len(keys) == 0 {
+		return "", nil, fmt.Errorf("no signing key available")
+	}
+	key := keys[0]
+
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", nil, fmt.Errorf("generate token id: %w", err)
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		UserID:      userID,
+		Email:       email,
+		Role:        role,
+		Permissions: PermissionsFor(role),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    issuer,
+		},
+	}
+
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(key.signer)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return tokenString, claims, nil
+}
+
+// ValidateToken validates and parses a JWT token against the key named by its kid header. An
+// unknown kid triggers one rate-limited reload (see ReloadOnUnknownKey) before it is rejected.
+func (k *Keyring) ValidateToken(tokenString string) (*Claims, error) {
+	claims, token, unknown, err := k.parse(tokenString)
+	if unknown && k.reloadKeys() {
+		claims, token, _, err = k.parse(tokenString)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	// Every token we issue carries a jti; without one it cannot be revoked.
+	if claims.ID == "" {
+		return nil, fmt.Errorf("token has no jti")
+	}
+
+	return claims, nil
+}
+
+// parse checks the token's signature with the current keys; unknown reports that none of them
+// carries the token's kid.
+func (k *Keyring) parse(tokenString string) (*Claims, *jwt.Token, bool, error) {
+	keys := k.Keys()
+	unknown := false
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		for _, key := range keys {
+			if key.ID != kid {
+				continue
+			}
+			// The algorithm comes from our key, never from the token header.
+			if token.Method.Alg() != key.method().Alg() {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return key.signer.Public(), nil
+		}
+		unknown = true
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}, jwt.WithIssuer(issuer))
+	return claims, token, unknown, err
+}
+
+// JWK is the public half of a signing key as published in the JWKS document (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS publishes every key that tokens may still be signed with.
+func (k *Keyring) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range k.Keys() {
+		jwk := JWK{Use: "sig", Alg: key.Algorithm, Kid: key.ID}
+		switch pub := key.signer.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,84 @@
+package authentication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyringVerifiesAcrossRotation(t *testing.T) {
+	oldKey, err := GenerateSigningKey(AlgRS256)
+	assert.NoError(t, err)
+	oldKey.CreatedAt = time.Now().Add(-time.Hour)
+	keyring := NewKeyring(oldKey)
+
+	token, claims, err := keyring.GenerateToken(1, "a@example.com", RoleAdmin)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claims.ID)
+
+	// Round-trip the new key through storage, as the rotation service does.
+	fresh, err := GenerateSigningKey(AlgEdDSA)
+	assert.NoError(t, err)
+	encoded, err := fresh.MarshalPrivateKey()
+	assert.NoError(t, err)
+	newKey, err := ParseSigningKey(fresh.ID, "eddsa", encoded, fresh.CreatedAt)
+	assert.NoError(t, err)
+	keyring.Replace([]*SigningKey{oldKey, newKey})
+
+	parsed, err := keyring.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), parsed.UserID)
+	assert.True(t, parsed.HasRole(RoleAdmin))
+	assert.True(t, parsed.HasPermission(PermissionIngestionRun))
+
+	next, _, err := keyring.GenerateToken(2, "b@example.com", RoleUser)
+	assert.NoError(t, err)
+	jwks := keyring.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+		assert.Equal(t, newKey.ID, jwks.Keys[0].Kid)
+		assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	}
+
+	// Once the old key is pruned its tokens stop validating; the new key's still do.
+	keyring.Replace([]*SigningKey{newKey})
+	_, err = keyring.ValidateToken(token)
+	assert.Error(t, err)
+	_, err = keyring.ValidateToken(next)
+	assert.NoError(t, err)
+}
+
+func TestKeyringReloadsOnUnknownKidAtMostOncePerInterval(t *testing.T) {
+	oldKey, err := GenerateSigningKey(AlgEdDSA)
+	assert.NoError(t, err)
+	newKey, err := GenerateSigningKey(AlgEdDSA)
+	assert.NoError(t, err)
+	otherKey, err := GenerateSigningKey(AlgEdDSA)
+	assert.NoError(t, err)
+
+	// Another instance rotated newKey in; this one still holds only oldKey.
+	keyring := NewKeyring(oldKey)
+	reloads := 0
+	keyring.ReloadOnUnknownKey(func(ctx context.Context) error {
+		reloads++
+		keyring.Replace([]*SigningKey{newKey, oldKey})
+		return nil
+	}, time.Hour)
+
+	token, _, err := NewKeyring(newKey).GenerateToken(1, "a@example.com", RoleUser)
+	assert.NoError(t, err)
+	_, err = keyring.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reloads)
+
+	// Known kids never reload, and unknown ones only once per interval.
+	_, err = keyring.ValidateToken(token)
+	assert.NoError(t, err)
+	forged, _, err := NewKeyring(otherKey).GenerateToken(1, "a@example.com", RoleUser)
+	assert.NoError(t, err)
+	_, err = keyring.ValidateToken(forged)
+	assert.Error(t, err)
+	assert.Equal(t, 1, reloads)
+}
@@ -0,0 +1,67 @@
+package authentication
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Roles a user can hold.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permissions granted by roles and carried in access-token claims.
+const (
+	PermissionUsersRead     = "users:read"
+	PermissionUsersWrite    = "users:write"
+	PermissionIngestionRun  = "ingestion:run"
+	PermissionSystemInspect = "system:inspect"
+)
+
+var rolePermissions = map[string][]string{
+	RoleUser: nil,
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionUsersWrite,
+		PermissionIngestionRun,
+		PermissionSystemInspect,
+	},
+}
+
+// NormalizeRole validates a role name; an empty role is the default user role.
+func NormalizeRole(role string) (string, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if role == "" {
+		return RoleUser, nil
+	}
+	if _, ok := rolePermissions[role]; !ok {
+		return "", fmt.Errorf("unsupported role %q; use user or admin", role)
+	}
+	return role, nil
+}
+
+// PermissionsFor lists the permissions a role grants.
+func PermissionsFor(role string) []string {
+	return append([]string(nil), rolePermissions[role]...)
+}
+
+// HasRole reports whether the claims hold any of roles.
+func (c *Claims) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if c.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the claims grant perm.
+func (c *Claims) HasPermission(perm string) bool {
+	for _, p := range c.Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
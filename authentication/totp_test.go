@@ -0,0 +1,52 @@
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 appendix B, SHA-1 vectors truncated to six digits.
+func TestTOTPCodeMatchesRFC6238Vectors(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "t=%d", unix)
+	}
+}
+
+func TestValidateTOTPAcceptsOneStepOfDrift(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+	now := time.Unix(1_700_000_000, 0)
+
+	previous, _ := TOTPCode(secret, TOTPStep(now)-1)
+	step, ok := ValidateTOTP(secret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now)-1, step)
+
+	stale, _ := TOTPCode(secret, TOTPStep(now)-3)
+	_, ok = ValidateTOTP(secret, stale, now)
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestRecoveryCodeHashIgnoresFormatting(t *testing.T) {
+	codes, err := NewRecoveryCodes(2)
+	assert.NoError(t, err)
+	assert.Len(t, codes[0], 11)
+	assert.NotEqual(t, codes[0], codes[1])
+	typed := " " + codes[0][:5] + " " + codes[0][6:] + " "
+	assert.Equal(t, HashRecoveryCode(codes[0]), HashRecoveryCode(typed))
+}
@@ -0,0 +1,258 @@
+// Package backtest replays a price series for a currency pair A/B through a long/flat strategy.
+//
+// Prices are units of B per one A. The account starts in B; going long converts all of it
+// into A and going flat converts back. Signals are taken on a bar's close and filled at the
+// next bar's open, so a strategy never trades on a price it could not have seen.
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Bar is one step of the replayed series. Raw snapshots use the same value for all four prices.
+type Bar struct {
+	Time  time.Time `json:"time"`
+	Open  float64   `json:"open"`
+	High  float64   `json:"high"`
+	Low   float64   `json:"low"`
+	Close float64   `json:"close"`
+}
+
+// Strategy decides, for every bar, whether to hold A after that bar closes.
+type Strategy interface {
+	Name() string
+	Positions(bars []Bar) []bool
+}
+
+// Config prices fills the way market-mode exchanges are priced: each conversion gets the mid
+// rate less SpreadBps, and FeeAmount (in B) is charged on top of every fill.
+type Config struct {
+	InitialCapital float64 `json:"initial_capital"`
+	SpreadBps      float64 `json:"spread_bps"`
+	FeeAmount      float64 `json:"fee_amount"`
+}
+
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"` // in B, open positions marked at the close
+	Long   bool      `json:"long"`
+}
+
+// Trade is one round trip. Open trades are marked at the last close and have no exit.
+type Trade struct {
+	EntryTime  time.Time  `json:"entry_time"`
+	EntryPrice float64    `json:"entry_price"` // mid at the fill
+	ExitTime   *time.Time `json:"exit_time,omitempty"`
+	ExitPrice  float64    `json:"exit_price"`
+	Quantity   float64    `json:"quantity"` // units of A held
+	Cost       float64    `json:"cost"`     // B spent, fees included
+	Proceeds   float64    `json:"proceeds"` // B received net of fees (or marked value if open)
+	PnL        float64    `json:"pnl"`
+	Return     float64    `json:"return"`
+	Fees       float64    `json:"fees"`
+	Open       bool       `json:"open"`
+}
+
+type Stats struct {
+	InitialCapital       float64  `json:"initial_capital"`
+	FinalEquity          float64  `json:"final_equity"`
+	TotalReturn          float64  `json:"total_return"`
+	AnnualizedReturn     float64  `json:"annualized_return"`
+	AnnualizedVolatility float64  `json:"annualized_volatility"`
+	Sharpe               *float64 `json:"sharpe"`
+	MaxDrawdown          float64  `json:"max_drawdown"`
+	Trades               int      `json:"trades"`
+	WinRate              float64  `json:"win_rate"`
+	ProfitFactor         *float64 `json:"profit_factor"` // nil when there are no losing trades
+	Exposure             float64  `json:"exposure"`      // fraction of bars spent long
+	FeesPaid             float64  `json:"fees_paid"`
+	SpreadPaid           float64  `json:"spread_paid"` // value given up to the spread, in B
+}
+
+type Result struct {
+	Strategy    string        `json:"strategy"`
+	Bars        int           `json:"bars"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	EquityCurve []EquityPoint `json:"equity_curve"`
+	Trades      []Trade       `json:"trades"`
+	Stats       Stats         `json:"stats"`
+}
+
+// Run replays bars (sorted by time) through the strategy.
+func Run(bars []Bar, strategy Strategy, cfg Config) (*Result, error) {
+	if len(bars) < 2 {
+		return nil, fmt.Errorf("at least two bars are required")
+	}
+	if cfg.InitialCapital <= 0 {
+		return nil, fmt.Errorf("initial capital must be positive")
+	}
+	if cfg.SpreadBps < 0 || cfg.SpreadBps >= 10000 || cfg.FeeAmount < 0 {
+		return nil, fmt.Errorf("spread and fee must be non-negative")
+	}
+	if !sort.SliceIsSorted(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) }) {
+		return nil, fmt.Errorf("bars must be sorted by time")
+	}
+	for _, b := range bars {
+		if b.Open <= 0 || b.Close <= 0 {
+			return nil, fmt.Errorf("bars must have positive prices")
+		}
+	}
+
+	signals := strategy.Positions(bars)
+	if len(signals) != len(bars) {
+		return nil, fmt.Errorf("strategy %s returned %d signals for %d bars", strategy.Name(), len(signals), len(bars))
+	}
+
+	keep := 1 - cfg.SpreadBps/10000
+	res := &Result{
+		Strategy: strategy.Name(),
+		Bars:     len(bars),
+		From:     bars[0].Time,
+		To:       bars[len(bars)-1].Time,
+	}
+	cash := cfg.InitialCapital
+	qty := 0.0
+	var current *Trade
+	longBars := 0
+
+	for i, bar := range bars {
+		// Fill the previous bar's signal at this bar's open.
+		if i > 0 {
+			want := signals[i-1]
+			switch {
+			case want && current == nil && cash > cfg.FeeAmount:
+				spend := cash - cfg.FeeAmount
+				qty = spend / bar.Open * keep
+				res.Stats.FeesPaid += cfg.FeeAmount
+				res.Stats.SpreadPaid += spend * (1 - keep)
+				current = &Trade{
+					EntryTime:  bar.Time,
+					EntryPrice: bar.Open,
+					Quantity:   qty,
+					Cost:       cash,
+					Fees:       cfg.FeeAmount,
+				}
+				cash = 0
+			case !want && current != nil:
+				gross := qty * bar.Open
+				proceeds := gross*keep - cfg.FeeAmount
+				res.Stats.FeesPaid += cfg.FeeAmount
+				res.Stats.SpreadPaid += gross * (1 - keep)
+				exit := bar.Time
+				current.ExitTime = &exit
+				current.ExitPrice = bar.Open
+				current.Proceeds = proceeds
+				current.Fees += cfg.FeeAmount
+				closeTrade(current)
+				res.Trades = append(res.Trades, *current)
+				current = nil
+				cash = proceeds
+				qty = 0
+			}
+		}
+
+		if current != nil {
+			longBars++
+		}
+		res.EquityCurve = append(res.EquityCurve, EquityPoint{
+			Time:   bar.Time,
+			Equity: cash + qty*bar.Close,
+			Long:   current != nil,
+		})
+	}
+
+	if current != nil {
+		last := bars[len(bars)-1]
+		current.ExitPrice = last.Close
+		current.Proceeds = qty * last.Close
+		current.Open = true
+		closeTrade(current)
+		res.Trades = append(res.Trades, *current)
+	}
+
+	res.Stats = summarize(res, cfg, longBars, res.Stats.FeesPaid, res.Stats.SpreadPaid)
+	return res, nil
+}
+
+func closeTrade(t *Trade) {
+	t.PnL = t.Proceeds - t.Cost
+	if t.Cost > 0 {
+		t.Return = t.PnL / t.Cost
+	}
+}
+
+func summarize(res *Result, cfg Config, longBars int, fees, spread float64) Stats {
+	curve := res.EquityCurve
+	final := curve[len(curve)-1].Equity
+	stats := Stats{
+		InitialCapital: cfg.InitialCapital,
+		FinalEquity:    final,
+		TotalReturn:    final/cfg.InitialCapital - 1,
+		Trades:         len(res.Trades),
+		Exposure:       float64(longBars) / float64(len(curve)),
+		FeesPaid:       fees,
+		SpreadPaid:     spread,
+	}
+
+	years := res.To.Sub(res.From).Hours() / (365 * 24)
+	if years > 0 && final > 0 {
+		stats.AnnualizedReturn = math.Pow(final/cfg.InitialCapital, 1/years) - 1
+	}
+
+	returns := make([]float64, 0, len(curve)-1)
+	peak := curve[0].Equity
+	for i := 1; i < len(curve); i++ {
+		if prev := curve[i-1].Equity; prev > 0 {
+			returns = append(returns, curve[i].Equity/prev-1)
+		}
+		peak = math.Max(peak, curve[i].Equity)
+		if peak > 0 {
+			stats.MaxDrawdown = math.Max(stats.MaxDrawdown, (peak-curve[i].Equity)/peak)
+		}
+	}
+	if years > 0 && len(returns) > 1 {
+		periodsPerYear := float64(len(returns)) / years
+		mean, std := meanStd(returns)
+		stats.AnnualizedVolatility = std * math.Sqrt(periodsPerYear)
+		if std > 0 {
+			sharpe := mean / std * math.Sqrt(periodsPerYear)
+			stats.Sharpe = &sharpe
+		}
+	}
+
+	wins := 0
+	grossWin, grossLoss := 0.0, 0.0
+	for _, t := range res.Trades {
+		if t.PnL > 0 {
+			wins++
+			grossWin += t.PnL
+		} else {
+			grossLoss -= t.PnL
+		}
+	}
+	if len(res.Trades) > 0 {
+		stats.WinRate = float64(wins) / float64(len(res.Trades))
+	}
+	if grossLoss > 0 {
+		pf := grossWin / grossLoss
+		stats.ProfitFactor = &pf
+	}
+	return stats
+}
+
+func meanStd(xs []float64) (float64, float64) {
+	mean := 0.0
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	ss := 0.0
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(xs)-1))
+}
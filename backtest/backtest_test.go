@@ -0,0 +1,73 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// scripted holds a fixed signal list so fills and costs can be checked by hand.
+type scripted []bool
+
+func (s scripted) Name() string                { return "scripted" }
+func (s scripted) Positions(bars []Bar) []bool { return s }
+
+func bars(prices ...float64) []Bar {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]Bar, len(prices))
+	for i, p := range prices {
+		out[i] = Bar{Time: start.Add(time.Duration(i) * 24 * time.Hour), Open: p, High: p, Low: p, Close: p}
+	}
+	return out
+}
+
+func TestRunFillsNextOpenWithSpreadAndFees(t *testing.T) {
+	res, err := Run(bars(1, 2, 4, 4), scripted{true, true, false, false}, Config{
+		InitialCapital: 1000,
+		SpreadBps:      100,
+		FeeAmount:      10,
+	})
+	assert.NoError(t, err)
+	if !assert.Len(t, res.Trades, 1) {
+		return
+	}
+
+	tr := res.Trades[0]
+	qty := (1000.0 - 10) / 2 * 0.99
+	proceeds := qty*4*0.99 - 10
+	assert.InDelta(t, qty, tr.Quantity, 1e-9)
+	assert.Equal(t, 2.0, tr.EntryPrice)
+	assert.Equal(t, 4.0, tr.ExitPrice)
+	assert.InDelta(t, proceeds-1000, tr.PnL, 1e-9)
+	assert.Equal(t, 20.0, res.Stats.FeesPaid)
+	assert.InDelta(t, proceeds, res.Stats.FinalEquity, 1e-9)
+	assert.Equal(t, 1.0, res.Stats.WinRate)
+	assert.Nil(t, res.Stats.ProfitFactor)
+}
+
+func TestRunMarksOpenTradeAtLastClose(t *testing.T) {
+	res, err := Run(bars(10, 10, 5), scripted{true, true, true}, Config{InitialCapital: 100})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Trades, 1) {
+		assert.True(t, res.Trades[0].Open)
+		assert.Nil(t, res.Trades[0].ExitTime)
+		assert.InDelta(t, -50, res.Trades[0].PnL, 1e-9)
+	}
+	assert.InDelta(t, 0.5, res.Stats.MaxDrawdown, 1e-9)
+}
+
+func TestBuiltInStrategies(t *testing.T) {
+	s, err := New(StrategyMACrossover, map[string]float64{"fast": 2, "slow": 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, false, false, true, false}, s.Positions(bars(3, 2, 4, 5, 1)))
+
+	s, err = New(StrategyBreakout, map[string]float64{"entry_period": 2, "exit_period": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, false, true, true, false}, s.Positions(bars(2, 2, 3, 3, 1)))
+
+	_, err = New(StrategyMACrossover, map[string]float64{"fast": 5, "slow": 3})
+	assert.Error(t, err)
+	_, err = New(StrategyBreakout, map[string]float64{"period": 5})
+	assert.EqualError(t, err, "unknown parameters for breakout: period")
+}
@@ -0,0 +1,188 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ODawah/Trading-Insights/indicators"
+)
+
+// Built-in strategy names accepted by New.
+const (
+	StrategyMACrossover   = "ma_crossover"
+	StrategyMeanReversion = "mean_reversion"
+	StrategyBreakout      = "breakout"
+)
+
+// MACrossover is long while the fast moving average is above the slow one.
+type MACrossover struct {
+	Fast        int
+	Slow        int
+	Exponential bool
+}
+
+func (s MACrossover) Name() string { return StrategyMACrossover }
+
+func (s MACrossover) Positions(bars []Bar) []bool {
+	closes := closePrices(bars)
+	average := indicators.SMA
+	if s.Exponential {
+		average = indicators.EMA
+	}
+	fast, slow := average(closes, s.Fast), average(closes, s.Slow)
+	out := make([]bool, len(bars))
+	for i := range bars {
+		out[i] = !math.IsNaN(fast[i]) && !math.IsNaN(slow[i]) && fast[i] > slow[i]
+	}
+	return out
+}
+
+// MeanReversion buys when the close is EntryZ standard deviations below its moving average
+// and exits once it has recovered to ExitZ (0 means back at the average).
+type MeanReversion struct {
+	Period int
+	EntryZ float64
+	ExitZ  float64
+}
+
+func (s MeanReversion) Name() string { return StrategyMeanReversion }
+
+func (s MeanReversion) Positions(bars []Bar) []bool {
+	closes := closePrices(bars)
+	middle, upper, _ := indicators.Bollinger(closes, s.Period, 1)
+	out := make([]bool, len(bars))
+	long := false
+	for i, c := range closes {
+		std := upper[i] - middle[i]
+		if math.IsNaN(middle[i]) || std <= 0 {
+			out[i] = long
+			continue
+		}
+		z := (c - middle[i]) / std
+		if !long && z <= -s.EntryZ {
+			long = true
+		} else if long && z >= -s.ExitZ {
+			long = false
+		}
+		out[i] = long
+	}
+	return out
+}
+
+// Breakout enters when the close clears the highest high of the previous EntryPeriod bars and
+// exits when it falls through the lowest low of the previous ExitPeriod bars.
+type Breakout struct {
+	EntryPeriod int
+	ExitPeriod  int
+}
+
+func (s Breakout) Name() string { return StrategyBreakout }
+
+func (s Breakout) Positions(bars []Bar) []bool {
+	out := make([]bool, len(bars))
+	long := false
+	for i, bar := range bars {
+		if !long && i >= s.EntryPeriod {
+			hi := bars[i-s.EntryPeriod].High
+			for _, b := range bars[i-s.EntryPeriod : i] {
+				hi = math.Max(hi, b.High)
+			}
+			long = bar.Close > hi
+		} else if long && i >= s.ExitPeriod {
+			lo := bars[i-s.ExitPeriod].Low
+			for _, b := range bars[i-s.ExitPeriod : i] {
+				lo = math.Min(lo, b.Low)
+			}
+			long = bar.Close >= lo
+		}
+		out[i] = long
+	}
+	return out
+}
+
+// New builds a built-in strategy from its name and parameters; missing parameters take defaults.
+//
+//	ma_crossover:   fast (10), slow (30), exponential (0 or 1)
+//	mean_reversion: period (20), entry_z (2), exit_z (0)
+//	breakout:       entry_period (20), exit_period (10)
+func New(name string, params map[string]float64) (Strategy, error) {
+	p := strategyParams(params)
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case StrategyMACrossover:
+		s := MACrossover{Fast: p.period("fast", 10), Slow: p.period("slow", 30), Exponential: p.value("exponential", 0) != 0}
+		if p.err == nil && s.Fast >= s.Slow {
+			p.err = fmt.Errorf("fast must be shorter than slow")
+		}
+		return s, p.check(StrategyMACrossover, "fast", "slow", "exponential")
+	case StrategyMeanReversion:
+		s := MeanReversion{Period: p.period("period", 20), EntryZ: p.value("entry_z", 2), ExitZ: p.value("exit_z", 0)}
+		if p.err == nil && (s.EntryZ <= 0 || s.ExitZ >= s.EntryZ) {
+			p.err = fmt.Errorf("entry_z must be positive and greater than exit_z")
+		}
+		return s, p.check(StrategyMeanReversion, "period", "entry_z", "exit_z")
+	case StrategyBreakout:
+		s := Breakout{EntryPeriod: p.period("entry_period", 20), ExitPeriod: p.period("exit_period", 10)}
+		return s, p.check(StrategyBreakout, "entry_period", "exit_period")
+	default:
+		return nil, fmt.Errorf("unsupported strategy; use one of: %s, %s, %s", StrategyMACrossover, StrategyMeanReversion, StrategyBreakout)
+	}
+}
+
+type paramReader struct {
+	values map[string]float64
+	err    error
+}
+
+func strategyParams(values map[string]float64) *paramReader {
+	return &paramReader{values: values}
+}
+
+func (p *paramReader) value(key string, def float64) float64 {
+	if v, ok := p.values[key]; ok {
+		return v
+	}
+	return def
+}
+
+func (p *paramReader) period(key string, def int) int {
+	v := p.value(key, float64(def))
+	if v < 1 || v > 1000 || v != math.Trunc(v) {
+		if p.err == nil {
+			p.err = fmt.Errorf("%s must be a whole number of bars between 1 and 1000", key)
+		}
+		return def
+	}
+	return int(v)
+}
+
+// check reports the first invalid value or any parameter the strategy does not know.
+func (p *paramReader) check(strategy string, known ...string) error {
+	if p.err != nil {
+		return p.err
+	}
+	var unknown []string
+	for key := range p.values {
+		found := false
+		for _, k := range known {
+			found = found || k == key
+		}
+		if !found {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown parameters for %s: %s", strategy, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func closePrices(bars []Bar) []float64 {
+	out := make([]float64, len(bars))
+	for i, b := range bars {
+		out[i] = b.Close
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// EnsureAdmins grants the admin role to the accounts listed in ADMIN_EMAILS (comma separated).
+// It only ever promotes; run it after AutoMigrate. Accounts created later are promoted on the
+// next start.
+func EnsureAdmins(db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("nil db")
+	}
+	var emails []string
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+	if err := db.Exec(`UPDATE users SET role = 'admin' WHERE LOWER(email) IN ? AND role <> 'admin';`, emails).Error; err != nil {
+		return fmt.Errorf("promote admins: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// EnsurePortfolios enforces one default portfolio per user and moves ledger rows written before
+// portfolios existed into their owner's default portfolio. It is idempotent; run it after AutoMigrate.
+func EnsurePortfolios(db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("nil db")
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Before named portfolios, the single live portfolio per user was implicitly the default.
+		if err := tx.Exec(`DROP INDEX IF EXISTS portfolios_one_live;`).Error; err != nil {
+			return fmt.Errorf("drop live portfolio index: %w", err)
+		}
+		if err := tx.Exec(`
+			UPDATE portfolios p
+			SET is_default = true
+			WHERE p.kind = 'live'
+			  AND p.id = (SELECT min(l.id) FROM portfolios l WHERE l.user_id = p.user_id AND l.kind = 'live')
+			  AND NOT EXISTS (SELECT 1 FROM portfolios d WHERE d.user_id = p.user_id AND d.is_default);`).Error; err != nil {
+			return fmt.Errorf("mark default portfolios: %w", err)
+		}
+		if err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS portfolios_one_default ON portfolios (user_id) WHERE is_default;`).Error; err != nil {
+			return fmt.Errorf("create default portfolio index: %w", err)
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO portfolios (user_id, name, kind, is_default, created_at, updated_at)
+			SELECT DISTINCT e.user_id, 'Live', 'live', true, now(), now()
+			FROM user_ledger_entries e
+			WHERE e.portfolio_id = 0
+			ON CONFLICT DO NOTHING;`).Error; err != nil {
+			return fmt.Errorf("create default portfolios for legacy ledger rows: %w", err)
+		}
+		if err := tx.Exec(`
+			UPDATE user_ledger_entries e
+			SET portfolio_id = p.id
+			FROM portfolios p
+			WHERE e.portfolio_id = 0 AND p.user_id = e.user_id AND p.is_default;`).Error; err != nil {
+			return fmt.Errorf("backfill ledger portfolio ids: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/services"
+)
+
+type AccountHandler struct {
+	accountService services.AccountService
+}
+
+func NewAccountHandler(accountService services.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+type tokenRequest struct {
+	Token string `json:"token"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// VerifyEmail handles POST /auth/verify-email with the token from the emailed link.
+func (h *AccountHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(r.Context(), req.Token); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerification handles POST /auth/verify-email/resend for the signed-in user.
+func (h *AccountHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.accountService.SendVerification(r.Context(), claims.UserID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ForgotPassword handles POST /auth/forgot-password. It answers 202 whether or not the address
+// is registered.
+func (h *AccountHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.accountService.ForgotPassword(r.Context(), req.Email); err != nil {
+		fmt.Printf("Forgot password error: %v\n", err)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword handles POST /auth/reset-password and signs the user out of every session.
+func (h *AccountHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req services.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.accountService.ResetPassword(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
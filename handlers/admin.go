@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/ODawah/Trading-Insights/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminHandler struct {
+	adminService services.AdminService
+}
+
+func NewAdminHandler(adminService services.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+type setRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// ListUsers pages through accounts with ?limit= (default 100, max 500) and ?offset=.
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := parseLimit(query.Get("limit"), 100, 500)
+	offset := 0
+	if raw := query.Get("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+
+	users, err := h.adminService.ListUsers(r.Context(), limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(users)
+}
+
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.GetUser(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), adminStatusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(user)
+}
+
+func (h *AdminHandler) ListUserEvents(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := h.adminService.UserEvents(r.Context(), id, parseLimit(r.URL.Query().Get("limit"), 100, 500))
+	if err != nil {
+		http.Error(w, err.Error(), adminStatusForError(err))
+		return
+	}
+	if events == nil {
+		events = []models.AuthEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(events)
+}
+
+// SetRole grants or revokes the admin role. It takes effect on the user's next token.
+func (h *AdminHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req setRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.adminService.SetRole(r.Context(), claims.UserID, id, req.Role)
+	if err != nil {
+		http.Error(w, err.Error(), adminStatusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(user)
+}
+
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.DeleteUser(r.Context(), claims.UserID, id); err != nil {
+		http.Error(w, err.Error(), adminStatusForError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RunIngestion fetches a rate snapshot immediately.
+func (h *AdminHandler) RunIngestion(w http.ResponseWriter, r *http.Request) {
+	run, err := h.adminService.RunIngestion(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(run)
+}
+
+func (h *AdminHandler) SystemStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.adminService.Status(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+func parseUserID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func adminStatusForError(err error) int {
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
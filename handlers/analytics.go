@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -14,10 +16,26 @@ import (
 
 type AnalyticsHandler struct {
 	analytics services.AnalyticsService
+	profiles  services.ProfileService
 }
 
-func NewAnalyticsHandler(analytics services.AnalyticsService) *AnalyticsHandler {
-	return &AnalyticsHandler{analytics: analytics}
+func NewAnalyticsHandler(analytics services.AnalyticsService, profiles services.ProfileService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analytics: analytics,
+		profiles:  profiles,
+	}
+}
+
+// reportingCurrency is ?in= when given, otherwise the user's reporting currency from /me.
+func (h *AnalyticsHandler) reportingCurrency(ctx context.Context, userID uint, query url.Values) string {
+	if in := query.Get("in"); in != "" {
+		return in
+	}
+	currency, err := h.profiles.ReportingCurrency(ctx, userID)
+	if err != nil {
+		return ""
+	}
+	return currency
 }
 
 func (h *AnalyticsHandler) CrossRate(w http.ResponseWriter, r *http.Request) {
@@ -75,6 +93,91 @@ func (h *AnalyticsHandler) ChartCross(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(points)
 }
 
+// CrossCandles returns OHLC candles for any pair A/B, e.g. ?a=EUR&b=JPY&bucket=1h.
+func (h *AnalyticsHandler) CrossCandles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := parseOptionalFromTo(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := parseLimit(query.Get("limit"), 500, 5000)
+	candles, err := h.analytics.CrossCandles(r.Context(), query.Get("a"), query.Get("b"), query.Get("bucket"), from, to, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(candles)
+}
+
+// PairVolatility returns realized volatility for ?a=EUR&b=JPY&bucket=1d&windows=10,30,90.
+func (h *AnalyticsHandler) PairVolatility(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := parseOptionalFromTo(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var windows []int
+	for _, raw := range strings.Split(query.Get("windows"), ",") {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "invalid windows", http.StatusBadRequest)
+			return
+		}
+		windows = append(windows, n)
+	}
+
+	limit := parseLimit(query.Get("limit"), 500, 5000)
+	res, err := h.analytics.PairVolatility(r.Context(), query.Get("a"), query.Get("b"), query.Get("bucket"), windows, from, to, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// ReturnDistribution returns a histogram of log returns with skew and kurtosis for ?a=&b=[&bucket=1h&bins=20].
+func (h *AnalyticsHandler) ReturnDistribution(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := parseOptionalFromTo(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bins := 0
+	if raw := strings.TrimSpace(query.Get("bins")); raw != "" {
+		bins, err = strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "invalid bins", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := parseLimit(query.Get("limit"), 1000, 5000)
+	res, err := h.analytics.ReturnDistribution(r.Context(), query.Get("a"), query.Get("b"), query.Get("bucket"), bins, from, to, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
 func (h *AnalyticsHandler) Correlation(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	a := query.Get("a")
@@ -97,6 +200,55 @@ func (h *AnalyticsHandler) Correlation(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
+// CorrelationMatrix returns the N×N correlation of bucketed log returns for ?tickers=EUR,GBP,JPY.
+func (h *AnalyticsHandler) CorrelationMatrix(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var tickers []string
+	for _, t := range strings.Split(query.Get("tickers"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tickers = append(tickers, t)
+		}
+	}
+
+	from, to, err := parseOptionalFromTo(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := parseLimit(query.Get("limit"), 500, 5000)
+	res, err := h.analytics.CorrelationMatrix(r.Context(), tickers, query.Get("bucket"), from, to, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// RollingCorrelation returns the correlation of a pair over a sliding window of buckets.
+func (h *AnalyticsHandler) RollingCorrelation(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := parseOptionalFromTo(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	window := parseLimit(query.Get("window"), 30, 1000)
+	limit := parseLimit(query.Get("limit"), 1000, 5000)
+	res, err := h.analytics.RollingCorrelation(r.Context(), query.Get("a"), query.Get("b"), query.Get("bucket"), window, from, to, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
 func (h *AnalyticsHandler) ConvertAt(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	fromCur := query.Get("from")
@@ -131,7 +283,12 @@ func (h *AnalyticsHandler) PortfolioValue(w http.ResponseWriter, r *http.Request
 	}
 
 	query := r.URL.Query()
-	in := query.Get("in")
+	in := h.reportingCurrency(ctx, claims.UserID, query)
+	portfolios, err := parsePortfolioSelector(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	at := time.Now().UTC()
 	if raw := query.Get("at"); raw != "" {
@@ -143,9 +300,9 @@ func (h *AnalyticsHandler) PortfolioValue(w http.ResponseWriter, r *http.Request
 		at = parsed
 	}
 
-	res, err := h.analytics.PortfolioValueAt(ctx, claims.UserID, in, at)
+	res, err := h.analytics.PortfolioValueAt(ctx, claims.UserID, portfolios, in, at)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), statusForError(err))
 		return
 	}
 
@@ -162,7 +319,12 @@ func (h *AnalyticsHandler) PortfolioHistory(w http.ResponseWriter, r *http.Reque
 	}
 
 	query := r.URL.Query()
-	in := query.Get("in")
+	in := h.reportingCurrency(ctx, claims.UserID, query)
+	portfolios, err := parsePortfolioSelector(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	fromRaw := query.Get("from")
 	toRaw := query.Get("to")
@@ -182,9 +344,9 @@ func (h *AnalyticsHandler) PortfolioHistory(w http.ResponseWriter, r *http.Reque
 	}
 
 	limit := parseLimit(query.Get("limit"), 500, 2000)
-	points, err := h.analytics.PortfolioValueHistory(ctx, claims.UserID, in, from, to, limit)
+	points, err := h.analytics.PortfolioValueHistory(ctx, claims.UserID, portfolios, in, from, to, limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), statusForError(err))
 		return
 	}
 
@@ -192,6 +354,147 @@ func (h *AnalyticsHandler) PortfolioHistory(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(points)
 }
 
+// PortfolioPnL reports realized and unrealized P&L using fifo, lifo or average cost lots.
+func (h *AnalyticsHandler) PortfolioPnL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	portfolios, err := parsePortfolioSelector(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	at := time.Now().UTC()
+	if raw := query.Get("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			http.Error(w, "invalid 'at' timestamp; expected RFC3339", http.StatusBadRequest)
+			return
+		}
+		at = parsed
+	}
+
+	report, err := h.analytics.PortfolioPnL(ctx, claims.UserID, portfolios, h.reportingCurrency(ctx, claims.UserID, query), query.Get("method"), at)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// PortfolioReturns reports time- and money-weighted returns per day, month or year.
+func (h *AnalyticsHandler) PortfolioReturns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := parseRequiredFromTo(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	portfolios, err := parsePortfolioSelector(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.analytics.PortfolioReturns(ctx, claims.UserID, portfolios, h.reportingCurrency(ctx, claims.UserID, query), from, to, query.Get("period"))
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// PortfolioRisk reports volatility, drawdown, Sharpe/Sortino and VaR/CVaR over a sampled valuation series.
+func (h *AnalyticsHandler) PortfolioRisk(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := parseRequiredFromTo(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	portfolios, err := parsePortfolioSelector(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := services.RiskOptions{Interval: query.Get("interval")}
+	if raw := query.Get("rf"); raw != "" {
+		opts.RiskFreeRate, err = strconv.ParseFloat(raw, 64)
+		if err != nil {
+			http.Error(w, "invalid 'rf'; expected an annual rate such as 0.04", http.StatusBadRequest)
+			return
+		}
+	}
+	if raw := query.Get("confidence"); raw != "" {
+		opts.ConfidenceLevels, err = parseFloatList(raw)
+		if err != nil {
+			http.Error(w, "invalid 'confidence'; expected comma-separated levels such as 0.95,0.99", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res, err := h.analytics.PortfolioRisk(ctx, claims.UserID, portfolios, h.reportingCurrency(ctx, claims.UserID, query), from, to, opts)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func parseFloatList(raw string) ([]float64, error) {
+	var out []float64
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func parseRequiredFromTo(fromRaw, toRaw string) (time.Time, time.Time, error) {
+	if fromRaw == "" || toRaw == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("'from' and 'to' are required (RFC3339)")
+	}
+	from, to, err := parseOptionalFromTo(fromRaw, toRaw)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return *from, *to, nil
+}
+
 func parseOptionalFromTo(fromRaw, toRaw string) (*time.Time, *time.Time, error) {
 	var fromPtr *time.Time
 	var toPtr *time.Time
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ODawah/Trading-Insights/services"
+)
+
+type ArbitrageHandler struct {
+	arbitrage services.ArbitrageService
+}
+
+func NewArbitrageHandler(arbitrage services.ArbitrageService) *ArbitrageHandler {
+	return &ArbitrageHandler{arbitrage: arbitrage}
+}
+
+// ListDetections handles GET /analytics/arbitrage?kind=triangle&min_bps=10&from=&to=&limit=
+func (h *ArbitrageHandler) ListDetections(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := parseOptionalFromTo(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	minBps := 0.0
+	if raw := strings.TrimSpace(query.Get("min_bps")); raw != "" {
+		minBps, err = strconv.ParseFloat(raw, 64)
+		if err != nil {
+			http.Error(w, "invalid min_bps", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res, err := h.arbitrage.ListDetections(r.Context(), services.ArbitrageFilter{
+		Kind:   query.Get("kind"),
+		MinBps: minBps,
+		From:   from,
+		To:     to,
+		Limit:  parseLimit(query.Get("limit"), 200, 2000),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
@@ -2,20 +2,26 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/models"
 	"github.com/ODawah/Trading-Insights/services"
-	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
 	authService services.AuthService
+	audit       services.AuditService
 }
 
-func NewAuthHandler(authService services.AuthService) *AuthHandler {
+func NewAuthHandler(authService services.AuthService, audit services.AuditService) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
+		audit:       audit,
 	}
 }
 
@@ -36,8 +42,9 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Same status and body whether or not the email was already registered.
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -51,10 +58,13 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.authService.Login(ctx, &req)
+	response, challenge, err := h.authService.Login(ctx, &req)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Invalid email or password", http.StatusNotFound)
+		if writeTooManyAttempts(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			http.Error(w, "Invalid email or password", http.StatusUnauthorized)
 			return
 		}
 		fmt.Printf("Login error: %v\n", err)
@@ -64,5 +74,123 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	if challenge != nil {
+		// Second step: POST /auth/login/2fa with the challenge token and a code.
+		json.NewEncoder(w).Encode(challenge)
+		return
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// LoginMFA handles POST /auth/login/2fa, the second step of a two-factor login.
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req services.MFALoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.authService.LoginMFA(ctx, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidChallenge) || errors.Is(err, services.ErrInvalidMFACode) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		fmt.Printf("Two-factor login error: %v\n", err)
+		http.Error(w, "Failed to log in", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Refresh handles POST /auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.authService.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		fmt.Printf("Refresh error: %v\n", err)
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// Logout handles POST /auth/logout. The body may carry the session's refresh token so it is
+// revoked along with the access token.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req refreshRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.authService.Logout(ctx, claims, req.RefreshToken); err != nil {
+		fmt.Printf("Logout error: %v\n", err)
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListEvents handles GET /auth/events: the caller's recent authentication events.
+func (h *AuthHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	events, err := h.audit.List(r.Context(), claims.UserID, parseLimit(r.URL.Query().Get("limit"), 100, 500))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []models.AuthEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(events)
+}
+
+// writeTooManyAttempts answers a throttled authentication with 429 and Retry-After. It reports
+// false, writing nothing, for any other error.
+func writeTooManyAttempts(w http.ResponseWriter, err error) bool {
+	var throttled *services.TooManyAttemptsError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+	return true
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/ODawah/Trading-Insights/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type BacktestHandler struct {
+	backtestService services.BacktestService
+}
+
+func NewBacktestHandler(backtestService services.BacktestService) *BacktestHandler {
+	return &BacktestHandler{backtestService: backtestService}
+}
+
+// RunBacktest replays stored history through a built-in strategy and persists the run.
+func (h *BacktestHandler) RunBacktest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req services.BacktestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = claims.UserID
+
+	run, err := h.backtestService.Run(ctx, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(run)
+}
+
+func (h *BacktestHandler) GetBacktest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	run, err := h.backtestService.Get(ctx, claims.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrBacktestNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(run)
+}
+
+func (h *BacktestHandler) ListBacktests(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	runs, err := h.backtestService.List(ctx, claims.UserID, parseLimit(r.URL.Query().Get("limit"), 50, 200))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(runs)
+}
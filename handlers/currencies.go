@@ -122,7 +122,7 @@ func (h *CurrenciesHandler) GetCandlesHandler() http.HandlerFunc {
 			}
 		}
 
-		bucket := query.Get("bucket") // 1m,5m,15m,30m,1h,4h,1d
+		bucket := query.Get("bucket") // 1m,5m,15m,30m,1h,4h,1d,1w
 		rows, err := h.currencyService.FetchCandles(r.Context(), ticker, bucket, fromPtr, toPtr, limit)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -137,3 +137,30 @@ func (h *CurrenciesHandler) GetCandlesHandler() http.HandlerFunc {
 		}
 	}
 }
+
+// GetMoversHandler handles GET /currencies/movers?window=1h|24h|7d&sort=percent|absolute|volatility&limit=
+func (h *CurrenciesHandler) GetMoversHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		limit := 0
+		if raw := query.Get("limit"); raw != "" {
+			if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+				limit = parsed
+			}
+		}
+
+		movers, err := h.currencyService.Movers(r.Context(), query.Get("window"), query.Get("sort"), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(movers); err != nil {
+			http.Error(w, "Failed to encode movers", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/ODawah/Trading-Insights/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type ExportHandler struct {
+	exports services.ExportService
+}
+
+func NewExportHandler(exports services.ExportService) *ExportHandler {
+	return &ExportHandler{exports: exports}
+}
+
+// RequestExport handles POST /me/export. The archive is built in the background; poll
+// GET /me/export/{id} until it is ready.
+func (h *ExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	export, err := h.exports.Request(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/me/export/%d", export.ID))
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(export)
+}
+
+func (h *ExportHandler) ListExports(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	exports, err := h.exports.List(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(exports)
+}
+
+// GetExport handles GET /me/export/{id}; a ready export carries its download_url.
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := parseExportID(w, r)
+	if !ok {
+		return
+	}
+
+	export, err := h.exports.Status(r.Context(), claims.UserID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrDataExportNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(export)
+}
+
+// DownloadExport handles GET /me/export/{id}/download?expires=&signature=. The zip is streamed
+// as it is read; an error part-way through can only cut the response short.
+func (h *ExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseExportID(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	archive, err := h.exports.Download(r.Context(), id, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidExportLink) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		fmt.Printf("Export download error: %v\n", err)
+		http.Error(w, "Failed to read export", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archive.Filename))
+	w.Header().Set("Content-Length", strconv.FormatInt(archive.Size, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	if err := archive.Stream(w); err != nil {
+		fmt.Printf("Export download %d interrupted: %v\n", id, err)
+	}
+}
+
+func parseExportID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid export id", http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/ODawah/Trading-Insights/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type IndicatorsHandler struct {
+	indicatorService services.IndicatorService
+}
+
+func NewIndicatorsHandler(indicatorService services.IndicatorService) *IndicatorsHandler {
+	return &IndicatorsHandler{indicatorService: indicatorService}
+}
+
+// GetIndicators handles GET /currencies/{ticker}/indicators?bucket=1h&indicators=sma:20,rsi:14[&quote=JPY]
+func (h *IndicatorsHandler) GetIndicators(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := parseOptionalFromTo(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var names []string
+	for _, name := range strings.Split(query.Get("indicators"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	res, err := h.indicatorService.Compute(r.Context(), &services.IndicatorRequest{
+		Ticker:     chi.URLParam(r, "ticker"),
+		Quote:      query.Get("quote"),
+		Bucket:     query.Get("bucket"),
+		From:       from,
+		To:         to,
+		Limit:      parseLimit(query.Get("limit"), 500, 5000),
+		Indicators: names,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ODawah/Trading-Insights/services"
+)
+
+type JWKSHandler struct {
+	signingKeys services.SigningKeyService
+}
+
+func NewJWKSHandler(signingKeys services.SigningKeyService) *JWKSHandler {
+	return &JWKSHandler{signingKeys: signingKeys}
+}
+
+// GetJWKS serves the public signing keys so other services can verify access tokens.
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = json.NewEncoder(w).Encode(h.signingKeys.JWKS())
+}
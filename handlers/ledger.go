@@ -2,11 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/repository"
 	"github.com/ODawah/Trading-Insights/services"
 	"github.com/go-chi/chi/v5"
 )
@@ -35,14 +36,14 @@ func (h *LedgerHandler) RecordExchange(w http.ResponseWriter, r *http.Request) {
 	}
 	req.UserID = claims.UserID // Enforce ownership from JWT, not body
 
-	// Allow client to omit executed_at; defaulted in service if zero.
-	if req.ExecutedAt.IsZero() {
-		req.ExecutedAt = time.Now().UTC()
-	}
-
+	// executed_at may be omitted; the service defaults it, and ignores it for priced trades.
 	tradeID, err := h.ledgerService.RecordExchange(ctx, &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		if errors.Is(err, repository.ErrQuoteNotFound) {
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+		http.Error(w, err.Error(), statusForError(err))
 		return
 	}
 
@@ -69,12 +70,18 @@ func (h *LedgerHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	entries, err := h.ledgerService.ListEntries(ctx, claims.UserID, query.Get("currency"), limit)
+	portfolios, err := parsePortfolioSelector(query)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	entries, err := h.ledgerService.ListEntries(ctx, claims.UserID, portfolios, query.Get("currency"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(entries)
 }
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/ODawah/Trading-Insights/services"
+)
+
+type MeHandler struct {
+	profiles    services.ProfileService
+	authService services.AuthService
+}
+
+func NewMeHandler(profiles services.ProfileService, authService services.AuthService) *MeHandler {
+	return &MeHandler{
+		profiles:    profiles,
+		authService: authService,
+	}
+}
+
+// GetProfile handles GET /me
+func (h *MeHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.profiles.Get(r.Context(), claims.UserID)
+	if err != nil {
+		writeMeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(user)
+}
+
+// UpdateProfile handles PATCH /me
+func (h *MeHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req services.ProfileUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.profiles.Update(r.Context(), claims.UserID, &req)
+	if err != nil {
+		writeMeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(user)
+}
+
+// ChangePassword handles POST /me/password. Every other session is signed out; the response
+// carries a fresh token pair for this one.
+func (h *MeHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req services.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.authService.ChangePassword(r.Context(), claims, &req)
+	if err != nil {
+		writeMeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// DeleteAccount handles DELETE /me
+func (h *MeHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req services.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.profiles.Delete(r.Context(), claims.UserID, &req); err != nil {
+		writeMeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeMeError reports a throttled re-authentication as 429 with Retry-After, and everything
+// else by meStatusForError.
+func writeMeError(w http.ResponseWriter, err error) {
+	if writeTooManyAttempts(w, err) {
+		return
+	}
+	http.Error(w, err.Error(), meStatusForError(err))
+}
+
+// meStatusForError reports a failed re-authentication as 401 and validation errors as 400.
+func meStatusForError(err error) int {
+	if errors.Is(err, services.ErrInvalidCredentials) || errors.Is(err, services.ErrInvalidMFACode) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
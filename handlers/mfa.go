@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/services"
+)
+
+type MFAHandler struct {
+	mfaService services.MFAService
+}
+
+func NewMFAHandler(mfaService services.MFAService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService}
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// GetStatus reports whether two-factor is on and how many recovery codes are left.
+func (h *MFAHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	h.withUser(w, r, func(userID uint) (any, error) {
+		return h.mfaService.Status(r.Context(), userID)
+	})
+}
+
+// Enroll returns a new TOTP secret and otpauth URI; confirm it with Verify.
+func (h *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	h.withUser(w, r, func(userID uint) (any, error) {
+		return h.mfaService.Enroll(r.Context(), userID)
+	})
+}
+
+// Verify enables two-factor and returns the recovery codes, which are not shown again.
+func (h *MFAHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	h.withUser(w, r, func(userID uint) (any, error) {
+		return h.mfaService.Verify(r.Context(), userID, req.Code)
+	})
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	h.withUser(w, r, func(userID uint) (any, error) {
+		return h.mfaService.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	})
+}
+
+func (h *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req services.DisableMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.mfaService.Disable(r.Context(), claims.UserID, &req); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// withUser authenticates the caller and encodes fn's result without caching, since the
+// results carry secrets.
+func (h *MFAHandler) withUser(w http.ResponseWriter, r *http.Request, fn func(userID uint) (any, error)) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	result, err := fn(claims.UserID)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// writeMFAError answers a throttled code check with 429 and Retry-After, and everything else
+// by mfaStatusForError.
+func writeMFAError(w http.ResponseWriter, err error) {
+	if writeTooManyAttempts(w, err) {
+		return
+	}
+	http.Error(w, err.Error(), mfaStatusForError(err))
+}
+
+func mfaStatusForError(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode), errors.Is(err, services.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrMFAAlreadyEnabled), errors.Is(err, services.ErrMFANotEnabled):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
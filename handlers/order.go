@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/ODawah/Trading-Insights/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type OrderHandler struct {
+	orderService services.OrderService
+}
+
+func NewOrderHandler(orderService services.OrderService) *OrderHandler {
+	return &OrderHandler{orderService: orderService}
+}
+
+// PlaceOrder rests a limit, stop or OCO order; OCO requests return both legs.
+func (h *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req services.OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = claims.UserID
+
+	orders, err := h.orderService.Place(ctx, &req)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(orders)
+}
+
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	orders, err := h.orderService.List(ctx, claims.UserID, query.Get("status"), parseLimit(query.Get("limit"), 100, 500))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(orders)
+}
+
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.orderService.Get(ctx, claims.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), orderStatusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(order)
+}
+
+// CancelOrder cancels an open order; cancelling either OCO leg cancels both.
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.orderService.Cancel(ctx, claims.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), orderStatusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(order)
+}
+
+func orderStatusForError(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrOrderNotOpen):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
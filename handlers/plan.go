@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/ODawah/Trading-Insights/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type PlanHandler struct {
+	planService services.PlanService
+}
+
+func NewPlanHandler(planService services.PlanService) *PlanHandler {
+	return &PlanHandler{planService: planService}
+}
+
+// CreatePlan schedules a recurring conversion at the stored market rate.
+func (h *PlanHandler) CreatePlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req services.PlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = claims.UserID
+
+	plan, err := h.planService.Create(ctx, &req)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(plan)
+}
+
+func (h *PlanHandler) ListPlans(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	plans, err := h.planService.List(ctx, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(plans)
+}
+
+func (h *PlanHandler) GetPlan(w http.ResponseWriter, r *http.Request) {
+	h.withPlan(w, r, func(userID, id uint) (any, error) {
+		return h.planService.Get(r.Context(), userID, id)
+	})
+}
+
+func (h *PlanHandler) UpdatePlan(w http.ResponseWriter, r *http.Request) {
+	var req services.PlanUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	h.withPlan(w, r, func(userID, id uint) (any, error) {
+		return h.planService.Update(r.Context(), userID, id, &req)
+	})
+}
+
+func (h *PlanHandler) PausePlan(w http.ResponseWriter, r *http.Request) {
+	h.withPlan(w, r, func(userID, id uint) (any, error) {
+		return h.planService.Pause(r.Context(), userID, id)
+	})
+}
+
+func (h *PlanHandler) ResumePlan(w http.ResponseWriter, r *http.Request) {
+	h.withPlan(w, r, func(userID, id uint) (any, error) {
+		return h.planService.Resume(r.Context(), userID, id)
+	})
+}
+
+// ListExecutions returns the plan's run history: fills, retries and skipped occurrences.
+func (h *PlanHandler) ListExecutions(w http.ResponseWriter, r *http.Request) {
+	limit := parseLimit(r.URL.Query().Get("limit"), 100, 500)
+	h.withPlan(w, r, func(userID, id uint) (any, error) {
+		executions, err := h.planService.Executions(r.Context(), userID, id, limit)
+		if executions == nil {
+			executions = []models.PlanExecution{}
+		}
+		return executions, err
+	})
+}
+
+func (h *PlanHandler) DeletePlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid plan id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.planService.Delete(ctx, claims.UserID, uint(id)); err != nil {
+		http.Error(w, err.Error(), planStatusForError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// withPlan authenticates the caller, parses the {id} path parameter and encodes fn's result.
+func (h *PlanHandler) withPlan(w http.ResponseWriter, r *http.Request, fn func(userID, id uint) (any, error)) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid plan id", http.StatusBadRequest)
+		return
+	}
+
+	res, err := fn(claims.UserID, uint(id))
+	if err != nil {
+		http.Error(w, err.Error(), planStatusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func planStatusForError(err error) int {
+	if errors.Is(err, repository.ErrPlanNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/ODawah/Trading-Insights/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type PortfolioHandler struct {
+	portfolioService services.PortfolioService
+}
+
+func NewPortfolioHandler(portfolioService services.PortfolioService) *PortfolioHandler {
+	return &PortfolioHandler{portfolioService: portfolioService}
+}
+
+func (h *PortfolioHandler) ListPortfolios(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	portfolios, err := h.portfolioService.List(ctx, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(portfolios)
+}
+
+// CreatePortfolio opens a named live or paper portfolio; paper portfolios are funded with a virtual deposit.
+func (h *PortfolioHandler) CreatePortfolio(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req services.CreatePortfolioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = claims.UserID
+
+	portfolio, err := h.portfolioService.Create(ctx, &req)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(portfolio)
+}
+
+func (h *PortfolioHandler) GetPortfolio(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	portfolioID, err := parsePortfolioID(chi.URLParam(r, "id"))
+	if err != nil || portfolioID == 0 {
+		http.Error(w, "invalid portfolio id", http.StatusBadRequest)
+		return
+	}
+
+	portfolio, err := h.portfolioService.Get(ctx, claims.UserID, portfolioID)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(portfolio)
+}
+
+func (h *PortfolioHandler) RenamePortfolio(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	portfolioID, err := parsePortfolioID(chi.URLParam(r, "id"))
+	if err != nil || portfolioID == 0 {
+		http.Error(w, "invalid portfolio id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	portfolio, err := h.portfolioService.Rename(ctx, claims.UserID, portfolioID, req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(portfolio)
+}
+
+// DeletePortfolio removes a portfolio. Paper portfolios go with their entries; live ones must be empty.
+func (h *PortfolioHandler) DeletePortfolio(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	portfolioID, err := parsePortfolioID(chi.URLParam(r, "id"))
+	if err != nil || portfolioID == 0 {
+		http.Error(w, "invalid portfolio id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.portfolioService.Delete(ctx, claims.UserID, portfolioID); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deposit adds virtual funds to a paper portfolio.
+func (h *PortfolioHandler) Deposit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	portfolioID, err := parsePortfolioID(chi.URLParam(r, "id"))
+	if err != nil || portfolioID == 0 {
+		http.Error(w, "invalid portfolio id", http.StatusBadRequest)
+		return
+	}
+
+	var req services.PaperDepositRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = claims.UserID
+	req.PortfolioID = portfolioID
+
+	tradeID, err := h.portfolioService.DepositPaper(ctx, &req)
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"trade_id": tradeID,
+	})
+}
+
+// parsePortfolioSelector reads portfolio_id (a number, or "all" to aggregate) and portfolio_kind,
+// which picks live or paper portfolios for the aggregate. An empty portfolio_id is the default portfolio.
+func parsePortfolioSelector(query url.Values) (services.PortfolioSelector, error) {
+	sel := services.PortfolioSelector{Kind: strings.TrimSpace(query.Get("portfolio_kind"))}
+	raw := strings.TrimSpace(query.Get("portfolio_id"))
+	if strings.EqualFold(raw, "all") {
+		sel.All = true
+		return sel, nil
+	}
+	if sel.Kind != "" {
+		return sel, fmt.Errorf("portfolio_kind only applies to portfolio_id=all")
+	}
+	id, err := parsePortfolioID(raw)
+	if err != nil {
+		return sel, err
+	}
+	sel.ID = id
+	return sel, nil
+}
+
+// parsePortfolioID reads an optional portfolio id; 0 means the user's default portfolio.
+func parsePortfolioID(raw string) (uint, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid portfolio_id")
+	}
+	return uint(id), nil
+}
+
+// statusForError maps lookups of resources the caller does not own to 404, deletes of portfolios
+// that still hold entries to 409 and everything else to 400.
+func statusForError(err error) int {
+	if errors.Is(err, repository.ErrPortfolioNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, repository.ErrPortfolioNotEmpty) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ODawah/Trading-Insights/middleware"
+	"github.com/ODawah/Trading-Insights/services"
+)
+
+type QuoteHandler struct {
+	quoteService services.QuoteService
+}
+
+func NewQuoteHandler(quoteService services.QuoteService) *QuoteHandler {
+	return &QuoteHandler{quoteService: quoteService}
+}
+
+// CreateQuote locks a rate for a pair and amount; execute it with POST /ledger/exchange and quote_id.
+func (h *QuoteHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req services.QuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = claims.UserID
+
+	quote, err := h.quoteService.CreateQuote(ctx, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(quote)
+}
@@ -0,0 +1,228 @@
+// Package indicators computes technical indicators over evenly bucketed price series.
+//
+// Every function returns slices aligned index-for-index with its input. Positions where an
+// indicator is still warming up (not enough history yet) hold NaN.
+package indicators
+
+import "math"
+
+// SMA is the simple moving average over period values.
+func SMA(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	if period <= 0 {
+		return out
+	}
+	sum := 0.0
+	valid := 0
+	for i, v := range values {
+		if math.IsNaN(v) {
+			sum, valid = 0, 0
+			continue
+		}
+		sum += v
+		valid++
+		if valid > period {
+			sum -= values[i-period]
+			valid = period
+		}
+		if valid == period {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA is the exponential moving average seeded with the SMA of the first period values.
+// Leading NaNs are skipped, so EMA can be chained onto another indicator's output.
+func EMA(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	if period <= 0 {
+		return out
+	}
+	start := firstValid(values)
+	if start < 0 || len(values)-start < period {
+		return out
+	}
+	alpha := 2.0 / float64(period+1)
+	seed := 0.0
+	for i := start; i < start+period; i++ {
+		seed += values[i]
+	}
+	prev := seed / float64(period)
+	out[start+period-1] = prev
+	for i := start + period; i < len(values); i++ {
+		prev = alpha*values[i] + (1-alpha)*prev
+		out[i] = prev
+	}
+	return out
+}
+
+// RSI is Wilder's relative strength index on a 0-100 scale.
+func RSI(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	if period <= 0 || len(values) <= period {
+		return out
+	}
+	gain, loss := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+	avgGain := gain / float64(period)
+	avgLoss := loss / float64(period)
+	out[period] = rsiValue(avgGain, avgLoss)
+	for i := period + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		g, l := 0.0, 0.0
+		if change > 0 {
+			g = change
+		} else {
+			l = -change
+		}
+		avgGain = (avgGain*float64(period-1) + g) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + l) / float64(period)
+		out[i] = rsiValue(avgGain, avgLoss)
+	}
+	return out
+}
+
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	rs := avgGain / avgLoss
+	return 100 - 100/(1+rs)
+}
+
+// MACD returns the MACD line (fast EMA - slow EMA), its signal EMA and the histogram.
+func MACD(values []float64, fast, slow, signal int) (line, signalLine, histogram []float64) {
+	fastEMA := EMA(values, fast)
+	slowEMA := EMA(values, slow)
+	line = nanSlice(len(values))
+	for i := range values {
+		if !math.IsNaN(fastEMA[i]) && !math.IsNaN(slowEMA[i]) {
+			line[i] = fastEMA[i] - slowEMA[i]
+		}
+	}
+	signalLine = EMA(line, signal)
+	histogram = nanSlice(len(values))
+	for i := range values {
+		if !math.IsNaN(line[i]) && !math.IsNaN(signalLine[i]) {
+			histogram[i] = line[i] - signalLine[i]
+		}
+	}
+	return line, signalLine, histogram
+}
+
+// Bollinger returns the SMA middle band and bands k population standard deviations away.
+func Bollinger(values []float64, period int, k float64) (middle, upper, lower []float64) {
+	middle = SMA(values, period)
+	upper = nanSlice(len(values))
+	lower = nanSlice(len(values))
+	for i := range values {
+		if math.IsNaN(middle[i]) {
+			continue
+		}
+		ss := 0.0
+		for j := i - period + 1; j <= i; j++ {
+			d := values[j] - middle[i]
+			ss += d * d
+		}
+		sd := math.Sqrt(ss / float64(period))
+		upper[i] = middle[i] + k*sd
+		lower[i] = middle[i] - k*sd
+	}
+	return middle, upper, lower
+}
+
+// TrueRange is the greatest of high-low and the gaps from the previous close.
+func TrueRange(high, low, close []float64) []float64 {
+	n := minLen(high, low, close)
+	out := nanSlice(n)
+	for i := 0; i < n; i++ {
+		tr := high[i] - low[i]
+		if i > 0 {
+			tr = math.Max(tr, math.Abs(high[i]-close[i-1]))
+			tr = math.Max(tr, math.Abs(low[i]-close[i-1]))
+		}
+		out[i] = tr
+	}
+	return out
+}
+
+// ATR is Wilder's average true range.
+func ATR(high, low, close []float64, period int) []float64 {
+	tr := TrueRange(high, low, close)
+	out := nanSlice(len(tr))
+	if period <= 0 || len(tr) < period {
+		return out
+	}
+	sum := 0.0
+	for i := 0; i < period; i++ {
+		sum += tr[i]
+	}
+	prev := sum / float64(period)
+	out[period-1] = prev
+	for i := period; i < len(tr); i++ {
+		prev = (prev*float64(period-1) + tr[i]) / float64(period)
+		out[i] = prev
+	}
+	return out
+}
+
+// Stochastic returns %K over kPeriod bars and %D, the dPeriod SMA of %K.
+func Stochastic(high, low, close []float64, kPeriod, dPeriod int) (k, d []float64) {
+	n := minLen(high, low, close)
+	k = nanSlice(n)
+	if kPeriod > 0 {
+		for i := kPeriod - 1; i < n; i++ {
+			hh, ll := high[i], low[i]
+			for j := i - kPeriod + 1; j < i; j++ {
+				hh = math.Max(hh, high[j])
+				ll = math.Min(ll, low[j])
+			}
+			if hh == ll {
+				k[i] = 50
+				continue
+			}
+			k[i] = 100 * (close[i] - ll) / (hh - ll)
+		}
+	}
+	d = SMA(k, dPeriod)
+	return k, d
+}
+
+func nanSlice(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+func firstValid(values []float64) int {
+	for i, v := range values {
+		if !math.IsNaN(v) {
+			return i
+		}
+	}
+	return -1
+}
+
+func minLen(a, b, c []float64) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	if len(c) < n {
+		n = len(c)
+	}
+	return n
+}
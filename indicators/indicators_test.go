@@ -0,0 +1,55 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMA(t *testing.T) {
+	out := SMA([]float64{1, 2, 3, 4, 5}, 3)
+	assert.True(t, math.IsNaN(out[0]))
+	assert.True(t, math.IsNaN(out[1]))
+	assert.Equal(t, []float64{2, 3, 4}, out[2:])
+}
+
+func TestEMASeedsWithSMA(t *testing.T) {
+	out := EMA([]float64{2, 4, 6, 8}, 3)
+	assert.InDelta(t, 4.0, out[2], 1e-12)
+	assert.InDelta(t, 0.5*8+0.5*4, out[3], 1e-12)
+}
+
+func TestRSIBounds(t *testing.T) {
+	up := RSI([]float64{1, 2, 3, 4, 5, 6}, 3)
+	assert.Equal(t, 100.0, up[5])
+
+	mixed := RSI([]float64{10, 11, 10, 11, 10}, 2)
+	assert.InDelta(t, 50.0, mixed[2], 1e-9)
+}
+
+func TestMACDLinesAlign(t *testing.T) {
+	values := make([]float64, 40)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	line, signal, hist := MACD(values, 3, 6, 4)
+	assert.True(t, math.IsNaN(line[4]))
+	assert.False(t, math.IsNaN(line[5]))
+	assert.True(t, math.IsNaN(signal[7]))
+	assert.False(t, math.IsNaN(signal[8]))
+	assert.InDelta(t, line[20]-signal[20], hist[20], 1e-12)
+}
+
+func TestStochasticAndATR(t *testing.T) {
+	high := []float64{10, 12, 11, 13}
+	low := []float64{8, 9, 9, 10}
+	closes := []float64{9, 11, 10, 12}
+
+	k, _ := Stochastic(high, low, closes, 3, 2)
+	assert.InDelta(t, 100*(10-8)/(12-8.0), k[2], 1e-12)
+
+	atr := ATR(high, low, closes, 2)
+	assert.InDelta(t, (2+3)/2.0, atr[1], 1e-12)
+	assert.InDelta(t, ((2.5+2)/2+3)/2, atr[3], 1e-12)
+}
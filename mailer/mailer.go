@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers account emails (verification links, password resets).
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv picks the sender named by MAIL_DRIVER: "smtp" (the default) or "log", which only
+// writes each message to the log and must be chosen explicitly. SMTP needs SMTP_HOST;
+// SMTP_PORT defaults to 587, SMTP_USERNAME/SMTP_PASSWORD enable PLAIN auth and MAIL_FROM is the
+// sender address.
+func FromEnv() (Sender, error) {
+	switch driver := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_DRIVER"))); driver {
+	case "log":
+		log.Println("MAIL_DRIVER=log; account emails are written to the log, not sent")
+		return LogSender{}, nil
+	case "", "smtp":
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", driver)
+	}
+	host := strings.TrimSpace(os.Getenv("SMTP_HOST"))
+	if host == "" {
+		return nil, fmt.Errorf("SMTP_HOST is required to send account emails; set MAIL_DRIVER=log to write them to the log instead")
+	}
+	port := strings.TrimSpace(os.Getenv("SMTP_PORT"))
+	if port == "" {
+		port = "587"
+	}
+	from := strings.TrimSpace(os.Getenv("MAIL_FROM"))
+	if from == "" {
+		from = "no-reply@" + host
+	}
+	return &SMTPSender{
+		Addr:     net.JoinHostPort(host, port),
+		Host:     host,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}, nil
+}
+
+// LogSender is the local stand-in: it prints each message instead of sending it.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("📧 Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPSender sends through an SMTP relay, upgrading to TLS when the server offers it.
+type SMTPSender struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("send mail: invalid header value")
+	}
+	body := "From: " + s.From + "\r\n" +
+		"To: " + msg.To + "\r\n" +
+		"Subject: " + msg.Subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		strings.ReplaceAll(msg.Body, "\n", "\r\n")
+	if err := smtp.SendMail(s.Addr, auth, s.From, []string{msg.To}, []byte(body)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"codnect.io/chrono"
+	auth "github.com/ODawah/Trading-Insights/authentication"
 	"github.com/ODawah/Trading-Insights/database"
+	"github.com/ODawah/Trading-Insights/mailer"
 	"github.com/ODawah/Trading-Insights/models"
 	"github.com/ODawah/Trading-Insights/repository"
 	"github.com/ODawah/Trading-Insights/server"
@@ -25,13 +27,33 @@ func main() {
 	}
 	err = pg.AutoMigrate(
 		models.User{},
+		models.RefreshToken{},
+		models.SigningKey{},
+		models.APIKey{},
+		models.RecoveryCode{},
+		models.AccountToken{},
+		models.DataExport{},
+		models.DataExportChunk{},
+		models.AuthEvent{},
 		models.WatchItem{},
 		models.Currency{},
+		models.Portfolio{},
 		models.UserLedgerEntry{},
+		models.ArbitrageDetection{},
+		models.BacktestRun{},
+		models.Order{},
+		models.ConversionPlan{},
+		models.PlanExecution{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
+	if err := database.EnsurePortfolios(pg); err != nil {
+		log.Fatalf("Failed to migrate portfolios: %v", err)
+	}
+	if err := database.EnsureAdmins(pg); err != nil {
+		log.Fatalf("Failed to promote admins: %v", err)
+	}
 	if err := database.EnsureTimescale(pg); err != nil {
 		log.Printf("TimescaleDB not enabled (continuing without hypertables): %v", err)
 	}
@@ -45,20 +67,69 @@ func main() {
 	currencyRepo := repository.NewCurrencyRepository(redis, pg)
 	userRepo := repository.NewUserRepository(pg)
 	ledgerRepo := repository.NewLedgerRepository(pg)
-	ingestionService := services.NewIngestionAPIClient(currencyRepo)
+	portfolioRepo := repository.NewPortfolioRepository(pg)
+	arbitrageService := services.NewArbitrageService(repository.NewArbitrageRepository(pg), services.ArbitrageConfigFromEnv())
 	currencyService := services.NewCurrencyService(currencyRepo)
-	authService := services.NewAuthService(userRepo)
+	keyring := auth.NewKeyring()
+	signingKeyService := services.NewSigningKeyService(repository.NewSigningKeyRepository(pg), keyring, services.SigningKeyConfigFromEnv())
+	if err := signingKeyService.Rotate(context.Background()); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	loginAttemptRepo := repository.NewLoginAttemptRepository(redis)
+	mfaService := services.NewMFAService(userRepo, repository.NewRecoveryCodeRepository(pg), repository.NewChallengeRepository(redis), loginAttemptRepo)
+	refreshRepo := repository.NewRefreshTokenRepository(pg)
+	revocationRepo := repository.NewRevocationRepository(redis)
+	auditService := services.NewAuditService(repository.NewAuthEventRepository(pg))
+	mailSender, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mail: %v", err)
+	}
+	accountService := services.NewAccountService(userRepo, repository.NewAccountTokenRepository(pg), refreshRepo, revocationRepo, mailSender, auditService)
+	loginGuard := services.NewLoginGuard(loginAttemptRepo, services.LoginGuardConfigFromEnv())
+	authService := services.NewAuthService(userRepo, refreshRepo, revocationRepo, mfaService, accountService, loginGuard, auditService, keyring)
 	watchListService := services.NewWatchListService(repository.NewWatchListRepository(pg))
-	ledgerService := services.NewLedgerService(ledgerRepo)
-	analyticsService := services.NewAnalyticsService(currencyRepo, ledgerRepo)
+	quoteRepo := repository.NewQuoteRepository(redis)
+	analyticsService := services.NewAnalyticsService(currencyRepo, ledgerRepo, portfolioRepo)
+	marketPricing := services.MarketPricingFromEnv()
+	ledgerService := services.NewLedgerService(ledgerRepo, portfolioRepo, quoteRepo, analyticsService, marketPricing)
+	orderService := services.NewOrderService(repository.NewOrderRepository(pg), portfolioRepo, ledgerService)
+	ingestionService := services.NewIngestionAPIClient(currencyRepo, arbitrageService, orderService)
+	planService := services.NewPlanService(repository.NewPlanRepository(pg), portfolioRepo, ledgerService, services.PlanConfigFromEnv())
+	quoteService := services.NewQuoteService(quoteRepo, analyticsService, marketPricing)
+	indicatorService := services.NewIndicatorService(currencyRepo, analyticsService)
+	portfolioService := services.NewPortfolioService(portfolioRepo, ledgerRepo)
+	backtestService := services.NewBacktestService(repository.NewBacktestRepository(pg), analyticsService, marketPricing)
+	apiKeyService := services.NewAPIKeyService(repository.NewAPIKeyRepository(pg), userRepo)
+	exportConfig, err := services.ExportConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure data exports: %v", err)
+	}
+	exportService := services.NewExportService(repository.NewDataExportRepository(pg), repository.NewPersonalDataRepository(pg), auditService, exportConfig)
+	profileService := services.NewProfileService(userRepo, refreshRepo, revocationRepo, mfaService, accountService, loginGuard, auditService)
+	adminService := services.NewAdminService(userRepo, refreshRepo, revocationRepo, repository.NewSystemRepository(pg, redis), ingestionService, keyring, auditService, profileService)
 
 	serverServices := &server.Services{
-		Ingestion: ingestionService,
-		Currency:  currencyService,
-		Auth:      authService, // ← New
-		WatchList: watchListService, // ← New
-		Ledger:    ledgerService,
-		Analytics: analyticsService,
+		Ingestion:   ingestionService,
+		Currency:    currencyService,
+		Auth:        authService,      // ← New
+		WatchList:   watchListService, // ← New
+		Ledger:      ledgerService,
+		Quotes:      quoteService,
+		Analytics:   analyticsService,
+		Indicators:  indicatorService,
+		Arbitrage:   arbitrageService,
+		Backtests:   backtestService,
+		Portfolios:  portfolioService,
+		Orders:      orderService,
+		Plans:       planService,
+		SigningKeys: signingKeyService,
+		Admin:       adminService,
+		APIKeys:     apiKeyService,
+		MFA:         mfaService,
+		Accounts:    accountService,
+		Audit:       auditService,
+		Profiles:    profileService,
+		Exports:     exportService,
 	}
 
 	r := server.Routes(serverServices)
@@ -79,6 +150,34 @@ func main() {
 		log.Fatalf("Failed to schedule task: %v", err)
 	}
 
+	_, err = taskScheduler.ScheduleAtFixedRate(func(ctx context.Context) {
+		if err := planService.RunDue(ctx); err != nil {
+			log.Printf("Error running conversion plans: %v", err)
+		}
+	}, time.Minute)
+	if err != nil {
+		log.Fatalf("Failed to schedule plan runner: %v", err)
+	}
+
+	_, err = taskScheduler.ScheduleAtFixedRate(func(ctx context.Context) {
+		if err := exportService.RunDue(ctx); err != nil {
+			log.Printf("Error building data exports: %v", err)
+		}
+	}, 15*time.Second)
+	if err != nil {
+		log.Fatalf("Failed to schedule data export runner: %v", err)
+	}
+
+	// Rotates keys when due and reloads keys created by other instances.
+	_, err = taskScheduler.ScheduleAtFixedRate(func(ctx context.Context) {
+		if err := signingKeyService.Rotate(ctx); err != nil {
+			log.Printf("Error rotating JWT signing keys: %v", err)
+		}
+	}, 5*time.Minute)
+	if err != nil {
+		log.Fatalf("Failed to schedule key rotation: %v", err)
+	}
+
 	err = http.ListenAndServe(":8000", r)
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,36 +14,84 @@ type contextKey string
 
 const UserContextKey contextKey = "user"
 
-// AuthMiddleware validates JWT token and adds user info to context
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get token from Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
-			return
-		}
-
-		// Extract token (format: "Bearer <token>")
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid authorization header format. Use: Bearer <token>", http.StatusUnauthorized)
-			return
-		}
-
-		tokenString := parts[1]
-
-		// Validate token
-		claims, err := auth.ValidateToken(tokenString)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
-			return
-		}
-
-		// Add user info to context
-		ctx := context.WithValue(r.Context(), UserContextKey, claims)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// TokenVerifier checks an access token's signature and claims, and whether its jti has been
+// revoked (logout, refresh token reuse).
+type TokenVerifier interface {
+	ValidateToken(token string) (*auth.Claims, error)
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// APIKeyVerifier resolves an API key to claims limited to the key's scopes. Keys that cannot
+// be used yield auth.ErrInvalidAPIKey; any other error means the key could not be checked.
+type APIKeyVerifier interface {
+	Authenticate(ctx context.Context, key string) (*auth.Claims, error)
+}
+
+// NewAuthMiddleware authenticates the request with either a JWT access token
+// ("Authorization: Bearer <token>") or an API key ("X-API-Key: <key>", or a Bearer value
+// starting with tik_), rejects revoked tokens and adds user info to context.
+func NewAuthMiddleware(tokens TokenVerifier, keys APIKeyVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			credential := r.Header.Get("X-API-Key")
+			if credential == "" {
+				// Get token from Authorization header
+				authHeader := r.Header.Get("Authorization")
+				if authHeader == "" {
+					http.Error(w, "Authorization header required", http.StatusUnauthorized)
+					return
+				}
+
+				// Extract token (format: "Bearer <token>")
+				parts := strings.Split(authHeader, " ")
+				if len(parts) != 2 || parts[0] != "Bearer" {
+					http.Error(w, "Invalid authorization header format. Use: Bearer <token>", http.StatusUnauthorized)
+					return
+				}
+				credential = parts[1]
+			} else if !auth.IsAPIKey(credential) {
+				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+
+			var claims *auth.Claims
+			if auth.IsAPIKey(credential) {
+				var err error
+				claims, err = keys.Authenticate(r.Context(), credential)
+				if err != nil {
+					if errors.Is(err, auth.ErrInvalidAPIKey) {
+						http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					} else {
+						http.Error(w, "Could not verify API key", http.StatusServiceUnavailable)
+					}
+					return
+				}
+			} else {
+				// Validate token
+				var err error
+				claims, err = tokens.ValidateToken(credential)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
+					return
+				}
+
+				// Reject revoked tokens; fail closed if the deny-list cannot be read
+				revoked, err := tokens.IsRevoked(r.Context(), claims.ID)
+				if err != nil {
+					http.Error(w, "Could not verify token", http.StatusServiceUnavailable)
+					return
+				}
+				if revoked {
+					http.Error(w, "Invalid token: token has been revoked", http.StatusUnauthorized)
+					return
+				}
+			}
+
+			// Add user info to context
+			ctx := context.WithValue(r.Context(), UserContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 // GetUserFromContext extracts user claims from context
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// RequireRole lets a request through only when the authenticated user holds one of roles.
+// It must run after the auth middleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetUserFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !claims.HasRole(roles...) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission lets a request through only when the user's token grants perm.
+// It must run after the auth middleware.
+func RequirePermission(perm string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetUserFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !claims.HasPermission(perm) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope lets API-key requests through only when the key was granted scope. Requests
+// authenticated with a login token always pass. It must run after the auth middleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return requireScope(func(*http.Request) string { return scope })
+}
+
+// RequireReadWriteScope checks read for GET and HEAD requests and write for everything else.
+func RequireReadWriteScope(read, write string) func(http.Handler) http.Handler {
+	return requireScope(func(r *http.Request) string {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return read
+		}
+		return write
+	})
+}
+
+func requireScope(scopeFor func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetUserFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if scope := scopeFor(r); !claims.HasScope(scope) {
+				http.Error(w, "Forbidden: API key lacks scope "+scope, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession rejects API keys, for routes that manage the account itself (keys, logout,
+// administration). It must run after the auth middleware.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := GetUserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if claims.IsAPIKey() {
+			http.Error(w, "Forbidden: API keys cannot use this endpoint", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+)
+
+// maxUserAgent bounds what is stored in the audit trail.
+const maxUserAgent = 256
+
+// ClientInfo records the caller's IP and user agent in the request context. The IP comes from
+// RemoteAddr, so put chi's RealIP in front only when a trusted proxy sets the headers.
+func ClientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		ua := r.UserAgent()
+		if len(ua) > maxUserAgent {
+			ua = ua[:maxUserAgent]
+		}
+		ctx := auth.WithClient(r.Context(), auth.Client{IP: ip, UserAgent: ua})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
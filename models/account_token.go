@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Purposes of an AccountToken.
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+// AccountToken is a single-use, time-limited token mailed to a user to verify their email or
+// reset their password. Only the SHA-256 of the token is stored.
+type AccountToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Purpose   string     `json:"purpose" gorm:"type:text;not null"`
+	TokenHash string     `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (AccountToken) TableName() string {
+	return "account_tokens"
+}
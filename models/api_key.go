@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// APIKey is a long-lived credential for scripts and bots. Only the SHA-256 of the key is
+// stored; Prefix is the non-secret head of the key, kept so users can tell their keys apart.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:text;not null"`
+	Prefix     string     `json:"prefix" gorm:"type:text;not null"`
+	KeyHash    string     `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	Scopes     []string   `json:"scopes" gorm:"type:jsonb;serializer:json;not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// Active reports whether the key can still authenticate at now.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Kinds of findings recorded by the arbitrage scanner.
+const (
+	ArbitrageKindTriangle     = "triangle"      // a three-leg cycle whose rates do not multiply back to 1
+	ArbitrageKindInvalidQuote = "invalid_quote" // a single quote that cannot be right (<= 0, NaN, base != 1)
+)
+
+// ArbitrageDetection is one flagged cycle or quote from an ingested snapshot.
+// DeviationBps is (product of leg rates - 1) in basis points; positive means the cycle
+// returns more than it started with when traded in Path order.
+type ArbitrageDetection struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	DetectedAt   time.Time `json:"detected_at" gorm:"not null;index:idx_arbitrage_time,sort:desc"`
+	SnapshotTime time.Time `json:"snapshot_time" gorm:"not null"`
+	Kind         string    `json:"kind" gorm:"type:text;not null"`
+	Path         string    `json:"path" gorm:"type:text;not null"` // e.g. USD>EUR>JPY>USD
+	Product      float64   `json:"product"`
+	DeviationBps float64   `json:"deviation_bps"`
+	Detail       string    `json:"detail,omitempty" gorm:"type:text"`
+}
+
+func (ArbitrageDetection) TableName() string {
+	return "arbitrage_detections"
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Auth events recorded in the audit trail.
+const (
+	AuthEventSignup         = "signup"
+	AuthEventSignupExisting = "signup_existing_email"
+	AuthEventLoginSuccess   = "login_success"
+	AuthEventLoginFailure   = "login_failure"
+	AuthEventLoginThrottled = "login_throttled"
+	AuthEventLockout        = "lockout"
+	AuthEventMFAChallenge   = "mfa_challenge"
+	AuthEventMFAFailure     = "mfa_failure"
+	AuthEventLogout         = "logout"
+	AuthEventRefreshReuse   = "refresh_token_reuse"
+	AuthEventPasswordForgot = "password_reset_requested"
+	AuthEventPasswordReset  = "password_reset"
+	AuthEventEmailVerified  = "email_verified"
+	AuthEventEmailChanged   = "email_changed"
+	AuthEventPasswordChange = "password_changed"
+	AuthEventAccountDeleted = "account_deleted"
+	AuthEventDataExport     = "data_export_requested"
+	AuthEventDataDownload   = "data_export_downloaded"
+)
+
+// AuthEvent is one entry in the authentication audit trail. UserID is nil when the email did
+// not match an account.
+type AuthEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    *uint     `json:"user_id,omitempty" gorm:"index"`
+	Email     string    `json:"email" gorm:"type:text;index"`
+	Event     string    `json:"event" gorm:"type:text;not null"`
+	IP        string    `json:"ip" gorm:"type:text"`
+	UserAgent string    `json:"user_agent" gorm:"type:text"`
+	Detail    string    `json:"detail,omitempty" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+func (AuthEvent) TableName() string {
+	return "auth_events"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// BacktestRun is a persisted backtest: the inputs it was run with and the full result
+// (equity curve, trades, stats) as JSON.
+type BacktestRun struct {
+	ID             string         `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID         uint           `json:"user_id" gorm:"not null;index:idx_backtest_user_time,priority:1"`
+	Strategy       string         `json:"strategy" gorm:"type:text;not null"`
+	Params         datatypes.JSON `json:"params" gorm:"type:jsonb"`
+	CurrencyA      string         `json:"currency_a" gorm:"type:text;not null"`
+	CurrencyB      string         `json:"currency_b" gorm:"type:text;not null"`
+	Bucket         string         `json:"bucket,omitempty" gorm:"type:text"` // empty means raw snapshots
+	From           time.Time      `json:"from"`
+	To             time.Time      `json:"to"`
+	InitialCapital float64        `json:"initial_capital"`
+	SpreadBps      float64        `json:"spread_bps"`
+	FeeAmount      float64        `json:"fee_amount"`
+	Result         datatypes.JSON `json:"result" gorm:"type:jsonb"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime;index:idx_backtest_user_time,priority:2,sort:desc"`
+}
+
+func (BacktestRun) TableName() string {
+	return "backtest_runs"
+}
@@ -17,3 +17,26 @@ type Currency struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+// MarketMover summarizes how one ticker's stored rate (units per base) moved over a window.
+// Volatility is annualized from bucketed log returns inside the window.
+type MarketMover struct {
+	Ticker         string  `json:"ticker"`
+	StartRate      float64 `json:"start_rate"`
+	EndRate        float64 `json:"end_rate"`
+	Change         float64 `json:"change"`
+	ChangePercent  float64 `json:"change_percent"`
+	Volatility     float64 `json:"volatility"`
+	RankPercent    int     `json:"rank_percent"`    // by |change_percent|, 1 = largest move
+	RankAbsolute   int     `json:"rank_absolute"`   // by |change|
+	RankVolatility int     `json:"rank_volatility"` // by volatility
+}
+
+// MarketMovers is the cached market overview for one window.
+type MarketMovers struct {
+	Window string        `json:"window"`
+	Base   string        `json:"base"`
+	From   time.Time     `json:"from"`
+	To     time.Time     `json:"to"`
+	Movers []MarketMover `json:"movers"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Data export states.
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+	DataExportExpired = "expired" // archive dropped after ExpiresAt
+)
+
+// DataExport is a requested archive of everything held about a user. It is built in the
+// background; the zip lives in DataExportChunk rows until ExpiresAt and is then discarded.
+// LeaseUntil keeps other runners off a job while it is being built, and ClaimToken names the
+// runner holding it so a runner that lost its lease can no longer write.
+type DataExport struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      uint       `json:"-" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"type:text;not null;index"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	LeaseUntil  *time.Time `json:"-"`
+	ClaimToken  string     `json:"-" gorm:"type:text"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// DataExportChunk is one piece of an export's zip, so archives are written and served a piece
+// at a time instead of as one value.
+type DataExportChunk struct {
+	ExportID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Seq      int    `gorm:"primaryKey;autoIncrement:false"`
+	Data     []byte `gorm:"type:bytea;not null"`
+}
+
+func (DataExportChunk) TableName() string {
+	return "data_export_chunks"
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Order types. Both sell FromAmount of FromCurrency for ToCurrency once the mid rate
+// (units of ToCurrency per one FromCurrency) crosses TriggerRate.
+const (
+	OrderTypeLimit = "limit" // fills when the rate rises to TriggerRate or above
+	OrderTypeStop  = "stop"  // fills when the rate falls to TriggerRate or below
+)
+
+// Order states.
+const (
+	OrderStatusOpen      = "open"
+	OrderStatusFilled    = "filled"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusExpired   = "expired"
+)
+
+// Order is a resting limit or stop order. The two legs of an OCO (one-cancels-other) pair
+// share OCOGroup; filling or cancelling one leg cancels the other.
+type Order struct {
+	ID           string     `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;index:idx_order_user_time,priority:1"`
+	PortfolioID  uint       `json:"portfolio_id" gorm:"not null"`
+	Type         string     `json:"type" gorm:"type:text;not null"`
+	Status       string     `json:"status" gorm:"type:text;not null;index:idx_order_status"`
+	OCOGroup     string     `json:"oco_group,omitempty" gorm:"type:text;index"`
+	FromCurrency string     `json:"from_currency" gorm:"type:text;not null"`
+	ToCurrency   string     `json:"to_currency" gorm:"type:text;not null"`
+	FromAmount   float64    `json:"from_amount"`
+	TriggerRate  float64    `json:"trigger_rate"`
+	GoodTill     *time.Time `json:"good_till,omitempty"`
+	TriggerMid   float64    `json:"trigger_mid,omitempty"` // snapshot mid rate that triggered the fill
+	ToAmount     float64    `json:"to_amount,omitempty"`   // filled amount, net of spread
+	TradeID      string     `json:"trade_id,omitempty" gorm:"type:text"`
+	Reason       string     `json:"reason,omitempty" gorm:"type:text"` // why a cancelled order was closed
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime;index:idx_order_user_time,priority:2,sort:desc"`
+}
+
+func (Order) TableName() string {
+	return "orders"
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Plan frequencies. All schedules are evaluated in UTC.
+const (
+	PlanFrequencyDaily   = "daily"
+	PlanFrequencyWeekly  = "weekly"  // on Weekday
+	PlanFrequencyMonthly = "monthly" // on DayOfMonth, or the month's last day when shorter
+)
+
+// Plan states.
+const (
+	PlanStatusActive = "active"
+	PlanStatusPaused = "paused"
+)
+
+// Plan execution outcomes.
+const (
+	PlanExecutionFilled  = "filled"
+	PlanExecutionRetry   = "retry"   // attempt failed, another one is scheduled
+	PlanExecutionSkipped = "skipped" // attempts exhausted, the occurrence is dropped
+)
+
+// ConversionPlan converts FromAmount of FromCurrency into ToCurrency on a recurring schedule
+// at the stored market rate. NextRunAt is the next scheduled occurrence; RetryAt, when set,
+// is an earlier retry of the current occurrence after a failed attempt.
+type ConversionPlan struct {
+	ID           uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID       uint       `json:"user_id" gorm:"not null;index:idx_plan_user"`
+	PortfolioID  uint       `json:"portfolio_id" gorm:"not null"`
+	Name         string     `json:"name,omitempty" gorm:"type:text"`
+	FromCurrency string     `json:"from_currency" gorm:"type:text;not null"`
+	ToCurrency   string     `json:"to_currency" gorm:"type:text;not null"`
+	FromAmount   float64    `json:"from_amount"`
+	Frequency    string     `json:"frequency" gorm:"type:text;not null"`
+	Weekday      int        `json:"weekday"`      // 0 = Sunday, weekly plans only
+	DayOfMonth   int        `json:"day_of_month"` // 1-31, monthly plans only
+	Hour         int        `json:"hour"`
+	Minute       int        `json:"minute"`
+	Status       string     `json:"status" gorm:"type:text;not null;index:idx_plan_due,priority:1"`
+	NextRunAt    time.Time  `json:"next_run_at" gorm:"not null;index:idx_plan_due,priority:2"`
+	RetryAt      *time.Time `json:"retry_at,omitempty"`
+	Attempts     int        `json:"attempts"` // failed attempts for the current occurrence
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (ConversionPlan) TableName() string {
+	return "conversion_plans"
+}
+
+// PlanExecution is one attempt at a plan occurrence.
+type PlanExecution struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	PlanID       uint      `json:"plan_id" gorm:"not null;index:idx_plan_execution,priority:1"`
+	UserID       uint      `json:"user_id" gorm:"not null"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	ExecutedAt   time.Time `json:"executed_at" gorm:"not null;index:idx_plan_execution,priority:2,sort:desc"`
+	Attempt      int       `json:"attempt"`
+	Status       string    `json:"status" gorm:"type:text;not null"`
+	TradeID      string    `json:"trade_id,omitempty" gorm:"type:text"`
+	FromAmount   float64   `json:"from_amount"`
+	ToAmount     float64   `json:"to_amount,omitempty"`
+	Error        string    `json:"error,omitempty" gorm:"type:text"`
+}
+
+func (PlanExecution) TableName() string {
+	return "plan_executions"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Portfolio kinds. Live portfolios hold real activity; paper portfolios are funded with
+// virtual deposits and are never aggregated with live ones.
+const (
+	PortfolioKindLive  = "live"
+	PortfolioKindPaper = "paper"
+)
+
+// Portfolio is an account dimension on the ledger. Every UserLedgerEntry belongs to one.
+// Each user has exactly one default (live) portfolio, used when a request names none.
+type Portfolio struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint      `json:"user_id" gorm:"not null;index:idx_portfolio_user"`
+	Name      string    `json:"name" gorm:"type:text;not null"`
+	Kind      string    `json:"kind" gorm:"type:text;not null"`
+	IsDefault bool      `json:"is_default" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Portfolio) TableName() string {
+	return "portfolios"
+}
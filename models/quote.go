@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Quote is a price locked for one user and one conversion until ExpiresAt.
+// Quotes live only in Redis and are consumed when executed.
+type Quote struct {
+	ID           string    `json:"id"`
+	UserID       uint      `json:"user_id"`
+	FromCurrency string    `json:"from_currency"`
+	FromAmount   float64   `json:"from_amount"`
+	ToCurrency   string    `json:"to_currency"`
+	ToAmount     float64   `json:"to_amount"`
+	MidRate      float64   `json:"mid_rate"`
+	Rate         float64   `json:"rate"`
+	SpreadBps    float64   `json:"spread_bps"`
+	QuoteTime    time.Time `json:"quote_time"` // snapshot the rate was taken from
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RecoveryCode is a single-use fallback for a user's TOTP device. Only the SHA-256 of the
+// normalized code is stored.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `json:"-" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// RefreshToken is one link in a rotating refresh-token family. Only the SHA-256 of the token
+// is stored. Each refresh marks the presented token used and issues the next one in the same
+// family; presenting a used token again is treated as theft and revokes the whole family.
+// AccessJTI and AccessExpiresAt record the access token issued alongside, so revoking the
+// family can also cut off access tokens that are still live.
+type RefreshToken struct {
+	ID              uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID        string     `json:"family_id" gorm:"type:text;not null;index"`
+	TokenHash       string     `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	AccessJTI       string     `json:"-" gorm:"type:text"`
+	AccessExpiresAt time.Time  `json:"-"`
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt          *time.Time `json:"used_at,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
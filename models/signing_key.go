@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SigningKey is a stored JWT signing key shared by every instance of the API. The private key
+// is PKCS#8 PEM; anyone with database access can mint tokens, so protect the table accordingly.
+type SigningKey struct {
+	KID        string    `json:"kid" gorm:"primaryKey;type:text"`
+	Algorithm  string    `json:"algorithm" gorm:"type:text;not null"`
+	PrivateKey []byte    `json:"-" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"not null;index"`
+}
+
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
@@ -1,15 +1,30 @@
 package models
 
 import (
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 type User struct {
 	gorm.Model
-	Name         string        `json:"name"`
-	Password     string        `gorm:"not null" json:"-"`
-	Email        string        `gorm:"uniqueIndex;not null" json:"email"`
+	Name            string     `json:"name"`
+	Password        string     `gorm:"not null" json:"-"`
+	Email           string     `gorm:"uniqueIndex;not null" json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	Role            string     `gorm:"type:text;not null;default:user" json:"role"` // see authentication.Role*
+
+	// Preferences: the default currency for valuations and the IANA zone clients display in.
+	ReportingCurrency string `gorm:"type:text;not null;default:USD" json:"reporting_currency"`
+	Timezone          string `gorm:"type:text;not null;default:UTC" json:"timezone"`
+
+	// TOTP two-factor state. The secret is set at enrollment and only enforced once verified;
+	// TOTPLastStep is the last accepted time step, so a code cannot be replayed.
+	TOTPSecret   string `gorm:"type:text" json:"-"`
+	TOTPEnabled  bool   `gorm:"not null;default:false" json:"totp_enabled"`
+	TOTPLastStep int64  `gorm:"not null;default:0" json:"-"`
+
 	Transactions []Transaction `gorm:"foreignKey:UserID"`
 	WatchItems   []WatchItem   `gorm:"foreignKey:UserID"`
 }
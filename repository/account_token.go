@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrAccountTokenInvalid covers unknown, expired and already used tokens alike.
+var ErrAccountTokenInvalid = errors.New("invalid or expired token")
+
+type AccountTokenRepository interface {
+	Create(ctx context.Context, token *models.AccountToken) error
+	Consume(ctx context.Context, purpose, hash string, at time.Time) (*models.AccountToken, error)
+	InvalidateUser(ctx context.Context, userID uint, purpose string, at time.Time) error
+}
+
+type accountTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountTokenRepository(db *gorm.DB) AccountTokenRepository {
+	return &accountTokenRepository{db: db}
+}
+
+func (r *accountTokenRepository) Create(ctx context.Context, token *models.AccountToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("create account token: %w", err)
+	}
+	return nil
+}
+
+// Consume marks a live token used and returns it, in one statement so a token can only ever
+// be redeemed once.
+func (r *accountTokenRepository) Consume(ctx context.Context, purpose, hash string, at time.Time) (*models.AccountToken, error) {
+	var tokens []models.AccountToken
+	res := r.db.WithContext(ctx).Model(&tokens).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, at).
+		Update("used_at", at)
+	if res.Error != nil {
+		return nil, fmt.Errorf("consume account token: %w", res.Error)
+	}
+	if len(tokens) == 0 {
+		return nil, ErrAccountTokenInvalid
+	}
+	return &tokens[0], nil
+}
+
+// InvalidateUser retires the user's outstanding tokens for purpose, so only the newest link
+// mailed works.
+func (r *accountTokenRepository) InvalidateUser(ctx context.Context, userID uint, purpose string, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&models.AccountToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", at).Error; err != nil {
+		return fmt.Errorf("invalidate account tokens: %w", err)
+	}
+	return nil
+}
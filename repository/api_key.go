@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// lastUsedResolution bounds how often authenticating with a key writes its last_used_at.
+const lastUsedResolution = time.Minute
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error)
+	FindByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	Revoke(ctx context.Context, userID, id uint, at time.Time) error
+	Touch(ctx context.Context, id uint, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+	return nil
+}
+
+// ListByUser returns the user's keys, newest first, including revoked and expired ones.
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find api key: %w", err)
+	}
+	return &key, nil
+}
+
+// Revoke revokes one of the user's keys. Revoking an already revoked key is a no-op.
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID, id uint, at time.Time) error {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	if err := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error; err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	return nil
+}
+
+// Touch records that the key was used. Writes are skipped while the stored value is recent,
+// so a busy key costs at most one update per minute.
+func (r *apiKeyRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-lastUsedResolution)).
+		Update("last_used_at", at).Error; err != nil {
+		return fmt.Errorf("touch api key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+)
+
+type ArbitrageRepository interface {
+	Store(ctx context.Context, detections []models.ArbitrageDetection) error
+	List(ctx context.Context, kind string, minBps float64, from, to *time.Time, limit int) ([]models.ArbitrageDetection, error)
+}
+
+type arbitrageRepository struct {
+	db *gorm.DB
+}
+
+func NewArbitrageRepository(db *gorm.DB) ArbitrageRepository {
+	return &arbitrageRepository{db: db}
+}
+
+func (r *arbitrageRepository) Store(ctx context.Context, detections []models.ArbitrageDetection) error {
+	if len(detections) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(&detections).Error; err != nil {
+		return fmt.Errorf("store arbitrage detections: %w", err)
+	}
+	return nil
+}
+
+// List returns detections newest first. minBps filters on the absolute deviation.
+func (r *arbitrageRepository) List(ctx context.Context, kind string, minBps float64, from, to *time.Time, limit int) ([]models.ArbitrageDetection, error) {
+	if limit <= 0 || limit > 2000 {
+		limit = 200
+	}
+	query := r.db.WithContext(ctx).Model(&models.ArbitrageDetection{})
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if minBps > 0 {
+		query = query.Where("ABS(deviation_bps) >= ?", minBps)
+	}
+	if from != nil {
+		query = query.Where("detected_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("detected_at <= ?", *to)
+	}
+
+	var rows []models.ArbitrageDetection
+	if err := query.
+		Order("detected_at DESC, id DESC").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list arbitrage detections: %w", err)
+	}
+	return rows, nil
+}
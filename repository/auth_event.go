@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+)
+
+type AuthEventRepository interface {
+	Create(ctx context.Context, event *models.AuthEvent) error
+	ListByUser(ctx context.Context, userID uint, limit int) ([]models.AuthEvent, error)
+}
+
+type authEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthEventRepository(db *gorm.DB) AuthEventRepository {
+	return &authEventRepository{db: db}
+}
+
+func (r *authEventRepository) Create(ctx context.Context, event *models.AuthEvent) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		return fmt.Errorf("create auth event: %w", err)
+	}
+	return nil
+}
+
+// ListByUser returns the user's most recent events first.
+func (r *authEventRepository) ListByUser(ctx context.Context, userID uint, limit int) ([]models.AuthEvent, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	var events []models.AuthEvent
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("list auth events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+)
+
+var ErrBacktestNotFound = errors.New("backtest not found")
+
+type BacktestRepository interface {
+	Create(ctx context.Context, run *models.BacktestRun) error
+	Get(ctx context.Context, userID uint, id string) (*models.BacktestRun, error)
+	ListByUser(ctx context.Context, userID uint, limit int) ([]models.BacktestRun, error)
+}
+
+type backtestRepository struct {
+	db *gorm.DB
+}
+
+func NewBacktestRepository(db *gorm.DB) BacktestRepository {
+	return &backtestRepository{db: db}
+}
+
+func (r *backtestRepository) Create(ctx context.Context, run *models.BacktestRun) error {
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		return fmt.Errorf("create backtest run: %w", err)
+	}
+	return nil
+}
+
+func (r *backtestRepository) Get(ctx context.Context, userID uint, id string) (*models.BacktestRun, error) {
+	var run models.BacktestRun
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBacktestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get backtest run %s: %w", id, err)
+	}
+	return &run, nil
+}
+
+// ListByUser returns run summaries newest first; the result payload is left out.
+func (r *backtestRepository) ListByUser(ctx context.Context, userID uint, limit int) ([]models.BacktestRun, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	var rows []models.BacktestRun
+	if err := r.db.WithContext(ctx).
+		Omit("result").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list backtest runs: %w", err)
+	}
+	return rows, nil
+}
@@ -16,13 +16,21 @@ type CurrencyRepository interface {
 	GetSnapShotCache(ctx context.Context) (*models.Snapshot, error)
 	StoreSnapshotCache(ctx context.Context, snapshot *models.Snapshot) error
 	GetSnapShotPG(ctx context.Context) (*models.Snapshot, error)
+	GetSnapShotAtOrBefore(ctx context.Context, at time.Time) (*models.Snapshot, error)
 	StoreSnapShotPG(ctx context.Context, snapshot *models.Snapshot) error
 	ListHistory(ctx context.Context, ticker string, from, to *time.Time, limit int) ([]models.Currency, error)
 	ListSnapshotTimes(ctx context.Context, from, to *time.Time, limit int) ([]time.Time, error)
+	ListBucketedSnapshotTimes(ctx context.Context, bucketInterval string, from, to *time.Time, limit int) ([]time.Time, error)
 	ListRatesAtTimes(ctx context.Context, tickers []string, times []time.Time) ([]models.Currency, error)
 	LatestRatesAtOrBefore(ctx context.Context, tickers []string, at time.Time) ([]models.Currency, error)
+	LatestRatesAtOrBeforeEach(ctx context.Context, tickers []string, times []time.Time) ([][]models.Currency, error)
 	ListPairedRates(ctx context.Context, tickerA, tickerB string, from, to *time.Time, limit int) ([]PairedRateRow, error)
 	ListCandles(ctx context.Context, ticker string, bucketInterval string, from, to *time.Time, limit int) ([]CandleRow, error)
+	ListBucketedCloses(ctx context.Context, tickers []string, bucketInterval string, from, to *time.Time, limit int) ([]BucketCloseRow, error)
+	ListPairedCandles(ctx context.Context, tickerA, tickerB string, bucketInterval string, from, to *time.Time, limit int) ([]CandleRow, error)
+	ListReturnVolatility(ctx context.Context, bucketInterval string, from, to time.Time) ([]ReturnVolatilityRow, error)
+	GetMoversCache(ctx context.Context, window string) (*models.MarketMovers, error)
+	StoreMoversCache(ctx context.Context, movers *models.MarketMovers, ttl time.Duration) error
 }
 
 type currencyRepository struct {
@@ -81,11 +89,23 @@ func (r *currencyRepository) StoreSnapShotPG(ctx context.Context, snapshot *mode
 }
 
 func (r *currencyRepository) GetSnapShotPG(ctx context.Context) (*models.Snapshot, error) {
+	return r.snapshotAtOrBefore(ctx, nil)
+}
+
+// GetSnapShotAtOrBefore returns the last full snapshot stored at or before at.
+func (r *currencyRepository) GetSnapShotAtOrBefore(ctx context.Context, at time.Time) (*models.Snapshot, error) {
+	return r.snapshotAtOrBefore(ctx, &at)
+}
+
+func (r *currencyRepository) snapshotAtOrBefore(ctx context.Context, at *time.Time) (*models.Snapshot, error) {
 	var latest time.Time
-	if err := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Model(&models.Currency{}).
-		Select("MAX(fetched_time)").
-		Scan(&latest).Error; err != nil {
+		Select("MAX(fetched_time)")
+	if at != nil {
+		query = query.Where("fetched_time <= ?", *at)
+	}
+	if err := query.Scan(&latest).Error; err != nil {
 		return nil, fmt.Errorf("get latest snapshot timestamp: %w", err)
 	}
 	if latest.IsZero() {
@@ -161,6 +181,40 @@ func (r *currencyRepository) ListSnapshotTimes(ctx context.Context, from, to *ti
 	return times, nil
 }
 
+// ListBucketedSnapshotTimes returns the last snapshot time in each bucket so long ranges can be
+// sampled evenly instead of truncated to the first N snapshots.
+func (r *currencyRepository) ListBucketedSnapshotTimes(ctx context.Context, bucketInterval string, from, to *time.Time, limit int) ([]time.Time, error) {
+	if strings.TrimSpace(bucketInterval) == "" {
+		return nil, fmt.Errorf("bucket interval is required")
+	}
+	if limit <= 0 || limit > 5000 {
+		limit = 500
+	}
+
+	query := `
+		SELECT max(fetched_time) AS time
+		FROM currencies
+		WHERE 1 = 1
+	`
+	args := []any{}
+	if from != nil {
+		query += " AND fetched_time >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += " AND fetched_time <= ?"
+		args = append(args, *to)
+	}
+	query += " GROUP BY time_bucket(?::interval, fetched_time) ORDER BY time ASC LIMIT ?"
+	args = append(args, bucketInterval, limit)
+
+	var times []time.Time
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&times).Error; err != nil {
+		return nil, fmt.Errorf("list bucketed snapshot times: %w", err)
+	}
+	return times, nil
+}
+
 func (r *currencyRepository) ListRatesAtTimes(ctx context.Context, tickers []string, times []time.Time) ([]models.Currency, error) {
 	if len(tickers) == 0 || len(times) == 0 {
 		return nil, nil
@@ -196,6 +250,52 @@ func (r *currencyRepository) LatestRatesAtOrBefore(ctx context.Context, tickers
 	return rows, nil
 }
 
+// ratesAtTimesBatch bounds how many points one LatestRatesAtOrBeforeEach query looks up.
+const ratesAtTimesBatch = 1000
+
+// LatestRatesAtOrBeforeEach is LatestRatesAtOrBefore for many points in time at once: out[i]
+// holds the rates as of times[i]. It costs one query per ratesAtTimesBatch points instead of
+// one per point.
+func (r *currencyRepository) LatestRatesAtOrBeforeEach(ctx context.Context, tickers []string, times []time.Time) ([][]models.Currency, error) {
+	out := make([][]models.Currency, len(times))
+	if len(tickers) == 0 {
+		return out, nil
+	}
+
+	type rateAtRow struct {
+		Idx int `gorm:"column:idx"`
+		models.Currency
+	}
+	for start := 0; start < len(times); start += ratesAtTimesBatch {
+		end := min(start+ratesAtTimesBatch, len(times))
+		values := make([]string, 0, end-start)
+		args := make([]any, 0, 2*(end-start)+1)
+		for i := start; i < end; i++ {
+			values = append(values, "(?::int, ?::timestamptz)")
+			args = append(args, i, times[i])
+		}
+		args = append(args, tickers)
+		query := `
+			SELECT t.idx, c.*
+			FROM (VALUES ` + strings.Join(values, ", ") + `) AS t(idx, at)
+			CROSS JOIN LATERAL (
+				SELECT DISTINCT ON (ticker) *
+				FROM currencies
+				WHERE ticker IN ? AND fetched_time <= t.at
+				ORDER BY ticker, fetched_time DESC, id DESC
+			) c
+		`
+		var rows []rateAtRow
+		if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("latest rates at or before each time: %w", err)
+		}
+		for _, row := range rows {
+			out[row.Idx] = append(out[row.Idx], row.Currency)
+		}
+	}
+	return out, nil
+}
+
 type PairedRateRow struct {
 	Time  time.Time `gorm:"column:time"`
 	RateA float64   `gorm:"column:rate_a"`
@@ -208,6 +308,19 @@ func (r *currencyRepository) ListPairedRates(ctx context.Context, tickerA, ticke
 		limit = 1000
 	}
 
+	query, args, timeCol := pairedRatesQuery(tickerA, tickerB, from, to)
+	query += " ORDER BY " + timeCol + " ASC LIMIT ?"
+	args = append(args, limit)
+
+	var rows []PairedRateRow
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list paired rates: %w", err)
+	}
+	return rows, nil
+}
+
+// pairedRatesQuery selects (time, rate_a, rate_b, base) for two tickers on shared snapshot times.
+func pairedRatesQuery(tickerA, tickerB string, from, to *time.Time) (string, []any, string) {
 	// In this codebase we ingest rates with a single base (USD). The base currency itself
 	// typically is not stored as a ticker row, so when either side is USD we synthesize
 	// it as a constant 1.0 series aligned to the other ticker's timestamps.
@@ -258,15 +371,7 @@ func (r *currencyRepository) ListPairedRates(ctx context.Context, tickerA, ticke
 		query += " AND " + timeCol + " <= ?"
 		args = append(args, *to)
 	}
-
-	query += " ORDER BY " + timeCol + " ASC LIMIT ?"
-	args = append(args, limit)
-
-	var rows []PairedRateRow
-	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
-		return nil, fmt.Errorf("list paired rates: %w", err)
-	}
-	return rows, nil
+	return query, args, timeCol
 }
 
 type CandleRow struct {
@@ -278,6 +383,8 @@ type CandleRow struct {
 	Samples int64     `gorm:"column:samples" json:"samples"`
 }
 
+// ListCandles builds OHLC candles for one ticker. Like ListPairedCandles, it keeps the most
+// recent limit buckets when the range holds more, still in ascending order.
 func (r *currencyRepository) ListCandles(ctx context.Context, ticker string, bucketInterval string, from, to *time.Time, limit int) ([]CandleRow, error) {
 	if strings.TrimSpace(ticker) == "" {
 		return nil, fmt.Errorf("ticker is required")
@@ -289,7 +396,7 @@ func (r *currencyRepository) ListCandles(ctx context.Context, ticker string, buc
 		limit = 500
 	}
 
-	query := `
+	inner := `
 		SELECT
 			time_bucket(?::interval, fetched_time) AS bucket,
 			first(rate, fetched_time) AS open,
@@ -302,16 +409,16 @@ func (r *currencyRepository) ListCandles(ctx context.Context, ticker string, buc
 	`
 	args := []any{bucketInterval, strings.ToUpper(strings.TrimSpace(ticker))}
 	if from != nil {
-		query += " AND fetched_time >= ?"
+		inner += " AND fetched_time >= ?"
 		args = append(args, *from)
 	}
 	if to != nil {
-		query += " AND fetched_time <= ?"
+		inner += " AND fetched_time <= ?"
 		args = append(args, *to)
 	}
-
-	query += " GROUP BY bucket ORDER BY bucket ASC LIMIT ?"
+	inner += " GROUP BY bucket ORDER BY bucket DESC LIMIT ?"
 	args = append(args, limit)
+	query := `SELECT * FROM (` + inner + `) c ORDER BY bucket ASC`
 
 	var rows []CandleRow
 	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
@@ -319,3 +426,165 @@ func (r *currencyRepository) ListCandles(ctx context.Context, ticker string, buc
 	}
 	return rows, nil
 }
+
+// ListPairedCandles builds OHLC candles for the cross rate A/B (units of B per A) from the paired
+// per-snapshot rates, so high and low reflect every sample in the bucket. When the range holds
+// more than limit buckets it keeps the most recent ones, still in ascending order.
+func (r *currencyRepository) ListPairedCandles(ctx context.Context, tickerA, tickerB string, bucketInterval string, from, to *time.Time, limit int) ([]CandleRow, error) {
+	if strings.TrimSpace(tickerA) == "" || strings.TrimSpace(tickerB) == "" {
+		return nil, fmt.Errorf("both tickers are required")
+	}
+	if strings.TrimSpace(bucketInterval) == "" {
+		return nil, fmt.Errorf("bucket interval is required")
+	}
+	if limit <= 0 || limit > 5000 {
+		limit = 500
+	}
+
+	paired, pairedArgs, _ := pairedRatesQuery(tickerA, tickerB, from, to)
+	query := `
+		SELECT * FROM (
+			SELECT
+				time_bucket(?::interval, p.time) AS bucket,
+				first(p.rate_b / p.rate_a, p.time) AS open,
+				max(p.rate_b / p.rate_a) AS high,
+				min(p.rate_b / p.rate_a) AS low,
+				last(p.rate_b / p.rate_a, p.time) AS close,
+				count(*) AS samples
+			FROM (` + paired + `) p
+			WHERE p.rate_a > 0
+			GROUP BY bucket ORDER BY bucket DESC LIMIT ?
+		) c ORDER BY bucket ASC
+	`
+	args := append([]any{bucketInterval}, pairedArgs...)
+	args = append(args, limit)
+
+	var rows []CandleRow
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list paired candles: %w", err)
+	}
+	return rows, nil
+}
+
+type BucketCloseRow struct {
+	Bucket time.Time `gorm:"column:bucket"`
+	Ticker string    `gorm:"column:ticker"`
+	Close  float64   `gorm:"column:close"`
+	Base   string    `gorm:"column:base"`
+}
+
+// ListBucketedCloses resamples several tickers onto a shared time_bucket grid, keeping the last
+// rate of each bucket. limit caps the number of buckets per ticker; when a range holds more,
+// each ticker keeps its most recent ones. Rows come oldest first.
+func (r *currencyRepository) ListBucketedCloses(ctx context.Context, tickers []string, bucketInterval string, from, to *time.Time, limit int) ([]BucketCloseRow, error) {
+	if len(tickers) == 0 {
+		return nil, nil
+	}
+	if strings.TrimSpace(bucketInterval) == "" {
+		return nil, fmt.Errorf("bucket interval is required")
+	}
+	if limit <= 0 || limit > 5000 {
+		limit = 500
+	}
+
+	upper := make([]string, 0, len(tickers))
+	for _, t := range tickers {
+		upper = append(upper, strings.ToUpper(strings.TrimSpace(t)))
+	}
+
+	inner := `
+		SELECT
+			time_bucket(?::interval, fetched_time) AS bucket,
+			ticker,
+			last(rate, fetched_time) AS close,
+			min(base) AS base
+		FROM currencies
+		WHERE ticker IN ?
+	`
+	args := []any{bucketInterval, upper}
+	if from != nil {
+		inner += " AND fetched_time >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		inner += " AND fetched_time <= ?"
+		args = append(args, *to)
+	}
+	inner += " GROUP BY bucket, ticker"
+
+	query := `
+		SELECT bucket, ticker, close, base
+		FROM (
+			SELECT *, row_number() OVER (PARTITION BY ticker ORDER BY bucket DESC) AS rn
+			FROM (` + inner + `) closes
+		) ranked
+		WHERE rn <= ?
+		ORDER BY bucket ASC, ticker ASC
+	`
+	args = append(args, limit)
+
+	var rows []BucketCloseRow
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list bucketed closes: %w", err)
+	}
+	return rows, nil
+}
+
+type ReturnVolatilityRow struct {
+	Ticker  string  `gorm:"column:ticker"`
+	StdDev  float64 `gorm:"column:std_dev"` // sample std-dev of bucket-to-bucket log returns
+	Samples int64   `gorm:"column:samples"`
+}
+
+// ListReturnVolatility resamples every ticker to bucket closes in [from,to] and returns the
+// dispersion of their log returns in one pass over the table.
+func (r *currencyRepository) ListReturnVolatility(ctx context.Context, bucketInterval string, from, to time.Time) ([]ReturnVolatilityRow, error) {
+	if strings.TrimSpace(bucketInterval) == "" {
+		return nil, fmt.Errorf("bucket interval is required")
+	}
+
+	query := `
+		SELECT ticker, COALESCE(stddev_samp(ln(close / prev_close)), 0) AS std_dev, count(*) AS samples
+		FROM (
+			SELECT ticker, close, lag(close) OVER (PARTITION BY ticker ORDER BY bucket) AS prev_close
+			FROM (
+				SELECT ticker, time_bucket(?::interval, fetched_time) AS bucket, last(rate, fetched_time) AS close
+				FROM currencies
+				WHERE fetched_time >= ? AND fetched_time <= ?
+				GROUP BY ticker, bucket
+			) closes
+		) paired
+		WHERE prev_close > 0 AND close > 0
+		GROUP BY ticker
+	`
+
+	var rows []ReturnVolatilityRow
+	if err := r.db.WithContext(ctx).Raw(query, bucketInterval, from, to).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list return volatility: %w", err)
+	}
+	return rows, nil
+}
+
+func moversCacheKey(window string) string {
+	return "movers:" + window
+}
+
+func (r *currencyRepository) GetMoversCache(ctx context.Context, window string) (*models.MarketMovers, error) {
+	val, err := r.redis.Get(ctx, moversCacheKey(window)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var movers models.MarketMovers
+	if err := json.Unmarshal([]byte(val), &movers); err != nil {
+		return nil, err
+	}
+	return &movers, nil
+}
+
+func (r *currencyRepository) StoreMoversCache(ctx context.Context, movers *models.MarketMovers, ttl time.Duration) error {
+	encoded, err := json.Marshal(movers)
+	if err != nil {
+		return err
+	}
+	return r.redis.Set(ctx, moversCacheKey(movers.Window), encoded, ttl).Err()
+}
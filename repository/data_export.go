@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDataExportNotFound = errors.New("data export not found")
+	// ErrDataExportClaimLost means another runner has claimed the export since; the caller
+	// must stop building it.
+	ErrDataExportClaimLost = errors.New("data export claimed by another runner")
+)
+
+// exportColumns is every column of data_exports. Older tables may still carry an archive column
+// from before chunks; naming the columns keeps it from ever being read.
+var exportColumns = []string{"id", "user_id", "status", "error", "size_bytes", "lease_until", "claim_token", "completed_at", "expires_at", "created_at"}
+
+// DataExportRepository stores export jobs and their archives. An archive is written as numbered
+// chunks while it is built and read back one chunk at a time, so neither side holds it whole.
+type DataExportRepository interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	Get(ctx context.Context, userID, id uint) (*models.DataExport, error)
+	FindActive(ctx context.Context, userID uint) (*models.DataExport, error)
+	ListByUser(ctx context.Context, userID uint, limit int) ([]models.DataExport, error)
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error)
+	// Claim marks a due export running under token until leaseUntil. The writes below take the
+	// same token and return ErrDataExportClaimLost once another runner has claimed the export.
+	Claim(ctx context.Context, id uint, token string, now, leaseUntil time.Time) (bool, error)
+	// DeleteChunks drops whatever an earlier, interrupted build of the export wrote, and
+	// extends the lease to leaseUntil.
+	DeleteChunks(ctx context.Context, id uint, token string, leaseUntil time.Time) error
+	// AppendChunk stores the next chunk and extends the lease to leaseUntil.
+	AppendChunk(ctx context.Context, id uint, token string, seq int, data []byte, leaseUntil time.Time) error
+	Complete(ctx context.Context, id uint, token string, size int64, at, expiresAt time.Time) error
+	Fail(ctx context.Context, id uint, token string, reason string, at time.Time) error
+	// GetReady loads a ready export while it has not expired.
+	GetReady(ctx context.Context, id uint, now time.Time) (*models.DataExport, error)
+	// EachChunk hands the export's archive to fn chunk by chunk, in order.
+	EachChunk(ctx context.Context, id uint, fn func([]byte) error) error
+	ExpireDue(ctx context.Context, now time.Time) (int64, error)
+}
+
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	if err := r.db.WithContext(ctx).Create(export).Error; err != nil {
+		return fmt.Errorf("create data export: %w", err)
+	}
+	return nil
+}
+
+func (r *dataExportRepository) Get(ctx context.Context, userID, id uint) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.WithContext(ctx).Select(exportColumns).Where("id = ? AND user_id = ?", id, userID).First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDataExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get data export: %w", err)
+	}
+	return &export, nil
+}
+
+// FindActive returns the user's pending or running export, or nil when there is none.
+func (r *dataExportRepository) FindActive(ctx context.Context, userID uint) (*models.DataExport, error) {
+	var exports []models.DataExport
+	if err := r.db.WithContext(ctx).Select(exportColumns).
+		Where("user_id = ? AND status IN ?", userID, []string{models.DataExportPending, models.DataExportRunning}).
+		Order("id DESC").Limit(1).Find(&exports).Error; err != nil {
+		return nil, fmt.Errorf("find active data export: %w", err)
+	}
+	if len(exports) == 0 {
+		return nil, nil
+	}
+	return &exports[0], nil
+}
+
+// ListByUser returns a user's exports newest first.
+func (r *dataExportRepository) ListByUser(ctx context.Context, userID uint, limit int) ([]models.DataExport, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var rows []models.DataExport
+	if err := r.db.WithContext(ctx).Select(exportColumns).
+		Where("user_id = ?", userID).
+		Order("id DESC").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list data exports: %w", err)
+	}
+	return rows, nil
+}
+
+// ListDue returns pending exports and running ones whose lease has lapsed (the runner died),
+// oldest first.
+func (r *dataExportRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	var rows []models.DataExport
+	if err := r.db.WithContext(ctx).Select(exportColumns).
+		Where("status = ? OR (status = ? AND lease_until <= ?)", models.DataExportPending, models.DataExportRunning, now).
+		Order("id").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list due data exports: %w", err)
+	}
+	return rows, nil
+}
+
+// Claim reports false when another runner got there first.
+func (r *dataExportRepository) Claim(ctx context.Context, id uint, token string, now, leaseUntil time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.DataExport{}).
+		Where("id = ? AND (status = ? OR (status = ? AND lease_until <= ?))", id, models.DataExportPending, models.DataExportRunning, now).
+		Updates(map[string]any{"status": models.DataExportRunning, "lease_until": leaseUntil, "claim_token": token})
+	if res.Error != nil {
+		return false, fmt.Errorf("claim data export %d: %w", id, res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *dataExportRepository) DeleteChunks(ctx context.Context, id uint, token string, leaseUntil time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := claimed(tx, id, token).Update("lease_until", leaseUntil)
+		if res.Error != nil {
+			return fmt.Errorf("renew data export %d lease: %w", id, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrDataExportClaimLost
+		}
+		if err := tx.Where("export_id = ?", id).Delete(&models.DataExportChunk{}).Error; err != nil {
+			return fmt.Errorf("delete data export %d chunks: %w", id, err)
+		}
+		return nil
+	})
+}
+
+// claimed scopes an update to the export while token still holds its claim.
+func claimed(tx *gorm.DB, id uint, token string) *gorm.DB {
+	return tx.Model(&models.DataExport{}).
+		Where("id = ? AND status = ? AND claim_token = ?", id, models.DataExportRunning, token)
+}
+
+// AppendChunk renews the lease before inserting, in one transaction: the row lock keeps a
+// re-claim from slipping in between, and a runner that lost its claim writes nothing.
+func (r *dataExportRepository) AppendChunk(ctx context.Context, id uint, token string, seq int, data []byte, leaseUntil time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := claimed(tx, id, token).Update("lease_until", leaseUntil)
+		if res.Error != nil {
+			return fmt.Errorf("renew data export %d lease: %w", id, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrDataExportClaimLost
+		}
+		if err := tx.Create(&models.DataExportChunk{ExportID: id, Seq: seq, Data: data}).Error; err != nil {
+			return fmt.Errorf("write data export %d chunk %d: %w", id, seq, err)
+		}
+		return nil
+	})
+}
+
+func (r *dataExportRepository) Complete(ctx context.Context, id uint, token string, size int64, at, expiresAt time.Time) error {
+	res := claimed(r.db.WithContext(ctx), id, token).Updates(map[string]any{
+		"status":       models.DataExportReady,
+		"size_bytes":   size,
+		"lease_until":  nil,
+		"completed_at": at,
+		"expires_at":   expiresAt,
+	})
+	if res.Error != nil {
+		return fmt.Errorf("complete data export %d: %w", id, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrDataExportClaimLost
+	}
+	return nil
+}
+
+// Fail marks the export failed and drops any chunks the build got to write. A runner that lost
+// its claim changes nothing, so it cannot fail or empty the new runner's build.
+func (r *dataExportRepository) Fail(ctx context.Context, id uint, token string, reason string, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := claimed(tx, id, token).Updates(map[string]any{
+			"status":       models.DataExportFailed,
+			"error":        reason,
+			"lease_until":  nil,
+			"completed_at": at,
+		})
+		if res.Error != nil {
+			return fmt.Errorf("fail data export %d: %w", id, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrDataExportClaimLost
+		}
+		if err := tx.Where("export_id = ?", id).Delete(&models.DataExportChunk{}).Error; err != nil {
+			return fmt.Errorf("fail data export %d: %w", id, err)
+		}
+		return nil
+	})
+}
+
+func (r *dataExportRepository) GetReady(ctx context.Context, id uint, now time.Time) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.WithContext(ctx).Select(exportColumns).
+		Where("id = ? AND status = ? AND expires_at > ?", id, models.DataExportReady, now).
+		First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDataExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read data export %d: %w", id, err)
+	}
+	return &export, nil
+}
+
+// EachChunk reads the chunks one row at a time.
+func (r *dataExportRepository) EachChunk(ctx context.Context, id uint, fn func([]byte) error) error {
+	rows, err := r.db.WithContext(ctx).Model(&models.DataExportChunk{}).
+		Select("data").Where("export_id = ?", id).Order("seq").Rows()
+	if err != nil {
+		return fmt.Errorf("read data export %d: %w", id, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return fmt.Errorf("read data export %d: %w", id, err)
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read data export %d: %w", id, err)
+	}
+	return nil
+}
+
+// ExpireDue drops the archives of ready exports past their expiry and reports how many.
+func (r *dataExportRepository) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	var expired int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		due := tx.Model(&models.DataExport{}).Select("id").
+			Where("status = ? AND expires_at <= ?", models.DataExportReady, now)
+		if err := tx.Where("export_id IN (?)", due).Delete(&models.DataExportChunk{}).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&models.DataExport{}).
+			Where("status = ? AND expires_at <= ?", models.DataExportReady, now).
+			Update("status", models.DataExportExpired)
+		expired = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("expire data exports: %w", err)
+	}
+	return expired, nil
+}
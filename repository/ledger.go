@@ -12,9 +12,9 @@ import (
 type LedgerRepository interface {
 	Append(ctx context.Context, entries []models.UserLedgerEntry) error
 	GetByTradeID(ctx context.Context, userID uint, tradeID string) ([]models.UserLedgerEntry, error)
-	ListByUser(ctx context.Context, userID uint, currency string, limit int) ([]models.UserLedgerEntry, error)
-	ListByUserBetween(ctx context.Context, userID uint, from, to time.Time) ([]models.UserLedgerEntry, error)
-	BalancesBefore(ctx context.Context, userID uint, before time.Time) (map[string]float64, error)
+	ListByUser(ctx context.Context, userID uint, portfolioIDs []uint, currency string, limit int) ([]models.UserLedgerEntry, error)
+	ListByUserBetween(ctx context.Context, userID uint, portfolioIDs []uint, from, to time.Time) ([]models.UserLedgerEntry, error)
+	BalancesBefore(ctx context.Context, userID uint, portfolioIDs []uint, before time.Time) (map[string]float64, error)
 }
 
 type ledgerRepository struct {
@@ -52,12 +52,12 @@ func (r *ledgerRepository) GetByTradeID(ctx context.Context, userID uint, tradeI
 	return rows, nil
 }
 
-func (r *ledgerRepository) ListByUser(ctx context.Context, userID uint, currency string, limit int) ([]models.UserLedgerEntry, error) {
+func (r *ledgerRepository) ListByUser(ctx context.Context, userID uint, portfolioIDs []uint, currency string, limit int) ([]models.UserLedgerEntry, error) {
 	if limit <= 0 || limit > 2000 {
 		limit = 200
 	}
 	query := r.db.WithContext(ctx).
-		Where("user_id = ?", userID)
+		Where("user_id = ? AND portfolio_id IN ?", userID, portfolioIDs)
 
 	if currency != "" {
 		query = query.Where("currency = ?", currency)
@@ -73,13 +73,13 @@ func (r *ledgerRepository) ListByUser(ctx context.Context, userID uint, currency
 	return rows, nil
 }
 
-func (r *ledgerRepository) ListByUserBetween(ctx context.Context, userID uint, from, to time.Time) ([]models.UserLedgerEntry, error) {
+func (r *ledgerRepository) ListByUserBetween(ctx context.Context, userID uint, portfolioIDs []uint, from, to time.Time) ([]models.UserLedgerEntry, error) {
 	if userID == 0 {
 		return nil, fmt.Errorf("user_id is required")
 	}
 	var rows []models.UserLedgerEntry
 	if err := r.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ? AND portfolio_id IN ?", userID, portfolioIDs).
 		Where("executed_at >= ?", from).
 		Where("executed_at <= ?", to).
 		Order("executed_at ASC, id ASC").
@@ -89,7 +89,7 @@ func (r *ledgerRepository) ListByUserBetween(ctx context.Context, userID uint, f
 	return rows, nil
 }
 
-func (r *ledgerRepository) BalancesBefore(ctx context.Context, userID uint, before time.Time) (map[string]float64, error) {
+func (r *ledgerRepository) BalancesBefore(ctx context.Context, userID uint, portfolioIDs []uint, before time.Time) (map[string]float64, error) {
 	if userID == 0 {
 		return nil, fmt.Errorf("user_id is required")
 	}
@@ -103,9 +103,10 @@ func (r *ledgerRepository) BalancesBefore(ctx context.Context, userID uint, befo
 		Raw(
 			`SELECT currency, COALESCE(SUM(amount), 0) AS balance
 			 FROM user_ledger_entries
-			 WHERE user_id = ? AND executed_at < ?
+			 WHERE user_id = ? AND portfolio_id IN ? AND executed_at < ?
 			 GROUP BY currency`,
 			userID,
+			portfolioIDs,
 			before,
 		).
 		Scan(&rows).Error; err != nil {
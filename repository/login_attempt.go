@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LoginAttemptRepository keeps sliding windows of failed logins in Redis sorted sets (one
+// member per failure, scored by time) and temporary lockouts as expiring keys. Keys are
+// caller-chosen subjects such as "email:a@example.com" or "ip:203.0.113.7".
+type LoginAttemptRepository interface {
+	// AddFailure records a failure at and returns how many fall inside the window.
+	AddFailure(ctx context.Context, subject string, at time.Time, window time.Duration) (int64, error)
+	// RemoveFailure withdraws the failure recorded at, for an attempt that turned out well.
+	RemoveFailure(ctx context.Context, subject string, at time.Time) error
+	// Failures counts failures inside the window ending at and returns the latest one.
+	Failures(ctx context.Context, subject string, at time.Time, window time.Duration) (int64, time.Time, error)
+	Clear(ctx context.Context, subject string) error
+	Lock(ctx context.Context, subject string, d time.Duration) error
+	// LockRemaining is zero when the subject is not locked.
+	LockRemaining(ctx context.Context, subject string) (time.Duration, error)
+}
+
+type loginAttemptRepository struct {
+	redis *redis.Client
+}
+
+func NewLoginAttemptRepository(redisClient *redis.Client) LoginAttemptRepository {
+	return &loginAttemptRepository{redis: redisClient}
+}
+
+func failuresKey(subject string) string {
+	return "login:fail:" + subject
+}
+
+func lockKey(subject string) string {
+	return "login:lock:" + subject
+}
+
+func (r *loginAttemptRepository) AddFailure(ctx context.Context, subject string, at time.Time, window time.Duration) (int64, error) {
+	key := failuresKey(subject)
+	score := float64(at.UnixNano())
+	pipe := r.redis.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(at.Add(-window).UnixNano(), 10))
+	pipe.ZAdd(ctx, key, redis.Z{Score: score, Member: strconv.FormatInt(at.UnixNano(), 10)})
+	count := pipe.ZCard(ctx, key)
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("record login failure: %w", err)
+	}
+	return count.Val(), nil
+}
+
+func (r *loginAttemptRepository) RemoveFailure(ctx context.Context, subject string, at time.Time) error {
+	if err := r.redis.ZRem(ctx, failuresKey(subject), strconv.FormatInt(at.UnixNano(), 10)).Err(); err != nil {
+		return fmt.Errorf("remove login failure: %w", err)
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) Failures(ctx context.Context, subject string, at time.Time, window time.Duration) (int64, time.Time, error) {
+	key := failuresKey(subject)
+	min := strconv.FormatInt(at.Add(-window).UnixNano(), 10)
+	pipe := r.redis.Pipeline()
+	count := pipe.ZCount(ctx, key, "("+min, "+inf")
+	latest := pipe.ZRevRangeWithScores(ctx, key, 0, 0)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, time.Time{}, fmt.Errorf("count login failures: %w", err)
+	}
+	var last time.Time
+	if z := latest.Val(); len(z) > 0 {
+		last = time.Unix(0, int64(z[0].Score))
+	}
+	return count.Val(), last, nil
+}
+
+func (r *loginAttemptRepository) Clear(ctx context.Context, subject string) error {
+	if err := r.redis.Del(ctx, failuresKey(subject), lockKey(subject)).Err(); err != nil {
+		return fmt.Errorf("clear login failures: %w", err)
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) Lock(ctx context.Context, subject string, d time.Duration) error {
+	if err := r.redis.Set(ctx, lockKey(subject), 1, d).Err(); err != nil {
+		return fmt.Errorf("lock login: %w", err)
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) LockRemaining(ctx context.Context, subject string) (time.Duration, error) {
+	ttl, err := r.redis.PTTL(ctx, lockKey(subject)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("check login lock: %w", err)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var ErrChallengeNotFound = errors.New("login challenge not found or expired")
+
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID uint, hashes []string) error
+	Consume(ctx context.Context, userID uint, hash string, at time.Time) (bool, error)
+	CountUnused(ctx context.Context, userID uint) (int64, error)
+	DeleteByUser(ctx context.Context, userID uint) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// Replace discards the user's recovery codes and stores a new set in one transaction.
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uint, hashes []string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+		rows := make([]models.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			rows[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return fmt.Errorf("replace recovery codes: %w", err)
+	}
+	return nil
+}
+
+// Consume marks a code used. It reports false when the code does not exist or was used.
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	if res.Error != nil {
+		return false, fmt.Errorf("consume recovery code: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *recoveryCodeRepository) CountUnused(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&n).Error; err != nil {
+		return 0, fmt.Errorf("count recovery codes: %w", err)
+	}
+	return n, nil
+}
+
+func (r *recoveryCodeRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	return nil
+}
+
+// ChallengeRepository holds pending second-factor logins in Redis, keyed by the hash of the
+// challenge token handed out after a correct password.
+type ChallengeRepository interface {
+	Create(ctx context.Context, hash string, userID uint, ttl time.Duration) error
+	// Attempt counts an attempt at the challenge before its code is checked and returns the
+	// user it belongs to with the attempts made so far, this one included.
+	Attempt(ctx context.Context, hash string) (uint, int64, error)
+	// Delete removes the challenge and reports whether it still existed, so it can be
+	// exchanged for tokens only once.
+	Delete(ctx context.Context, hash string) (bool, error)
+}
+
+type challengeRepository struct {
+	redis *redis.Client
+}
+
+func NewChallengeRepository(redisClient *redis.Client) ChallengeRepository {
+	return &challengeRepository{redis: redisClient}
+}
+
+func challengeKey(hash string) string {
+	return "mfa:challenge:" + hash
+}
+
+func (r *challengeRepository) Create(ctx context.Context, hash string, userID uint, ttl time.Duration) error {
+	key := challengeKey(hash)
+	pipe := r.redis.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", userID, "attempts", 0)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("create login challenge: %w", err)
+	}
+	return nil
+}
+
+// attemptChallenge increments the attempt counter only while the challenge exists, so a late
+// attempt never re-creates an expired or consumed challenge without a TTL.
+var attemptChallenge = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+return {redis.call("HGET", KEYS[1], "user_id"), attempts}
+`)
+
+func (r *challengeRepository) Attempt(ctx context.Context, hash string) (uint, int64, error) {
+	res, err := attemptChallenge.Run(ctx, r.redis, []string{challengeKey(hash)}).Slice()
+	if errors.Is(err, redis.Nil) {
+		return 0, 0, ErrChallengeNotFound
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("record challenge attempt: %w", err)
+	}
+	if len(res) != 2 {
+		return 0, 0, fmt.Errorf("record challenge attempt: unexpected reply %v", res)
+	}
+	raw, _ := res[0].(string)
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("record challenge attempt: %w", err)
+	}
+	attempts, _ := res[1].(int64)
+	return uint(id), attempts, nil
+}
+
+func (r *challengeRepository) Delete(ctx context.Context, hash string) (bool, error) {
+	n, err := r.redis.Del(ctx, challengeKey(hash)).Result()
+	if err != nil {
+		return false, fmt.Errorf("delete login challenge: %w", err)
+	}
+	return n == 1, nil
+}
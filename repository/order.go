@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotOpen  = errors.New("order is no longer open")
+)
+
+// ocoFilledReason marks an OCO leg cancelled by Claim, so Fail can tell it from one the owner
+// cancelled.
+const ocoFilledReason = "oco: other leg filled"
+
+type OrderRepository interface {
+	Create(ctx context.Context, orders []models.Order) error
+	Get(ctx context.Context, userID uint, id string) (*models.Order, error)
+	ListByUser(ctx context.Context, userID uint, status string, limit int) ([]models.Order, error)
+	ListOpen(ctx context.Context) ([]models.Order, error)
+	ExpireDue(ctx context.Context, now time.Time) (int64, error)
+	Cancel(ctx context.Context, userID uint, id string, at time.Time) error
+	Claim(ctx context.Context, id, tradeID string, mid float64, at time.Time) (bool, error)
+	SetFilledAmount(ctx context.Context, id string, toAmount float64) error
+	Fail(ctx context.Context, id, reason string) error
+}
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &orderRepository{db: db}
+}
+
+// Create stores the orders together, so both legs of an OCO pair exist or neither does.
+func (r *orderRepository) Create(ctx context.Context, orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(&orders).Error; err != nil {
+		return fmt.Errorf("create orders: %w", err)
+	}
+	return nil
+}
+
+func (r *orderRepository) Get(ctx context.Context, userID uint, id string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get order %s: %w", id, err)
+	}
+	return &order, nil
+}
+
+// ListByUser returns the user's orders newest first, optionally filtered by status.
+func (r *orderRepository) ListByUser(ctx context.Context, userID uint, status string, limit int) ([]models.Order, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var rows []models.Order
+	if err := query.Order("created_at DESC, id").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list orders: %w", err)
+	}
+	return rows, nil
+}
+
+// ListOpen returns every open order, oldest first so earlier orders fill first.
+func (r *orderRepository) ListOpen(ctx context.Context) ([]models.Order, error) {
+	var rows []models.Order
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", models.OrderStatusOpen).
+		Order("created_at, id").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list open orders: %w", err)
+	}
+	return rows, nil
+}
+
+// ExpireDue closes open orders whose good-till time has passed.
+func (r *orderRepository) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("status = ? AND good_till IS NOT NULL AND good_till <= ?", models.OrderStatusOpen, now).
+		Updates(map[string]any{"status": models.OrderStatusExpired, "closed_at": now})
+	if res.Error != nil {
+		return 0, fmt.Errorf("expire orders: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// Cancel closes an open order and, for OCO legs, its sibling.
+func (r *orderRepository) Cancel(ctx context.Context, userID uint, id string, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		err := tx.Where("id = ? AND user_id = ?", id, userID).First(&order).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("get order %s: %w", id, err)
+		}
+
+		query := tx.Model(&models.Order{}).Where("status = ? AND user_id = ?", models.OrderStatusOpen, userID)
+		if order.OCOGroup != "" {
+			query = query.Where("oco_group = ?", order.OCOGroup)
+		} else {
+			query = query.Where("id = ?", id)
+		}
+		res := query.Updates(map[string]any{
+			"status":    models.OrderStatusCancelled,
+			"reason":    "cancelled by user",
+			"closed_at": at,
+		})
+		if res.Error != nil {
+			return fmt.Errorf("cancel order %s: %w", id, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrOrderNotOpen
+		}
+		return nil
+	})
+}
+
+// Claim moves an open order to filled and cancels its OCO sibling. It reports false when the
+// order was already closed, so concurrent evaluations never fill the same order twice.
+func (r *orderRepository) Claim(ctx context.Context, id, tradeID string, mid float64, at time.Time) (bool, error) {
+	claimed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Order{}).
+			Where("id = ? AND status = ?", id, models.OrderStatusOpen).
+			Updates(map[string]any{
+				"status":      models.OrderStatusFilled,
+				"trade_id":    tradeID,
+				"trigger_mid": mid,
+				"closed_at":   at,
+			})
+		if res.Error != nil {
+			return fmt.Errorf("claim order %s: %w", id, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		claimed = true
+
+		var order models.Order
+		if err := tx.Where("id = ?", id).First(&order).Error; err != nil {
+			return fmt.Errorf("get order %s: %w", id, err)
+		}
+		if order.OCOGroup == "" {
+			return nil
+		}
+		if err := tx.Model(&models.Order{}).
+			Where("oco_group = ? AND id <> ? AND status = ?", order.OCOGroup, id, models.OrderStatusOpen).
+			Updates(map[string]any{
+				"status":    models.OrderStatusCancelled,
+				"reason":    ocoFilledReason,
+				"closed_at": at,
+			}).Error; err != nil {
+			return fmt.Errorf("cancel oco sibling of %s: %w", id, err)
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+func (r *orderRepository) SetFilledAmount(ctx context.Context, id string, toAmount float64) error {
+	if err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("id = ?", id).
+		Update("to_amount", toAmount).Error; err != nil {
+		return fmt.Errorf("update order %s: %w", id, err)
+	}
+	return nil
+}
+
+// Fail cancels an order whose fill could not be booked after Claim, and reopens the OCO sibling
+// that Claim cancelled on its behalf: nothing was filled, so the other leg still stands.
+func (r *orderRepository) Fail(ctx context.Context, id, reason string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Order{}).
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"status":   models.OrderStatusCancelled,
+				"trade_id": "",
+				"reason":   reason,
+			}).Error; err != nil {
+			return fmt.Errorf("fail order %s: %w", id, err)
+		}
+
+		var order models.Order
+		if err := tx.Where("id = ?", id).First(&order).Error; err != nil {
+			return fmt.Errorf("get order %s: %w", id, err)
+		}
+		if order.OCOGroup == "" {
+			return nil
+		}
+		if err := tx.Model(&models.Order{}).
+			Where("oco_group = ? AND id <> ? AND status = ? AND reason = ?", order.OCOGroup, id, models.OrderStatusCancelled, ocoFilledReason).
+			Updates(map[string]any{
+				"status":    models.OrderStatusOpen,
+				"reason":    "",
+				"closed_at": nil,
+			}).Error; err != nil {
+			return fmt.Errorf("reopen oco sibling of %s: %w", id, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+)
+
+const ledgerExportBatch = 5000
+
+// PersonalData is everything stored about a user apart from ledger entries, which are
+// streamed with EachLedgerBatch because an active account can hold a great many.
+type PersonalData struct {
+	User           *models.User
+	WatchItems     []models.WatchItem
+	Portfolios     []models.Portfolio
+	Orders         []models.Order
+	Plans          []models.ConversionPlan
+	PlanExecutions []models.PlanExecution
+	Backtests      []models.BacktestRun
+	APIKeys        []models.APIKey
+	AuthEvents     []models.AuthEvent
+}
+
+// PersonalDataRepository reads a user's data across every table for the data export.
+type PersonalDataRepository interface {
+	Collect(ctx context.Context, userID uint) (*PersonalData, error)
+	EachLedgerBatch(ctx context.Context, userID uint, fn func([]models.UserLedgerEntry) error) error
+}
+
+type personalDataRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalDataRepository(db *gorm.DB) PersonalDataRepository {
+	return &personalDataRepository{db: db}
+}
+
+func (r *personalDataRepository) Collect(ctx context.Context, userID uint) (*PersonalData, error) {
+	db := r.db.WithContext(ctx)
+	data := &PersonalData{User: &models.User{}}
+	if err := db.First(data.User, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("collect user: %w", err)
+	}
+
+	queries := []struct {
+		name  string
+		dest  any
+		order string
+	}{
+		{"watch items", &data.WatchItems, "id"},
+		{"portfolios", &data.Portfolios, "id"},
+		{"orders", &data.Orders, "created_at, id"},
+		{"plans", &data.Plans, "id"},
+		{"plan executions", &data.PlanExecutions, "executed_at, id"},
+		{"backtests", &data.Backtests, "created_at, id"},
+		{"api keys", &data.APIKeys, "id"},
+		{"auth events", &data.AuthEvents, "created_at, id"},
+	}
+	for _, q := range queries {
+		if err := db.Where("user_id = ?", userID).Order(q.order).Find(q.dest).Error; err != nil {
+			return nil, fmt.Errorf("collect %s: %w", q.name, err)
+		}
+	}
+	return data, nil
+}
+
+// EachLedgerBatch calls fn with the user's ledger entries in id order, a batch at a time.
+func (r *personalDataRepository) EachLedgerBatch(ctx context.Context, userID uint, fn func([]models.UserLedgerEntry) error) error {
+	var batch []models.UserLedgerEntry
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		FindInBatches(&batch, ledgerExportBatch, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+	if err != nil {
+		return fmt.Errorf("read ledger entries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+)
+
+var ErrPlanNotFound = errors.New("plan not found")
+
+type PlanRepository interface {
+	Create(ctx context.Context, plan *models.ConversionPlan) error
+	Get(ctx context.Context, userID, id uint) (*models.ConversionPlan, error)
+	ListByUser(ctx context.Context, userID uint) ([]models.ConversionPlan, error)
+	// UpdateSettings stores the owner's edits to a plan. The schedule columns are written only
+	// when rescheduled is set, so an edit never rewinds a run recorded since the plan was read.
+	UpdateSettings(ctx context.Context, plan *models.ConversionPlan, rescheduled bool) error
+	Pause(ctx context.Context, userID, id uint) error
+	// Resume reactivates a paused plan; it reports ErrPlanNotFound when no paused plan matches.
+	Resume(ctx context.Context, userID, id uint, nextRunAt time.Time) error
+	// RecordRun stores a run's effect on the schedule without touching anything the owner may
+	// have changed meanwhile; a plan paused or deleted during the run stays that way.
+	RecordRun(ctx context.Context, plan *models.ConversionPlan) error
+	Delete(ctx context.Context, userID, id uint) error
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.ConversionPlan, error)
+	Claim(ctx context.Context, id uint, now, leaseUntil time.Time) (bool, error)
+	AddExecution(ctx context.Context, execution *models.PlanExecution) error
+	ListExecutions(ctx context.Context, userID, planID uint, limit int) ([]models.PlanExecution, error)
+}
+
+type planRepository struct {
+	db *gorm.DB
+}
+
+func NewPlanRepository(db *gorm.DB) PlanRepository {
+	return &planRepository{db: db}
+}
+
+func (r *planRepository) Create(ctx context.Context, plan *models.ConversionPlan) error {
+	if err := r.db.WithContext(ctx).Create(plan).Error; err != nil {
+		return fmt.Errorf("create plan: %w", err)
+	}
+	return nil
+}
+
+func (r *planRepository) Get(ctx context.Context, userID, id uint) (*models.ConversionPlan, error) {
+	var plan models.ConversionPlan
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get plan %d: %w", id, err)
+	}
+	return &plan, nil
+}
+
+func (r *planRepository) ListByUser(ctx context.Context, userID uint) ([]models.ConversionPlan, error) {
+	var rows []models.ConversionPlan
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list plans: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *planRepository) UpdateSettings(ctx context.Context, plan *models.ConversionPlan, rescheduled bool) error {
+	changes := map[string]any{
+		"name":         plan.Name,
+		"from_amount":  plan.FromAmount,
+		"frequency":    plan.Frequency,
+		"weekday":      plan.Weekday,
+		"day_of_month": plan.DayOfMonth,
+		"hour":         plan.Hour,
+		"minute":       plan.Minute,
+	}
+	if rescheduled {
+		changes["next_run_at"] = plan.NextRunAt
+		changes["retry_at"] = plan.RetryAt
+		changes["attempts"] = plan.Attempts
+	}
+	res := r.db.WithContext(ctx).Model(&models.ConversionPlan{}).
+		Where("id = ? AND user_id = ?", plan.ID, plan.UserID).
+		Updates(changes)
+	if res.Error != nil {
+		return fmt.Errorf("update plan %d: %w", plan.ID, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrPlanNotFound
+	}
+	return nil
+}
+
+// Pause stops a plan and drops any pending retry.
+func (r *planRepository) Pause(ctx context.Context, userID, id uint) error {
+	res := r.db.WithContext(ctx).Model(&models.ConversionPlan{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Updates(map[string]any{
+			"status":   models.PlanStatusPaused,
+			"retry_at": nil,
+			"attempts": 0,
+		})
+	if res.Error != nil {
+		return fmt.Errorf("pause plan %d: %w", id, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrPlanNotFound
+	}
+	return nil
+}
+
+func (r *planRepository) Resume(ctx context.Context, userID, id uint, nextRunAt time.Time) error {
+	res := r.db.WithContext(ctx).Model(&models.ConversionPlan{}).
+		Where("id = ? AND user_id = ? AND status = ?", id, userID, models.PlanStatusPaused).
+		Updates(map[string]any{
+			"status":      models.PlanStatusActive,
+			"next_run_at": nextRunAt,
+		})
+	if res.Error != nil {
+		return fmt.Errorf("resume plan %d: %w", id, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrPlanNotFound
+	}
+	return nil
+}
+
+func (r *planRepository) RecordRun(ctx context.Context, plan *models.ConversionPlan) error {
+	err := r.db.WithContext(ctx).Model(&models.ConversionPlan{}).
+		Where("id = ? AND status = ?", plan.ID, models.PlanStatusActive).
+		Updates(map[string]any{
+			"next_run_at": plan.NextRunAt,
+			"retry_at":    plan.RetryAt,
+			"attempts":    plan.Attempts,
+			"last_run_at": plan.LastRunAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("record plan %d run: %w", plan.ID, err)
+	}
+	return nil
+}
+
+// Delete removes the plan together with its execution history.
+func (r *planRepository) Delete(ctx context.Context, userID, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.ConversionPlan{})
+		if res.Error != nil {
+			return fmt.Errorf("delete plan %d: %w", id, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrPlanNotFound
+		}
+		if err := tx.Where("plan_id = ?", id).Delete(&models.PlanExecution{}).Error; err != nil {
+			return fmt.Errorf("delete plan %d executions: %w", id, err)
+		}
+		return nil
+	})
+}
+
+// ListDue returns active plans whose next attempt (retry or scheduled occurrence) is due.
+func (r *planRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.ConversionPlan, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var rows []models.ConversionPlan
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND COALESCE(retry_at, next_run_at) <= ?", models.PlanStatusActive, now).
+		Order("next_run_at, id").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list due plans: %w", err)
+	}
+	return rows, nil
+}
+
+// Claim pushes a due plan's retry time to leaseUntil so that no other runner picks it up while
+// it executes. It reports false when the plan is no longer due.
+func (r *planRepository) Claim(ctx context.Context, id uint, now, leaseUntil time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.ConversionPlan{}).
+		Where("id = ? AND status = ? AND COALESCE(retry_at, next_run_at) <= ?", id, models.PlanStatusActive, now).
+		Update("retry_at", leaseUntil)
+	if res.Error != nil {
+		return false, fmt.Errorf("claim plan %d: %w", id, res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *planRepository) AddExecution(ctx context.Context, execution *models.PlanExecution) error {
+	if err := r.db.WithContext(ctx).Create(execution).Error; err != nil {
+		return fmt.Errorf("record plan execution: %w", err)
+	}
+	return nil
+}
+
+// ListExecutions returns a plan's attempts newest first.
+func (r *planRepository) ListExecutions(ctx context.Context, userID, planID uint, limit int) ([]models.PlanExecution, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	var rows []models.PlanExecution
+	if err := r.db.WithContext(ctx).
+		Where("plan_id = ? AND user_id = ?", planID, userID).
+		Order("executed_at DESC, id DESC").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list plan executions: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPortfolioNotFound = errors.New("portfolio not found")
+	ErrPortfolioNotEmpty = errors.New("portfolio still has ledger entries")
+)
+
+type PortfolioRepository interface {
+	Create(ctx context.Context, portfolio *models.Portfolio) error
+	Get(ctx context.Context, userID, portfolioID uint) (*models.Portfolio, error)
+	GetOrCreateDefault(ctx context.Context, userID uint) (*models.Portfolio, error)
+	ListByUser(ctx context.Context, userID uint, kind string) ([]models.Portfolio, error)
+	Rename(ctx context.Context, userID, portfolioID uint, name string) (*models.Portfolio, error)
+	Delete(ctx context.Context, userID, portfolioID uint, withEntries bool) error
+}
+
+type portfolioRepository struct {
+	db *gorm.DB
+}
+
+func NewPortfolioRepository(db *gorm.DB) PortfolioRepository {
+	return &portfolioRepository{db: db}
+}
+
+func (r *portfolioRepository) Create(ctx context.Context, portfolio *models.Portfolio) error {
+	if err := r.db.WithContext(ctx).Create(portfolio).Error; err != nil {
+		return fmt.Errorf("create portfolio: %w", err)
+	}
+	return nil
+}
+
+func (r *portfolioRepository) Get(ctx context.Context, userID, portfolioID uint) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", portfolioID, userID).
+		First(&portfolio).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPortfolioNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get portfolio %d: %w", portfolioID, err)
+	}
+	return &portfolio, nil
+}
+
+// GetOrCreateDefault returns the user's default live portfolio, creating it on first use. The
+// partial unique index from database.EnsurePortfolios makes concurrent first calls converge on one row.
+func (r *portfolioRepository) GetOrCreateDefault(ctx context.Context, userID uint) (*models.Portfolio, error) {
+	def := models.Portfolio{UserID: userID, Name: "Live", Kind: models.PortfolioKindLive, IsDefault: true}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Where("user_id = ? AND is_default", userID).
+		FirstOrCreate(&def).Error; err != nil {
+		return nil, fmt.Errorf("get default portfolio: %w", err)
+	}
+	if def.ID == 0 {
+		// Lost the race to another request; read the row it created.
+		if err := r.db.WithContext(ctx).
+			Where("user_id = ? AND is_default", userID).
+			First(&def).Error; err != nil {
+			return nil, fmt.Errorf("get default portfolio: %w", err)
+		}
+	}
+	return &def, nil
+}
+
+// ListByUser returns the user's portfolios, default first; kind filters when non-empty.
+func (r *portfolioRepository) ListByUser(ctx context.Context, userID uint, kind string) ([]models.Portfolio, error) {
+	query := r.db.WithContext(ctx).
+		Where("user_id = ?", userID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var rows []models.Portfolio
+	if err := query.
+		Order("is_default DESC, id ASC").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list portfolios: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *portfolioRepository) Rename(ctx context.Context, userID, portfolioID uint, name string) (*models.Portfolio, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.Portfolio{}).
+		Where("id = ? AND user_id = ?", portfolioID, userID).
+		Update("name", name)
+	if res.Error != nil {
+		return nil, fmt.Errorf("rename portfolio %d: %w", portfolioID, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrPortfolioNotFound
+	}
+	return r.Get(ctx, userID, portfolioID)
+}
+
+// Delete removes a portfolio. Unless withEntries is set, portfolios that still have ledger
+// entries are refused with ErrPortfolioNotEmpty.
+func (r *portfolioRepository) Delete(ctx context.Context, userID, portfolioID uint, withEntries bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.UserLedgerEntry{}).
+			Where("user_id = ? AND portfolio_id = ?", userID, portfolioID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("count portfolio entries: %w", err)
+		}
+		if count > 0 {
+			if !withEntries {
+				return ErrPortfolioNotEmpty
+			}
+			if err := tx.Where("user_id = ? AND portfolio_id = ?", userID, portfolioID).
+				Delete(&models.UserLedgerEntry{}).Error; err != nil {
+				return fmt.Errorf("delete portfolio entries: %w", err)
+			}
+		}
+
+		res := tx.Where("id = ? AND user_id = ?", portfolioID, userID).Delete(&models.Portfolio{})
+		if res.Error != nil {
+			return fmt.Errorf("delete portfolio %d: %w", portfolioID, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrPortfolioNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrQuoteNotFound is returned when a quote never existed, expired, or was already used.
+var ErrQuoteNotFound = errors.New("quote not found or expired")
+
+type QuoteRepository interface {
+	Store(ctx context.Context, quote *models.Quote) error
+	Get(ctx context.Context, userID uint, quoteID string) (*models.Quote, error)
+	Take(ctx context.Context, userID uint, quoteID string) (*models.Quote, error)
+}
+
+type quoteRepository struct {
+	redis *redis.Client
+}
+
+func NewQuoteRepository(redisClient *redis.Client) QuoteRepository {
+	return &quoteRepository{redis: redisClient}
+}
+
+func quoteKey(userID uint, quoteID string) string {
+	return fmt.Sprintf("quote:%d:%s", userID, quoteID)
+}
+
+// Store saves the quote with a TTL matching its expiry so Redis drops it on its own.
+func (r *quoteRepository) Store(ctx context.Context, quote *models.Quote) error {
+	ttl := time.Until(quote.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("quote already expired")
+	}
+	encoded, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+	if err := r.redis.Set(ctx, quoteKey(quote.UserID, quote.ID), encoded, ttl).Err(); err != nil {
+		return fmt.Errorf("store quote: %w", err)
+	}
+	return nil
+}
+
+// Get reads a quote without using it up.
+func (r *quoteRepository) Get(ctx context.Context, userID uint, quoteID string) (*models.Quote, error) {
+	val, err := r.redis.Get(ctx, quoteKey(userID, quoteID)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, fmt.Errorf("get quote: %w", err)
+	}
+	return decodeQuote(val)
+}
+
+// Take atomically reads and deletes a quote so it can be executed at most once.
+func (r *quoteRepository) Take(ctx context.Context, userID uint, quoteID string) (*models.Quote, error) {
+	val, err := r.redis.GetDel(ctx, quoteKey(userID, quoteID)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, fmt.Errorf("take quote: %w", err)
+	}
+	return decodeQuote(val)
+}
+
+func decodeQuote(val string) (*models.Quote, error) {
+	var quote models.Quote
+	if err := json.Unmarshal([]byte(val), &quote); err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"gorm.io/gorm"
+)
+
+type SigningKeyRepository interface {
+	Create(ctx context.Context, key *models.SigningKey) error
+	List(ctx context.Context) ([]models.SigningKey, error)
+	Delete(ctx context.Context, kids []string) error
+}
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepository{db: db}
+}
+
+func (r *signingKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("create signing key: %w", err)
+	}
+	return nil
+}
+
+// List returns every stored key, newest first.
+func (r *signingKeyRepository) List(ctx context.Context) ([]models.SigningKey, error) {
+	var rows []models.SigningKey
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list signing keys: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *signingKeyRepository) Delete(ctx context.Context, kids []string) error {
+	if len(kids) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Where("kid IN ?", kids).Delete(&models.SigningKey{}).Error; err != nil {
+		return fmt.Errorf("delete signing keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// SystemStats is a point-in-time summary of what the API stores.
+type SystemStats struct {
+	Users          int64      `json:"users"`
+	Portfolios     int64      `json:"portfolios"`
+	LedgerEntries  int64      `json:"ledger_entries"`
+	OpenOrders     int64      `json:"open_orders"`
+	ActivePlans    int64      `json:"active_plans"`
+	LatestSnapshot *time.Time `json:"latest_snapshot,omitempty"`
+}
+
+type SystemRepository interface {
+	Stats(ctx context.Context) (*SystemStats, error)
+	PingPostgres(ctx context.Context) error
+	PingRedis(ctx context.Context) error
+}
+
+type systemRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+func NewSystemRepository(db *gorm.DB, redisClient *redis.Client) SystemRepository {
+	return &systemRepository{db: db, redis: redisClient}
+}
+
+func (r *systemRepository) Stats(ctx context.Context) (*SystemStats, error) {
+	db := r.db.WithContext(ctx)
+	stats := &SystemStats{}
+	counts := []struct {
+		dst   *int64
+		query *gorm.DB
+	}{
+		{&stats.Users, db.Model(&models.User{})},
+		{&stats.Portfolios, db.Model(&models.Portfolio{})},
+		{&stats.LedgerEntries, db.Model(&models.UserLedgerEntry{})},
+		{&stats.OpenOrders, db.Model(&models.Order{}).Where("status = ?", models.OrderStatusOpen)},
+		{&stats.ActivePlans, db.Model(&models.ConversionPlan{}).Where("status = ?", models.PlanStatusActive)},
+	}
+	for _, c := range counts {
+		if err := c.query.Count(c.dst).Error; err != nil {
+			return nil, fmt.Errorf("system stats: %w", err)
+		}
+	}
+
+	var latest *time.Time
+	if err := db.Model(&models.Currency{}).Select("MAX(fetched_time)").Scan(&latest).Error; err != nil {
+		return nil, fmt.Errorf("system stats: %w", err)
+	}
+	stats.LatestSnapshot = latest
+	return stats, nil
+}
+
+func (r *systemRepository) PingPostgres(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (r *systemRepository) PingRedis(ctx context.Context) error {
+	return r.redis.Ping(ctx).Err()
+}
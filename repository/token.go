@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) ([]models.RefreshToken, error)
+	RevokeUser(ctx context.Context, userID uint, at time.Time) ([]models.RefreshToken, error)
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("create refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// MarkUsed consumes a live token. It reports false when the token was already used or revoked,
+// which callers treat as reuse.
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", at)
+	if res.Error != nil {
+		return false, fmt.Errorf("mark refresh token used: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every token in the family and returns the family's rows, so callers
+// can also revoke the access tokens issued with them.
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) ([]models.RefreshToken, error) {
+	var rows []models.RefreshToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", at).Error; err != nil {
+			return err
+		}
+		return tx.Where("family_id = ?", familyID).Find(&rows).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("revoke refresh token family: %w", err)
+	}
+	return rows, nil
+}
+
+// RevokeUser revokes every refresh token the user holds and returns the rows whose access
+// tokens may still be live, so callers can deny-list those too.
+func (r *refreshTokenRepository) RevokeUser(ctx context.Context, userID uint, at time.Time) ([]models.RefreshToken, error) {
+	var rows []models.RefreshToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", at).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND access_expires_at > ?", userID, at).Find(&rows).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("revoke user refresh tokens: %w", err)
+	}
+	return rows, nil
+}
+
+// RevocationRepository is the Redis deny-list of access-token IDs (jti). Entries expire with
+// the token they revoke, so the list only ever holds tokens that would otherwise still work.
+type RevocationRepository interface {
+	Revoke(ctx context.Context, jti string, until time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type revocationRepository struct {
+	redis *redis.Client
+}
+
+func NewRevocationRepository(redisClient *redis.Client) RevocationRepository {
+	return &revocationRepository{redis: redisClient}
+}
+
+func revokedKey(jti string) string {
+	return "revoked:jti:" + jti
+}
+
+func (r *revocationRepository) Revoke(ctx context.Context, jti string, until time.Time) error {
+	ttl := time.Until(until)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	if err := r.redis.Set(ctx, revokedKey(jti), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+	return nil
+}
+
+func (r *revocationRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := r.redis.Exists(ctx, revokedKey(jti)).Result()
+	if err != nil {
+		return false, fmt.Errorf("check token revocation: %w", err)
+	}
+	return n > 0, nil
+}
@@ -2,17 +2,30 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/ODawah/Trading-Insights/models"
 	"gorm.io/gorm"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByID(ctx context.Context, id uint) (*models.User, error)
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	UpdateUser(ctx context.Context, user *models.User) error
 	DeleteUser(ctx context.Context, id uint) error
+	ListUsers(ctx context.Context, limit, offset int) ([]models.User, error)
+	SetRole(ctx context.Context, id uint, role string) error
+	SetTOTP(ctx context.Context, id uint, secret string, enabled bool) error
+	AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error)
+	SetPassword(ctx context.Context, id uint, hash string) error
+	MarkEmailVerified(ctx context.Context, id uint, at time.Time) error
+	UpdateProfile(ctx context.Context, user *models.User) error
+	DeleteAccount(ctx context.Context, id uint) error
 }
 
 type userRepository struct {
@@ -30,6 +43,9 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
 	err := r.db.WithContext(ctx).First(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +68,161 @@ func (r *userRepository) UpdateUser(ctx context.Context, user *models.User) erro
 	return r.db.WithContext(ctx).Save(user).Error
 }
 
+// UpdateProfile writes only the fields a user edits themselves, so it never overwrites a
+// password or two-factor change made concurrently.
+func (r *userRepository) UpdateProfile(ctx context.Context, user *models.User) error {
+	res := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", user.ID).
+		Select("name", "email", "email_verified_at", "reporting_currency", "timezone").
+		Updates(user)
+	if res.Error != nil {
+		return fmt.Errorf("update profile: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 func (r *userRepository) DeleteUser(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
 }
+
+// ListUsers pages through accounts in signup order.
+func (r *userRepository) ListUsers(ctx context.Context, limit, offset int) ([]models.User, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	var users []models.User
+	if err := r.db.WithContext(ctx).
+		Order("id").
+		Limit(limit).
+		Offset(offset).
+		Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	return users, nil
+}
+
+func (r *userRepository) SetRole(ctx context.Context, id uint, role string) error {
+	res := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("role", role)
+	if res.Error != nil {
+		return fmt.Errorf("set user role: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetTOTP stores the user's TOTP secret and whether it is enforced. An empty secret with
+// enabled false turns two-factor off.
+func (r *userRepository) SetTOTP(ctx context.Context, id uint, secret string, enabled bool) error {
+	res := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]any{
+		"totp_secret":    secret,
+		"totp_enabled":   enabled,
+		"totp_last_step": 0,
+	})
+	if res.Error != nil {
+		return fmt.Errorf("set totp: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP step. It reports false when a step at
+// or after it was already accepted, i.e. the code is being replayed.
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if res.Error != nil {
+		return false, fmt.Errorf("advance totp step: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// SetPassword stores a new bcrypt hash for the user.
+func (r *userRepository) SetPassword(ctx context.Context, id uint, hash string) error {
+	res := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("password", hash)
+	if res.Error != nil {
+		return fmt.Errorf("set password: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", id).
+		Update("email_verified_at", at).Error; err != nil {
+		return fmt.Errorf("mark email verified: %w", err)
+	}
+	return nil
+}
+
+// DeleteAccount erases a user and everything they own in one transaction: portfolios, ledger
+// entries, orders, plans and their runs, backtests, watch items, data exports and every
+// credential. The auth audit trail is kept for security review but detached from the account
+// and stripped of email, IP and user agent; that includes events recorded by email alone, such
+// as throttled logins. The user row is removed outright so the email can sign up again.
+func (r *userRepository) DeleteAccount(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Select("id", "email").First(&user, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		exports := tx.Model(&models.DataExport{}).Select("id").Where("user_id = ?", id)
+		if err := tx.Where("export_id IN (?)", exports).Delete(&models.DataExportChunk{}).Error; err != nil {
+			return err
+		}
+		owned := []any{
+			&models.PlanExecution{},
+			&models.ConversionPlan{},
+			&models.Order{},
+			&models.UserLedgerEntry{},
+			&models.Portfolio{},
+			&models.BacktestRun{},
+			&models.WatchItem{},
+			&models.APIKey{},
+			&models.RefreshToken{},
+			&models.RecoveryCode{},
+			&models.AccountToken{},
+			&models.DataExport{},
+		}
+		for _, model := range owned {
+			if err := tx.Unscoped().Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.AuthEvent{}).Where("user_id = ? OR email = ?", id, user.Email).Updates(map[string]any{
+			"user_id":    nil,
+			"email":      "",
+			"ip":         "",
+			"user_agent": "",
+		}).Error; err != nil {
+			return err
+		}
+		res := tx.Unscoped().Delete(&models.User{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("delete account: %w", err)
+	}
+	return nil
+}
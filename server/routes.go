@@ -2,8 +2,10 @@ package server
 
 import (
 	"net/http"
+	"os"
 	"time"
 
+	auth "github.com/ODawah/Trading-Insights/authentication"
 	"github.com/ODawah/Trading-Insights/handlers"
 	"github.com/ODawah/Trading-Insights/middleware"
 	"github.com/ODawah/Trading-Insights/services"
@@ -12,12 +14,27 @@ import (
 )
 
 type Services struct {
-	Currency  services.CurrencyService
-	Ingestion services.IngestionService
-	Auth      services.AuthService
-	WatchList services.WatchListService
-	Ledger    services.LedgerService
-	Analytics services.AnalyticsService
+	Currency    services.CurrencyService
+	Ingestion   services.IngestionService
+	Auth        services.AuthService
+	WatchList   services.WatchListService
+	Ledger      services.LedgerService
+	Quotes      services.QuoteService
+	Analytics   services.AnalyticsService
+	Indicators  services.IndicatorService
+	Arbitrage   services.ArbitrageService
+	Backtests   services.BacktestService
+	Portfolios  services.PortfolioService
+	Orders      services.OrderService
+	Plans       services.PlanService
+	SigningKeys services.SigningKeyService
+	Admin       services.AdminService
+	APIKeys     services.APIKeyService
+	MFA         services.MFAService
+	Accounts    services.AccountService
+	Audit       services.AuditService
+	Profiles    services.ProfileService
+	Exports     services.ExportService
 }
 
 func Routes(services *Services) *chi.Mux {
@@ -26,29 +43,90 @@ func Routes(services *Services) *chi.Mux {
 	r.Use(chimiddleware.Logger)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.Timeout(30 * time.Second))
+	if os.Getenv("TRUST_PROXY_HEADERS") == "true" {
+		r.Use(chimiddleware.RealIP)
+	}
+	r.Use(middleware.ClientInfo)
 
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
-	authHandler := handlers.NewAuthHandler(services.Auth)
+	jwksHandler := handlers.NewJWKSHandler(services.SigningKeys)
+	r.Get("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
+	authMiddleware := middleware.NewAuthMiddleware(services.Auth, services.APIKeys)
+	authHandler := handlers.NewAuthHandler(services.Auth, services.Audit)
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/signup", authHandler.Signup)
 		r.Post("/login", authHandler.Login)
+		r.Post("/login/2fa", authHandler.LoginMFA)
+		r.Post("/refresh", authHandler.Refresh)
+
+		accountHandler := handlers.NewAccountHandler(services.Accounts)
+		r.Post("/verify-email", accountHandler.VerifyEmail)
+		r.With(authMiddleware, middleware.RequireSession).Post("/verify-email/resend", accountHandler.ResendVerification)
+		r.Post("/forgot-password", accountHandler.ForgotPassword)
+		r.Post("/reset-password", accountHandler.ResetPassword)
+		r.With(authMiddleware, middleware.RequireSession).Post("/logout", authHandler.Logout)
+		r.With(authMiddleware, middleware.RequireSession).Get("/events", authHandler.ListEvents)
+
+		mfaHandler := handlers.NewMFAHandler(services.MFA)
+		r.Route("/2fa", func(r chi.Router) {
+			r.Use(authMiddleware)
+			r.Use(middleware.RequireSession)
+			r.Get("/", mfaHandler.GetStatus)
+			r.Post("/enroll", mfaHandler.Enroll)
+			r.Post("/verify", mfaHandler.Verify)
+			r.Post("/disable", mfaHandler.Disable)
+			r.Post("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+		})
+	})
+
+	meHandler := handlers.NewMeHandler(services.Profiles, services.Auth)
+	exportHandler := handlers.NewExportHandler(services.Exports)
+	r.Route("/me", func(r chi.Router) {
+		// The signed link is the credential, so downloads work straight from a browser.
+		r.Get("/export/{id}/download", exportHandler.DownloadExport)
+
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware)
+			r.Use(middleware.RequireSession)
+			r.Get("/", meHandler.GetProfile)
+			r.Patch("/", meHandler.UpdateProfile)
+			r.Delete("/", meHandler.DeleteAccount)
+			r.Post("/password", meHandler.ChangePassword)
+			r.Post("/export", exportHandler.RequestExport)
+			r.Get("/export", exportHandler.ListExports)
+			r.Get("/export/{id}", exportHandler.GetExport)
+		})
+	})
+
+	apiKeyHandler := handlers.NewAPIKeyHandler(services.APIKeys)
+	r.Route("/api-keys", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireSession)
+		r.Post("/", apiKeyHandler.CreateAPIKey)
+		r.Get("/", apiKeyHandler.ListAPIKeys)
+		r.Delete("/{id}", apiKeyHandler.RevokeAPIKey)
 	})
 
 	currenciesHandler := handlers.NewCurrenciesHandler(services.Currency)
 	r.Get("/currencies/latest", currenciesHandler.GetAllCurrenciesHandler())
+	r.Get("/currencies/movers", currenciesHandler.GetMoversHandler())
 	r.Get("/currencies/{ticker}/history", currenciesHandler.GetHistoryHandler())
 	r.Get("/currencies/{ticker}/candles", currenciesHandler.GetCandlesHandler())
+	indicatorsHandler := handlers.NewIndicatorsHandler(services.Indicators)
+	r.Get("/currencies/{ticker}/indicators", indicatorsHandler.GetIndicators)
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware) // Apply JWT middleware
+		r.Use(authMiddleware) // Apply JWT middleware
 
 	})
 	watchListHandler := handlers.NewWatchListHandler(services.WatchList)
 	r.Route("/watchlist", func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware)
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireReadWriteScope(auth.ScopeMarketRead, auth.ScopeWatchlistWrite))
 		r.Post("/add", watchListHandler.AddToWatchlist)
 		r.Post("/remove", watchListHandler.RemoveFromWatchlist)
 		r.Get("/", watchListHandler.GetWatchlist)
@@ -56,23 +134,101 @@ func Routes(services *Services) *chi.Mux {
 
 	ledgerHandler := handlers.NewLedgerHandler(services.Ledger)
 	r.Route("/ledger", func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware)
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireReadWriteScope(auth.ScopeLedgerRead, auth.ScopeLedgerWrite))
 		r.Post("/exchange", ledgerHandler.RecordExchange)
 		r.Get("/", ledgerHandler.ListEntries)
 		r.Get("/trade/{tradeID}", ledgerHandler.GetTrade)
 	})
 
-	analyticsHandler := handlers.NewAnalyticsHandler(services.Analytics)
+	portfolioHandler := handlers.NewPortfolioHandler(services.Portfolios)
+	r.Route("/portfolios", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireReadWriteScope(auth.ScopeLedgerRead, auth.ScopeLedgerWrite))
+		r.Get("/", portfolioHandler.ListPortfolios)
+		r.Post("/", portfolioHandler.CreatePortfolio)
+		r.Get("/{id}", portfolioHandler.GetPortfolio)
+		r.Patch("/{id}", portfolioHandler.RenamePortfolio)
+		r.Delete("/{id}", portfolioHandler.DeletePortfolio)
+		r.Post("/{id}/deposit", portfolioHandler.Deposit)
+	})
+
+	orderHandler := handlers.NewOrderHandler(services.Orders)
+	r.Route("/orders", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireReadWriteScope(auth.ScopeLedgerRead, auth.ScopeLedgerWrite))
+		r.Post("/", orderHandler.PlaceOrder)
+		r.Get("/", orderHandler.ListOrders)
+		r.Get("/{id}", orderHandler.GetOrder)
+		r.Delete("/{id}", orderHandler.CancelOrder)
+	})
+
+	planHandler := handlers.NewPlanHandler(services.Plans)
+	r.Route("/plans", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireReadWriteScope(auth.ScopeLedgerRead, auth.ScopeLedgerWrite))
+		r.Post("/", planHandler.CreatePlan)
+		r.Get("/", planHandler.ListPlans)
+		r.Get("/{id}", planHandler.GetPlan)
+		r.Patch("/{id}", planHandler.UpdatePlan)
+		r.Delete("/{id}", planHandler.DeletePlan)
+		r.Post("/{id}/pause", planHandler.PausePlan)
+		r.Post("/{id}/resume", planHandler.ResumePlan)
+		r.Get("/{id}/executions", planHandler.ListExecutions)
+	})
+
+	quoteHandler := handlers.NewQuoteHandler(services.Quotes)
+	r.Route("/quotes", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireScope(auth.ScopeMarketRead))
+		r.Post("/", quoteHandler.CreateQuote)
+	})
+
+	backtestHandler := handlers.NewBacktestHandler(services.Backtests)
+	r.Route("/backtests", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireScope(auth.ScopeMarketRead))
+		r.Post("/", backtestHandler.RunBacktest)
+		r.Get("/", backtestHandler.ListBacktests)
+		r.Get("/{id}", backtestHandler.GetBacktest)
+	})
+
+	adminHandler := handlers.NewAdminHandler(services.Admin)
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireSession)
+		r.Use(middleware.RequireRole(auth.RoleAdmin))
+		r.With(middleware.RequirePermission(auth.PermissionUsersRead)).Get("/users", adminHandler.ListUsers)
+		r.With(middleware.RequirePermission(auth.PermissionUsersRead)).Get("/users/{id}", adminHandler.GetUser)
+		r.With(middleware.RequirePermission(auth.PermissionUsersRead)).Get("/users/{id}/events", adminHandler.ListUserEvents)
+		r.With(middleware.RequirePermission(auth.PermissionUsersWrite)).Patch("/users/{id}/role", adminHandler.SetRole)
+		r.With(middleware.RequirePermission(auth.PermissionUsersWrite)).Delete("/users/{id}", adminHandler.DeleteUser)
+		r.With(middleware.RequirePermission(auth.PermissionIngestionRun)).Post("/ingestion/run", adminHandler.RunIngestion)
+		r.With(middleware.RequirePermission(auth.PermissionSystemInspect)).Get("/system", adminHandler.SystemStatus)
+	})
+
+	analyticsHandler := handlers.NewAnalyticsHandler(services.Analytics, services.Profiles)
+	arbitrageHandler := handlers.NewArbitrageHandler(services.Arbitrage)
 	r.Route("/analytics", func(r chi.Router) {
 		r.Get("/cross", analyticsHandler.CrossRate)
 		r.Get("/chart", analyticsHandler.ChartCross)
+		r.Get("/candles", analyticsHandler.CrossCandles)
+		r.Get("/volatility", analyticsHandler.PairVolatility)
+		r.Get("/distribution", analyticsHandler.ReturnDistribution)
 		r.Get("/convert", analyticsHandler.ConvertAt)
 		r.Get("/correlation", analyticsHandler.Correlation)
+		r.Get("/correlation/matrix", analyticsHandler.CorrelationMatrix)
+		r.Get("/correlation/rolling", analyticsHandler.RollingCorrelation)
+		r.Get("/arbitrage", arbitrageHandler.ListDetections)
 
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.AuthMiddleware)
+			r.Use(authMiddleware)
+			r.Use(middleware.RequireScope(auth.ScopeLedgerRead))
 			r.Get("/portfolio/value", analyticsHandler.PortfolioValue)
 			r.Get("/portfolio/history", analyticsHandler.PortfolioHistory)
+			r.Get("/portfolio/pnl", analyticsHandler.PortfolioPnL)
+			r.Get("/portfolio/returns", analyticsHandler.PortfolioReturns)
+			r.Get("/portfolio/risk", analyticsHandler.PortfolioRisk)
 		})
 	})
 
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/mailer"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+	defaultAppURL    = "http://localhost:8000"
+)
+
+var ErrInvalidAccountToken = errors.New("invalid or expired token")
+
+// AccountService owns the emailed flows: verifying an address and resetting a forgotten
+// password. Tokens are single use, expire, and are stored only as hashes.
+type AccountService interface {
+	SendVerification(ctx context.Context, userID uint) error
+	NotifySignupAttempt(ctx context.Context, userID uint) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, req *ResetPasswordRequest) error
+}
+
+type accountService struct {
+	users         repository.UserRepository
+	tokens        repository.AccountTokenRepository
+	refreshTokens repository.RefreshTokenRepository
+	revocations   repository.RevocationRepository
+	mail          mailer.Sender
+	audit         AuditService
+	appURL        string
+	// background runs work that must not hold up the response; tests run it inline.
+	background func(func())
+}
+
+// NewAccountService builds links in emails from APP_BASE_URL (default http://localhost:8000).
+func NewAccountService(users repository.UserRepository, tokens repository.AccountTokenRepository, refreshTokens repository.RefreshTokenRepository, revocations repository.RevocationRepository, mail mailer.Sender, audit AuditService) AccountService {
+	appURL := strings.TrimRight(strings.TrimSpace(os.Getenv("APP_BASE_URL")), "/")
+	if appURL == "" {
+		appURL = defaultAppURL
+	}
+	return &accountService{
+		users:         users,
+		tokens:        tokens,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		mail:          mail,
+		audit:         audit,
+		appURL:        appURL,
+		background:    func(f func()) { go f() },
+	}
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// SendVerification mails a fresh verification link; earlier links stop working.
+func (s *accountService) SendVerification(ctx context.Context, userID uint) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return fmt.Errorf("email is already verified")
+	}
+	token, err := s.issueToken(ctx, user.ID, models.TokenPurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	return s.mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: "Confirm this address for your Trading-Insights account:\n\n" +
+			s.link("/verify-email", token) + "\n\n" +
+			"The link expires in 48 hours. If you did not sign up, ignore this email.",
+	})
+}
+
+// NotifySignupAttempt tells the owner of an address that someone tried to sign up with it.
+// Signup sends this instead of an error so it does not reveal which emails are registered.
+func (s *accountService) NotifySignupAttempt(ctx context.Context, userID uint) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Sign-up attempt with your email address",
+		Body: "Someone tried to create a Trading-Insights account with this address, which already has one.\n\n" +
+			"If it was you, log in instead, or reset your password at " + s.appURL + "/forgot-password.\n" +
+			"If it was not you, you can ignore this email.",
+	})
+}
+
+func (s *accountService) VerifyEmail(ctx context.Context, token string) error {
+	stored, err := s.consume(ctx, models.TokenPurposeVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+	if err := s.users.MarkEmailVerified(ctx, stored.UserID, time.Now().UTC()); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuthEventEmailVerified, stored.UserID, "", "")
+	return nil
+}
+
+// ForgotPassword mails a reset link when the address belongs to an account. It succeeds either
+// way, so callers cannot learn which addresses are registered. The link is issued and mailed in
+// the background, so both cases answer after the same single lookup.
+func (s *accountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	s.background(func() {
+		if err := s.sendPasswordReset(ctx, user); err != nil {
+			log.Printf("⚠️  Failed to send password reset to user %d: %v", user.ID, err)
+		}
+	})
+	return nil
+}
+
+func (s *accountService) sendPasswordReset(ctx context.Context, user *models.User) error {
+	token, err := s.issueToken(ctx, user.ID, models.TokenPurposeResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuthEventPasswordForgot, user.ID, user.Email, "")
+	return s.mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: "Use this link to choose a new password:\n\n" +
+			s.link("/reset-password", token) + "\n\n" +
+			"The link expires in one hour and works once. If you did not ask for it, ignore this email.",
+	})
+}
+
+// ResetPassword sets a new password and signs the user out everywhere: every refresh token is
+// revoked and the access tokens issued with them are deny-listed.
+func (s *accountService) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
+	if len(req.Password) < 6 {
+		return fmt.Errorf("password must be at least 6 characters long")
+	}
+	stored, err := s.consume(ctx, models.TokenPurposeResetPassword, req.Token)
+	if err != nil {
+		return err
+	}
+
+	user := &models.User{}
+	if err := user.HashPassword(req.Password); err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+	if err := s.users.SetPassword(ctx, stored.UserID, user.Password); err != nil {
+		return err
+	}
+	// Receiving the reset email proves the address as well.
+	now := time.Now().UTC()
+	if err := s.users.MarkEmailVerified(ctx, stored.UserID, now); err != nil {
+		return err
+	}
+
+	sessions, err := revokeSessions(ctx, s.refreshTokens, s.revocations, stored.UserID, now)
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuthEventPasswordReset, stored.UserID, "", fmt.Sprintf("revoked %d sessions", sessions))
+	log.Printf("🔐 Password reset for user %d; revoked %d sessions", stored.UserID, sessions)
+	return nil
+}
+
+// revokeSessions revokes every refresh token the user holds and deny-lists the access tokens
+// still live under them. It returns how many sessions were revoked.
+func revokeSessions(ctx context.Context, refreshTokens repository.RefreshTokenRepository, revocations repository.RevocationRepository, userID uint, now time.Time) (int, error) {
+	sessions, err := refreshTokens.RevokeUser(ctx, userID, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range sessions {
+		if err := revocations.Revoke(ctx, t.AccessJTI, t.AccessExpiresAt); err != nil {
+			return 0, err
+		}
+	}
+	return len(sessions), nil
+}
+
+func (s *accountService) issueToken(ctx context.Context, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	if err := s.tokens.InvalidateUser(ctx, userID, purpose, now); err != nil {
+		return "", err
+	}
+	if err := s.tokens.Create(ctx, &models.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: now.Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *accountService) consume(ctx context.Context, purpose, token string) (*models.AccountToken, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrInvalidAccountToken
+	}
+	stored, err := s.tokens.Consume(ctx, purpose, auth.HashRefreshToken(token), time.Now().UTC())
+	if errors.Is(err, repository.ErrAccountTokenInvalid) {
+		return nil, ErrInvalidAccountToken
+	}
+	return stored, err
+}
+
+func (s *accountService) link(path, token string) string {
+	return s.appURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/mailer"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeUserRepo) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, user := range f.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeUserRepo) SetPassword(ctx context.Context, id uint, hash string) error {
+	f.users[id].Password = hash
+	return nil
+}
+
+func (f *fakeUserRepo) MarkEmailVerified(ctx context.Context, id uint, at time.Time) error {
+	if f.users[id].EmailVerifiedAt == nil {
+		f.users[id].EmailVerifiedAt = &at
+	}
+	return nil
+}
+
+type fakeAccountTokens struct {
+	tokens []*models.AccountToken
+}
+
+func (f *fakeAccountTokens) Create(ctx context.Context, token *models.AccountToken) error {
+	f.tokens = append(f.tokens, token)
+	return nil
+}
+
+func (f *fakeAccountTokens) Consume(ctx context.Context, purpose, hash string, at time.Time) (*models.AccountToken, error) {
+	for _, t := range f.tokens {
+		if t.TokenHash == hash && t.Purpose == purpose && t.UsedAt == nil && t.ExpiresAt.After(at) {
+			t.UsedAt = &at
+			return t, nil
+		}
+	}
+	return nil, repository.ErrAccountTokenInvalid
+}
+
+func (f *fakeAccountTokens) InvalidateUser(ctx context.Context, userID uint, purpose string, at time.Time) error {
+	for _, t := range f.tokens {
+		if t.UserID == userID && t.Purpose == purpose && t.UsedAt == nil {
+			t.UsedAt = &at
+		}
+	}
+	return nil
+}
+
+type fakeMailer struct {
+	sent []mailer.Message
+}
+
+func (f *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// linkToken pulls the token out of the link in the last email sent.
+func (f *fakeMailer) linkToken(t *testing.T) string {
+	body := f.sent[len(f.sent)-1].Body
+	start := strings.Index(body, "http")
+	end := start + strings.IndexAny(body[start:], "\n ")
+	link, err := url.Parse(body[start:end])
+	assert.NoError(t, err)
+	return link.Query().Get("token")
+}
+
+func TestResetPasswordIsSingleUseAndRevokesSessions(t *testing.T) {
+	user := &models.User{Email: "a@example.com"}
+	user.ID = 7
+	assert.NoError(t, user.HashPassword("old-password"))
+	users := &fakeUserRepo{users: map[uint]*models.User{7: user}}
+	refresh := &fakeRefreshTokenRepo{}
+	revoked := fakeRevocations{}
+	mail := &fakeMailer{}
+	svc := NewAccountService(users, &fakeAccountTokens{}, refresh, revoked, mail, &fakeAudit{})
+	var queued []func()
+	svc.(*accountService).background = func(f func()) { queued = append(queued, f) }
+	ctx := context.Background()
+
+	assert.NoError(t, refresh.Create(ctx, &models.RefreshToken{UserID: 7, AccessJTI: "live", AccessExpiresAt: time.Now().Add(time.Minute)}))
+
+	// Unknown addresses succeed silently; known ones are mailed in the background.
+	assert.NoError(t, svc.ForgotPassword(ctx, "nobody@example.com"))
+	assert.Empty(t, queued)
+
+	assert.NoError(t, svc.ForgotPassword(ctx, " A@example.com "))
+	assert.Empty(t, mail.sent)
+	if !assert.Len(t, queued, 1) {
+		return
+	}
+	queued[0]()
+	first := mail.linkToken(t)
+	assert.NoError(t, svc.ForgotPassword(ctx, "a@example.com"))
+	queued[1]()
+	second := mail.linkToken(t)
+
+	// Only the newest link works, and only once.
+	assert.ErrorIs(t, svc.ResetPassword(ctx, &ResetPasswordRequest{Token: first, Password: "new-password"}), ErrInvalidAccountToken)
+	assert.NoError(t, svc.ResetPassword(ctx, &ResetPasswordRequest{Token: second, Password: "new-password"}))
+	assert.ErrorIs(t, svc.ResetPassword(ctx, &ResetPasswordRequest{Token: second, Password: "other-password"}), ErrInvalidAccountToken)
+
+	assert.True(t, user.CheckPassword("new-password"))
+	assert.NotNil(t, refresh.tokens[0].RevokedAt)
+	assert.True(t, revoked["live"])
+	assert.NotNil(t, user.EmailVerifiedAt)
+}
+
+func TestVerifyEmailToken(t *testing.T) {
+	user := &models.User{Email: "a@example.com"}
+	user.ID = 7
+	mail := &fakeMailer{}
+	svc := NewAccountService(&fakeUserRepo{users: map[uint]*models.User{7: user}}, &fakeAccountTokens{}, &fakeRefreshTokenRepo{}, fakeRevocations{}, mail, &fakeAudit{})
+	ctx := context.Background()
+
+	assert.NoError(t, svc.SendVerification(ctx, 7))
+	token := mail.linkToken(t)
+	assert.ErrorIs(t, svc.VerifyEmail(ctx, auth.HashRefreshToken(token)), ErrInvalidAccountToken)
+	assert.NoError(t, svc.VerifyEmail(ctx, token))
+	assert.NotNil(t, user.EmailVerifiedAt)
+	assert.Error(t, svc.SendVerification(ctx, 7))
+}
+
+func TestValidEmail(t *testing.T) {
+	for _, email := range []string{"a@example.com", "first.last+tag@sub.example.co.uk"} {
+		assert.True(t, validEmail(email), email)
+	}
+	for _, email := range []string{"@", "a@b", "a@.com", "a@example.", "Name <a@example.com>", "a b@example.com", "a@example.com,b@example.com"} {
+		assert.False(t, validEmail(email), email)
+	}
+}
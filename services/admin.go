@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+type AdminService interface {
+	ListUsers(ctx context.Context, limit, offset int) ([]models.User, error)
+	GetUser(ctx context.Context, id uint) (*models.User, error)
+	UserEvents(ctx context.Context, id uint, limit int) ([]models.AuthEvent, error)
+	SetRole(ctx context.Context, actorID, id uint, role string) (*models.User, error)
+	DeleteUser(ctx context.Context, actorID, id uint) error
+	RunIngestion(ctx context.Context) (*IngestionRun, error)
+	Status(ctx context.Context) (*SystemStatus, error)
+}
+
+type adminService struct {
+	users         repository.UserRepository
+	refreshTokens repository.RefreshTokenRepository
+	revocations   repository.RevocationRepository
+	system        repository.SystemRepository
+	ingestion     IngestionService
+	keyring       *auth.Keyring
+	audit         AuditService
+	profiles      ProfileService
+	started       time.Time
+}
+
+func NewAdminService(users repository.UserRepository, refreshTokens repository.RefreshTokenRepository, revocations repository.RevocationRepository, system repository.SystemRepository, ingestion IngestionService, keyring *auth.Keyring, audit AuditService, profiles ProfileService) AdminService {
+	return &adminService{
+		users:         users,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		system:        system,
+		ingestion:     ingestion,
+		keyring:       keyring,
+		audit:         audit,
+		profiles:      profiles,
+		started:       time.Now().UTC(),
+	}
+}
+
+// IngestionRun summarizes a manually triggered fetch.
+type IngestionRun struct {
+	Base      string    `json:"base"`
+	Timestamp time.Time `json:"timestamp"`
+	Rates     int       `json:"rates"`
+	Duration  string    `json:"duration"`
+}
+
+type SystemStatus struct {
+	StartedAt   time.Time               `json:"started_at"`
+	Uptime      string                  `json:"uptime"`
+	Postgres    string                  `json:"postgres"`
+	Redis       string                  `json:"redis"`
+	Stats       *repository.SystemStats `json:"stats,omitempty"`
+	SigningKeys []SigningKeyStatus      `json:"signing_keys"`
+}
+
+type SigningKeyStatus struct {
+	KID       string    `json:"kid"`
+	Algorithm string    `json:"algorithm"`
+	CreatedAt time.Time `json:"created_at"`
+	Active    bool      `json:"active"` // signs new tokens; the others only verify
+}
+
+func (s *adminService) ListUsers(ctx context.Context, limit, offset int) ([]models.User, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+	return s.users.ListUsers(ctx, limit, offset)
+}
+
+func (s *adminService) GetUser(ctx context.Context, id uint) (*models.User, error) {
+	return s.users.FindByID(ctx, id)
+}
+
+// UserEvents returns a user's authentication audit trail, newest first.
+func (s *adminService) UserEvents(ctx context.Context, id uint, limit int) ([]models.AuthEvent, error) {
+	if _, err := s.users.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.audit.List(ctx, id, limit)
+}
+
+// SetRole changes a user's role. Admins cannot change their own role, so the last admin can
+// never lock everyone out. The new role applies from the user's next access token.
+func (s *adminService) SetRole(ctx context.Context, actorID, id uint, role string) (*models.User, error) {
+	role, err := auth.NormalizeRole(role)
+	if err != nil {
+		return nil, err
+	}
+	if actorID == id {
+		return nil, fmt.Errorf("you cannot change your own role")
+	}
+	user, err := s.users.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+	if err := s.users.SetRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+	// Access tokens carry the role and its permissions, so sign the user out everywhere; the
+	// next login picks up the new role.
+	revoked, err := revokeSessions(ctx, s.refreshTokens, s.revocations, id, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("👤 Changed role of user %d to %s; revoked %d sessions", id, role, revoked)
+	return s.users.FindByID(ctx, id)
+}
+
+// DeleteUser erases an account the same way closing it from /me does.
+func (s *adminService) DeleteUser(ctx context.Context, actorID, id uint) error {
+	if actorID == id {
+		return fmt.Errorf("you cannot delete your own account here")
+	}
+	if _, err := s.users.FindByID(ctx, id); err != nil {
+		return err
+	}
+	return s.profiles.Erase(ctx, id)
+}
+
+// RunIngestion fetches and stores a snapshot now instead of waiting for the scheduler.
+func (s *adminService) RunIngestion(ctx context.Context) (*IngestionRun, error) {
+	started := time.Now()
+	snapshot, err := s.ingestion.FetchRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &IngestionRun{
+		Base:      snapshot.Base,
+		Timestamp: snapshot.Timestamp,
+		Rates:     len(snapshot.Result),
+		Duration:  time.Since(started).Round(time.Millisecond).String(),
+	}, nil
+}
+
+// Status reports dependency health, stored volumes and the signing keys in use. A failing
+// dependency is reported in the result rather than as an error.
+func (s *adminService) Status(ctx context.Context) (*SystemStatus, error) {
+	status := &SystemStatus{
+		StartedAt:   s.started,
+		Uptime:      time.Since(s.started).Round(time.Second).String(),
+		Postgres:    "ok",
+		Redis:       "ok",
+		SigningKeys: []SigningKeyStatus{},
+	}
+	if err := s.system.PingPostgres(ctx); err != nil {
+		status.Postgres = err.Error()
+	} else if stats, err := s.system.Stats(ctx); err == nil {
+		status.Stats = stats
+	} else {
+		status.Postgres = err.Error()
+	}
+	if err := s.system.PingRedis(ctx); err != nil {
+		status.Redis = err.Error()
+	}
+	for i, key := range s.keyring.Keys() {
+		status.SigningKeys = append(status.SigningKeys, SigningKeyStatus{
+			KID:       key.ID,
+			Algorithm: key.Algorithm,
+			CreatedAt: key.CreatedAt,
+			Active:    i == 0,
+		})
+	}
+	return status, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeUserRepo) SetRole(ctx context.Context, id uint, role string) error {
+	user, ok := f.users[id]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	user.Role = role
+	return nil
+}
+
+func (f *fakeUserRepo) DeleteAccount(ctx context.Context, id uint) error {
+	if _, ok := f.users[id]; !ok {
+		return repository.ErrUserNotFound
+	}
+	delete(f.users, id)
+	return nil
+}
+
+func TestAdminCannotDemoteOrDeleteThemselves(t *testing.T) {
+	users := &fakeUserRepo{users: map[uint]*models.User{
+		1: {Email: "admin@example.com", Role: auth.RoleAdmin},
+		2: {Email: "user@example.com", Role: auth.RoleUser},
+	}}
+	refresh := &fakeRefreshTokenRepo{}
+	revoked := fakeRevocations{}
+	profiles := NewProfileService(users, refresh, revoked, nil, nil, nil, &fakeAudit{})
+	svc := NewAdminService(users, refresh, revoked, nil, nil, auth.NewKeyring(), &fakeAudit{}, profiles)
+	ctx := context.Background()
+	assert.NoError(t, refresh.Create(ctx, &models.RefreshToken{UserID: 2, AccessJTI: "live", AccessExpiresAt: time.Now().Add(time.Minute)}))
+
+	_, err := svc.SetRole(ctx, 1, 1, auth.RoleUser)
+	assert.Error(t, err)
+	assert.Equal(t, auth.RoleAdmin, users.users[1].Role)
+	assert.Error(t, svc.DeleteUser(ctx, 1, 1))
+
+	_, err = svc.SetRole(ctx, 1, 2, "superuser")
+	assert.Error(t, err)
+
+	promoted, err := svc.SetRole(ctx, 1, 2, " Admin ")
+	assert.NoError(t, err)
+	assert.Equal(t, auth.RoleAdmin, promoted.Role)
+	// Tokens issued under the old role stop working.
+	assert.True(t, revoked["live"])
+	assert.NotNil(t, refresh.tokens[0].RevokedAt)
+
+	assert.ErrorIs(t, svc.DeleteUser(ctx, 1, 3), repository.ErrUserNotFound)
+	assert.NoError(t, svc.DeleteUser(ctx, 1, 2))
+	assert.NotContains(t, users.users, uint(2))
+}
@@ -8,26 +8,37 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ODawah/Trading-Insights/models"
 	"github.com/ODawah/Trading-Insights/repository"
 )
 
 type AnalyticsService interface {
 	CrossRateHistory(ctx context.Context, currencyA, currencyB string, from, to *time.Time, limit int) ([]CrossPoint, error)
 	Correlation(ctx context.Context, currencyA, currencyB string, from, to *time.Time, limit int) (*CorrelationResult, error)
+	CorrelationMatrix(ctx context.Context, tickers []string, bucket string, from, to *time.Time, limit int) (*CorrelationMatrix, error)
+	RollingCorrelation(ctx context.Context, currencyA, currencyB, bucket string, window int, from, to *time.Time, limit int) (*RollingCorrelation, error)
 	CrossRateAt(ctx context.Context, currencyA, currencyB string, at time.Time) (*CrossPoint, error)
-	PortfolioValueAt(ctx context.Context, userID uint, inCurrency string, at time.Time) (*PortfolioValue, error)
-	PortfolioValueHistory(ctx context.Context, userID uint, inCurrency string, from, to time.Time, limit int) ([]PortfolioValue, error)
+	CrossCandles(ctx context.Context, currencyA, currencyB, bucket string, from, to *time.Time, limit int) ([]repository.CandleRow, error)
+	PairVolatility(ctx context.Context, currencyA, currencyB, bucket string, windows []int, from, to *time.Time, limit int) (*PairVolatility, error)
+	ReturnDistribution(ctx context.Context, currencyA, currencyB, bucket string, bins int, from, to *time.Time, limit int) (*ReturnDistribution, error)
+	PortfolioValueAt(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency string, at time.Time) (*PortfolioValue, error)
+	PortfolioValueHistory(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency string, from, to time.Time, limit int) ([]PortfolioValue, error)
+	PortfolioPnL(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency, method string, at time.Time) (*PnLReport, error)
+	PortfolioReturns(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency string, from, to time.Time, period string) (*PortfolioReturns, error)
+	PortfolioRisk(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency string, from, to time.Time, opts RiskOptions) (*PortfolioRisk, error)
 }
 
 type analyticsService struct {
 	currencyRepo repository.CurrencyRepository
 	ledgerRepo   repository.LedgerRepository
+	portfolios   repository.PortfolioRepository
 }
 
-func NewAnalyticsService(currencyRepo repository.CurrencyRepository, ledgerRepo repository.LedgerRepository) AnalyticsService {
+func NewAnalyticsService(currencyRepo repository.CurrencyRepository, ledgerRepo repository.LedgerRepository, portfolios repository.PortfolioRepository) AnalyticsService {
 	return &analyticsService{
 		currencyRepo: currencyRepo,
 		ledgerRepo:   ledgerRepo,
+		portfolios:   portfolios,
 	}
 }
 
@@ -72,27 +83,122 @@ func (s *analyticsService) CrossRateHistory(ctx context.Context, currencyA, curr
 	return out, nil
 }
 
+// CrossRateAt returns the latest stored cross rate at or before at. Point.Time is the
+// snapshot the rate was taken from, so callers can judge how stale the quote is.
 func (s *analyticsService) CrossRateAt(ctx context.Context, currencyA, currencyB string, at time.Time) (*CrossPoint, error) {
-	from := &at
-	to := &at
-	points, err := s.CrossRateHistory(ctx, currencyA, currencyB, from, to, 1)
+	a := strings.ToUpper(strings.TrimSpace(currencyA))
+	b := strings.ToUpper(strings.TrimSpace(currencyB))
+	if a == "" || b == "" {
+		return nil, fmt.Errorf("currency_a and currency_b are required")
+	}
+	if a == b {
+		return nil, fmt.Errorf("currencies must be different")
+	}
+
+	rows, err := s.currencyRepo.LatestRatesAtOrBefore(ctx, []string{a, b}, at)
 	if err != nil {
 		return nil, err
 	}
-	if len(points) == 0 {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rate available at requested time")
+	}
+
+	byTicker := make(map[string]float64, len(rows))
+	base := ""
+	var quoteTime time.Time
+	for _, r := range rows {
+		byTicker[strings.ToUpper(r.Ticker)] = r.Rate
+		if base == "" && strings.TrimSpace(r.Base) != "" {
+			base = strings.ToUpper(strings.TrimSpace(r.Base))
+		}
+		// Use the older side so the quote never looks fresher than its stalest leg.
+		if quoteTime.IsZero() || r.FetchedTime.Before(quoteTime) {
+			quoteTime = r.FetchedTime
+		}
+	}
+	if base == "" {
+		base = "USD"
+	}
+
+	// The base currency is not stored as a ticker row; it is 1.0 by definition.
+	byTicker[base] = 1.0
+	rateA, okA := byTicker[a]
+	rateB, okB := byTicker[b]
+	if !okA || !okB || rateA <= 0 || rateB <= 0 {
 		return nil, fmt.Errorf("no rate available at requested time")
 	}
-	return &points[0], nil
+
+	return &CrossPoint{
+		Time: quoteTime,
+		Rate: rateB / rateA,
+		Base: base,
+		A:    a,
+		B:    b,
+	}, nil
+}
+
+// CrossCandles returns OHLC candles for A/B (units of B per one A) in any supported bucket size,
+// the most recent limit buckets in ascending order.
+//
+// Rates are stored against USD, which has no rows of its own: it is 1.0 at every snapshot. A
+// pair against USD is therefore read from the other currency's own candles, inverted when USD
+// is the quote side; other pairs are aggregated from paired snapshots.
+func (s *analyticsService) CrossCandles(ctx context.Context, currencyA, currencyB, bucket string, from, to *time.Time, limit int) ([]repository.CandleRow, error) {
+	a := strings.ToUpper(strings.TrimSpace(currencyA))
+	b := strings.ToUpper(strings.TrimSpace(currencyB))
+	if a == "" || b == "" {
+		return nil, fmt.Errorf("currency_a and currency_b are required")
+	}
+	if a == b {
+		return nil, fmt.Errorf("currencies must be different")
+	}
+	interval, err := normalizeCandleBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case a == "USD":
+		return s.currencyRepo.ListCandles(ctx, b, interval, from, to, limit)
+	case b == "USD":
+		candles, err := s.currencyRepo.ListCandles(ctx, a, interval, from, to, limit)
+		if err != nil {
+			return nil, err
+		}
+		return invertCandles(candles), nil
+	default:
+		return s.currencyRepo.ListPairedCandles(ctx, a, b, interval, from, to, limit)
+	}
+}
+
+// invertCandles turns candles of X per USD into candles of USD per X. Inverting swaps high and
+// low. A bucket holding a non-positive rate has no finite inverse and is dropped, as paired
+// candles drop samples whose A rate is not positive.
+func invertCandles(candles []repository.CandleRow) []repository.CandleRow {
+	out := make([]repository.CandleRow, 0, len(candles))
+	for _, c := range candles {
+		if c.Low <= 0 {
+			continue
+		}
+		out = append(out, repository.CandleRow{
+			Bucket:  c.Bucket,
+			Open:    1 / c.Open,
+			High:    1 / c.Low,
+			Low:     1 / c.High,
+			Close:   1 / c.Close,
+			Samples: c.Samples,
+		})
+	}
+	return out
 }
 
 type CorrelationResult struct {
-	A           string    `json:"a"`
-	B           string    `json:"b"`
-	Base        string    `json:"base"`
+	A           string     `json:"a"`
+	B           string     `json:"b"`
+	Base        string     `json:"base"`
 	From        *time.Time `json:"from,omitempty"`
 	To          *time.Time `json:"to,omitempty"`
-	Samples     int       `json:"samples"`
-	Correlation float64   `json:"correlation"`
+	Samples     int        `json:"samples"`
+	Correlation float64    `json:"correlation"`
 }
 
 func (s *analyticsService) Correlation(ctx context.Context, currencyA, currencyB string, from, to *time.Time, limit int) (*CorrelationResult, error) {
@@ -156,7 +262,7 @@ type PortfolioValue struct {
 	Value float64   `json:"value"`
 }
 
-func (s *analyticsService) PortfolioValueAt(ctx context.Context, userID uint, inCurrency string, at time.Time) (*PortfolioValue, error) {
+func (s *analyticsService) PortfolioValueAt(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency string, at time.Time) (*PortfolioValue, error) {
 	if userID == 0 {
 		return nil, fmt.Errorf("user_id is required")
 	}
@@ -167,7 +273,11 @@ func (s *analyticsService) PortfolioValueAt(ctx context.Context, userID uint, in
 
 	// Balances as-of 'at' (approximation: balances before at + entries between [at,at] are ignored).
 	// For point-in-time valuation in this codebase, executed_at and fetched_time typically share the same timeline.
-	balances, err := s.ledgerRepo.BalancesBefore(ctx, userID, at.Add(1*time.Nanosecond))
+	portfolioIDs, err := resolvePortfolioIDs(ctx, s.portfolios, userID, portfolios)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.ledgerRepo.BalancesBefore(ctx, userID, portfolioIDs, at.Add(1*time.Nanosecond))
 	if err != nil {
 		return nil, err
 	}
@@ -184,23 +294,11 @@ func (s *analyticsService) PortfolioValueAt(ctx context.Context, userID uint, in
 	}
 	tickers = uniqueStrings(tickers)
 
-	rates, err := s.currencyRepo.LatestRatesAtOrBefore(ctx, tickers, at)
+	rateByTicker, baseCurrency, err := s.ratesAt(ctx, tickers, at)
 	if err != nil {
 		return nil, err
 	}
 
-	rateByTicker := make(map[string]float64, len(rates))
-	baseCurrency := ""
-	for _, r := range rates {
-		rateByTicker[strings.ToUpper(r.Ticker)] = r.Rate
-		if baseCurrency == "" && strings.TrimSpace(r.Base) != "" {
-			baseCurrency = strings.ToUpper(strings.TrimSpace(r.Base))
-		}
-	}
-	if baseCurrency == "" {
-		baseCurrency = "USD"
-	}
-
 	valueIn, err := valueBalancesIn(balances, rateByTicker, baseCurrency, in)
 	if err != nil {
 		return nil, err
@@ -213,7 +311,7 @@ func (s *analyticsService) PortfolioValueAt(ctx context.Context, userID uint, in
 	}, nil
 }
 
-func (s *analyticsService) PortfolioValueHistory(ctx context.Context, userID uint, inCurrency string, from, to time.Time, limit int) ([]PortfolioValue, error) {
+func (s *analyticsService) PortfolioValueHistory(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency string, from, to time.Time, limit int) ([]PortfolioValue, error) {
 	if userID == 0 {
 		return nil, fmt.Errorf("user_id is required")
 	}
@@ -228,23 +326,47 @@ func (s *analyticsService) PortfolioValueHistory(ctx context.Context, userID uin
 		limit = 500
 	}
 
-	startBalances, err := s.ledgerRepo.BalancesBefore(ctx, userID, from)
+	fromPtr := &from
+	toPtr := &to
+	times, err := s.currencyRepo.ListSnapshotTimes(ctx, fromPtr, toPtr, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no price snapshots in requested time range")
+	}
+
+	portfolioIDs, err := resolvePortfolioIDs(ctx, s.portfolios, userID, portfolios)
 	if err != nil {
 		return nil, err
 	}
-	entries, err := s.ledgerRepo.ListByUserBetween(ctx, userID, from, to)
+	points, err := s.valuationSeries(ctx, userID, portfolioIDs, in, from, to, times)
 	if err != nil {
 		return nil, err
 	}
+	out := make([]PortfolioValue, 0, len(points))
+	for _, p := range points {
+		out = append(out, p.PortfolioValue)
+	}
+	return out, nil
+}
 
-	fromPtr := &from
-	toPtr := &to
-	times, err := s.currencyRepo.ListSnapshotTimes(ctx, fromPtr, toPtr, limit)
+// valuationPoint is a portfolio value plus the external flows (adjustment entries) applied since
+// the previous point, valued in the output currency at this point's rates.
+type valuationPoint struct {
+	PortfolioValue
+	Flow float64
+}
+
+// valuationSeries values the user's balances at each snapshot time in times (ascending, within [from,to]).
+func (s *analyticsService) valuationSeries(ctx context.Context, userID uint, portfolioIDs []uint, in string, from, to time.Time, times []time.Time) ([]valuationPoint, error) {
+	startBalances, err := s.ledgerRepo.BalancesBefore(ctx, userID, portfolioIDs, from)
 	if err != nil {
 		return nil, err
 	}
-	if len(times) == 0 {
-		return nil, fmt.Errorf("no price snapshots in requested time range")
+	entries, err := s.ledgerRepo.ListByUserBetween(ctx, userID, portfolioIDs, from, to)
+	if err != nil {
+		return nil, err
 	}
 
 	// Determine which currencies matter (start balances + entries currencies + output currency).
@@ -300,12 +422,16 @@ func (s *analyticsService) PortfolioValueHistory(ctx context.Context, userID uin
 	}
 
 	entryIdx := 0
-	out := make([]PortfolioValue, 0, len(times))
+	pendingFlows := make(map[string]float64)
+	out := make([]valuationPoint, 0, len(times))
 	for _, t := range times {
 		for entryIdx < len(entries) && !entries[entryIdx].ExecutedAt.After(t) {
 			e := entries[entryIdx]
 			cc := strings.ToUpper(strings.TrimSpace(e.Currency))
 			balances[cc] += e.Amount
+			if e.EntryType == models.LedgerEntryAdjustment {
+				pendingFlows[cc] += e.Amount
+			}
 			entryIdx++
 		}
 
@@ -329,16 +455,50 @@ func (s *analyticsService) PortfolioValueHistory(ctx context.Context, userID uin
 		if err != nil {
 			return nil, err
 		}
-		out = append(out, PortfolioValue{
-			Time:  t,
-			In:    in,
-			Value: valueIn,
+		flowIn, err := valueBalancesIn(pendingFlows, rateByTicker, baseCurrency, in)
+		if err != nil {
+			return nil, err
+		}
+		pendingFlows = make(map[string]float64)
+		out = append(out, valuationPoint{
+			PortfolioValue: PortfolioValue{
+				Time:  t,
+				In:    in,
+				Value: valueIn,
+			},
+			Flow: flowIn,
 		})
 	}
 
 	return out, nil
 }
 
+// ratesAt returns the latest Base->Ticker rates at or before at, keyed by ticker, plus the base currency.
+func (s *analyticsService) ratesAt(ctx context.Context, tickers []string, at time.Time) (map[string]float64, string, error) {
+	rates, err := s.currencyRepo.LatestRatesAtOrBefore(ctx, tickers, at)
+	if err != nil {
+		return nil, "", err
+	}
+	rateByTicker, baseCurrency := indexRates(rates)
+	return rateByTicker, baseCurrency, nil
+}
+
+// indexRates maps stored rates by ticker and picks out their base, USD when none is recorded.
+func indexRates(rates []models.Currency) (map[string]float64, string) {
+	rateByTicker := make(map[string]float64, len(rates))
+	baseCurrency := ""
+	for _, r := range rates {
+		rateByTicker[strings.ToUpper(r.Ticker)] = r.Rate
+		if baseCurrency == "" && strings.TrimSpace(r.Base) != "" {
+			baseCurrency = strings.ToUpper(strings.TrimSpace(r.Base))
+		}
+	}
+	if baseCurrency == "" {
+		baseCurrency = "USD"
+	}
+	return rateByTicker, baseCurrency
+}
+
 func valueBalancesIn(balances map[string]float64, rateByTicker map[string]float64, baseCurrency, inCurrency string) (float64, error) {
 	baseCurrency = strings.ToUpper(strings.TrimSpace(baseCurrency))
 	inCurrency = strings.ToUpper(strings.TrimSpace(inCurrency))
@@ -418,9 +578,9 @@ func pearsonCorrelation(a, b []float64) (float64, error) {
 	meanB /= float64(n)
 
 	var (
-		num   float64
-		denA  float64
-		denB  float64
+		num  float64
+		denA float64
+		denB float64
 	)
 	for i := 0; i < n; i++ {
 		da := a[i] - meanA
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCandles serves each ticker's candles, keeping the most recent limit buckets in ascending
+// order as the repository does, and records which query a pair was routed to.
+type fakeCandles struct {
+	repository.CurrencyRepository
+	candles map[string][]repository.CandleRow
+	paired  []repository.CandleRow
+	calls   []string
+	limits  []int
+}
+
+func (f *fakeCandles) ListCandles(ctx context.Context, ticker string, bucketInterval string, from, to *time.Time, limit int) ([]repository.CandleRow, error) {
+	f.calls = append(f.calls, "candles "+ticker)
+	f.limits = append(f.limits, limit)
+	rows := f.candles[ticker]
+	if len(rows) > limit {
+		rows = rows[len(rows)-limit:]
+	}
+	return rows, nil
+}
+
+func (f *fakeCandles) ListPairedCandles(ctx context.Context, currencyA, currencyB string, bucketInterval string, from, to *time.Time, limit int) ([]repository.CandleRow, error) {
+	f.calls = append(f.calls, "paired "+currencyA+"/"+currencyB)
+	f.limits = append(f.limits, limit)
+	return f.paired, nil
+}
+
+func hourlyCandles(n int, candle func(i int) repository.CandleRow) []repository.CandleRow {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]repository.CandleRow, n)
+	for i := range rows {
+		rows[i] = candle(i)
+		rows[i].Bucket = start.Add(time.Duration(i) * time.Hour)
+	}
+	return rows
+}
+
+func TestCrossCandlesReadsUSDPairsFromTheOtherLeg(t *testing.T) {
+	eur := hourlyCandles(5, func(i int) repository.CandleRow {
+		base := float64(i + 1)
+		return repository.CandleRow{Open: base, High: 2 * base, Low: base / 2, Close: base, Samples: 3}
+	})
+	repo := &fakeCandles{candles: map[string][]repository.CandleRow{"EUR": eur}}
+	svc := &analyticsService{currencyRepo: repo}
+	ctx := context.Background()
+
+	// USD/EUR is EUR's own series: EUR per one USD.
+	got, err := svc.CrossCandles(ctx, "usd", "eur", "1h", nil, nil, 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, eur[2:], got)
+
+	// EUR/USD inverts it, so high and low trade places. Only the most recent three buckets
+	// are kept, still oldest first.
+	got, err = svc.CrossCandles(ctx, "EUR", "USD", "1h", nil, nil, 3)
+	if !assert.NoError(t, err) || !assert.Len(t, got, 3) {
+		return
+	}
+	for i, c := range got {
+		src := eur[2+i]
+		assert.Equal(t, src.Bucket, c.Bucket)
+		assert.InDelta(t, 1/src.Open, c.Open, 1e-12)
+		assert.InDelta(t, 1/src.Low, c.High, 1e-12)
+		assert.InDelta(t, 1/src.High, c.Low, 1e-12)
+		assert.InDelta(t, 1/src.Close, c.Close, 1e-12)
+		assert.Equal(t, src.Samples, c.Samples)
+	}
+	assert.True(t, got[0].Bucket.Before(got[1].Bucket) && got[1].Bucket.Before(got[2].Bucket))
+
+	assert.Equal(t, []string{"candles EUR", "candles EUR"}, repo.calls)
+	assert.Equal(t, []int{3, 3}, repo.limits)
+}
+
+func TestCrossCandlesDropsBucketsWithoutAPositiveRate(t *testing.T) {
+	jpy := hourlyCandles(3, func(i int) repository.CandleRow {
+		return repository.CandleRow{Open: 150, High: 151, Low: 149, Close: 150, Samples: 2}
+	})
+	// A zero sample in the middle bucket would invert to +Inf.
+	jpy[1].Low = 0
+	svc := &analyticsService{currencyRepo: &fakeCandles{candles: map[string][]repository.CandleRow{"JPY": jpy}}}
+
+	got, err := svc.CrossCandles(context.Background(), "JPY", "USD", "1h", nil, nil, 10)
+	if !assert.NoError(t, err) || !assert.Len(t, got, 2) {
+		return
+	}
+	assert.Equal(t, jpy[0].Bucket, got[0].Bucket)
+	assert.Equal(t, jpy[2].Bucket, got[1].Bucket)
+	assert.InDelta(t, 1.0/149, got[1].High, 1e-12)
+}
+
+func TestCrossCandlesPairsOtherCurrenciesBySnapshot(t *testing.T) {
+	paired := hourlyCandles(2, func(i int) repository.CandleRow {
+		return repository.CandleRow{Open: 0.85, High: 0.86, Low: 0.84, Close: 0.85, Samples: 4}
+	})
+	repo := &fakeCandles{paired: paired}
+	svc := &analyticsService{currencyRepo: repo}
+
+	got, err := svc.CrossCandles(context.Background(), "eur", "gbp", "1h", nil, nil, 50)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, paired, got)
+	assert.Equal(t, []string{"paired EUR/GBP"}, repo.calls)
+	assert.Equal(t, []int{50}, repo.limits)
+
+	_, err = svc.CrossCandles(context.Background(), "EUR", "eur", "1h", nil, nil, 50)
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const (
+	defaultAPIKeyLifetimeDays = 90
+	maxAPIKeyLifetimeDays     = 365
+	maxActiveAPIKeys          = 20
+)
+
+type APIKeyService interface {
+	Create(ctx context.Context, req *CreateAPIKeyRequest) (*CreatedAPIKey, error)
+	List(ctx context.Context, userID uint) ([]models.APIKey, error)
+	Revoke(ctx context.Context, userID, id uint) error
+	Authenticate(ctx context.Context, key string) (*auth.Claims, error)
+}
+
+type apiKeyService struct {
+	repo  repository.APIKeyRepository
+	users repository.UserRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, users repository.UserRepository) APIKeyService {
+	return &apiKeyService{repo: repo, users: users}
+}
+
+type CreateAPIKeyRequest struct {
+	UserID        uint     `json:"-"`
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // default 90, max 365
+}
+
+// CreatedAPIKey is returned once at creation; Key is never retrievable again.
+type CreatedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+func (s *apiKeyService) Create(ctx context.Context, req *CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(name) > 100 {
+		return nil, fmt.Errorf("name must be at most 100 characters")
+	}
+	scopes, err := auth.NormalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = defaultAPIKeyLifetimeDays
+	}
+	if days < 0 || days > maxAPIKeyLifetimeDays {
+		return nil, fmt.Errorf("expires_in_days must be between 1 and %d", maxAPIKeyLifetimeDays)
+	}
+
+	now := time.Now().UTC()
+	existing, err := s.repo.ListByUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	active := 0
+	for i := range existing {
+		if existing[i].Active(now) {
+			active++
+		}
+	}
+	if active >= maxActiveAPIKeys {
+		return nil, fmt.Errorf("at most %d active api keys are allowed; revoke one first", maxActiveAPIKeys)
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	row := models.APIKey{
+		UserID:    req.UserID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		ExpiresAt: now.AddDate(0, 0, days),
+		CreatedAt: now,
+	}
+	if err := s.repo.Create(ctx, &row); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKey{APIKey: row, Key: key}, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, userID, id uint) error {
+	return s.repo.Revoke(ctx, userID, id, time.Now().UTC())
+}
+
+// Authenticate resolves an API key to claims for its owner, limited to the key's scopes. The
+// claims carry no role, so keys can never reach admin routes.
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*auth.Claims, error) {
+	row, err := s.repo.FindByHash(ctx, auth.HashRefreshToken(key))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if !row.Active(now) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	user, err := s.users.FindByID(ctx, row.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Usage tracking is best effort; it must not fail the request.
+	if err := s.repo.Touch(ctx, row.ID, now); err != nil {
+		log.Printf("Failed to record api key use: %v", err)
+	}
+
+	return &auth.Claims{
+		UserID:   user.ID,
+		Email:    user.Email,
+		APIKeyID: row.ID,
+		Scopes:   row.Scopes,
+	}, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAPIKeyRepo struct {
+	repository.APIKeyRepository
+	keys []*models.APIKey
+}
+
+func (f *fakeAPIKeyRepo) Create(ctx context.Context, key *models.APIKey) error {
+	key.ID = uint(len(f.keys) + 1)
+	copied := *key
+	f.keys = append(f.keys, &copied)
+	return nil
+}
+
+func (f *fakeAPIKeyRepo) ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var out []models.APIKey
+	for _, k := range f.keys {
+		if k.UserID == userID {
+			out = append(out, *k)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAPIKeyRepo) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	for _, k := range f.keys {
+		if k.KeyHash == hash {
+			copied := *k
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrAPIKeyNotFound
+}
+
+func (f *fakeAPIKeyRepo) Revoke(ctx context.Context, userID, id uint, at time.Time) error {
+	for _, k := range f.keys {
+		if k.ID == id && k.UserID == userID {
+			k.RevokedAt = &at
+			return nil
+		}
+	}
+	return repository.ErrAPIKeyNotFound
+}
+
+func (f *fakeAPIKeyRepo) Touch(ctx context.Context, id uint, at time.Time) error {
+	f.keys[id-1].LastUsedAt = &at
+	return nil
+}
+
+func TestAPIKeyAuthenticatesWithScopesUntilRevokedOrExpired(t *testing.T) {
+	keys := &fakeAPIKeyRepo{}
+	users := &fakeUserRepo{users: map[uint]*models.User{7: {Email: "bot@example.com", Role: auth.RoleAdmin}}}
+	users.users[7].ID = 7
+	svc := NewAPIKeyService(keys, users)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, &CreateAPIKeyRequest{UserID: 7, Name: "bot", Scopes: []string{"ledger:delete"}})
+	assert.Error(t, err)
+
+	created, err := svc.Create(ctx, &CreateAPIKeyRequest{UserID: 7, Name: "bot", Scopes: []string{"ledger:read", "market:read", "ledger:read"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, auth.IsAPIKey(created.Key))
+	assert.NotEqual(t, created.Key, keys.keys[0].KeyHash)
+	assert.Equal(t, []string{auth.ScopeLedgerRead, auth.ScopeMarketRead}, created.Scopes)
+
+	claims, err := svc.Authenticate(ctx, created.Key)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint(7), claims.UserID)
+	assert.True(t, claims.HasScope(auth.ScopeLedgerRead))
+	assert.False(t, claims.HasScope(auth.ScopeLedgerWrite))
+	assert.False(t, claims.HasRole(auth.RoleAdmin), "api keys never carry the owner's role")
+	assert.NotNil(t, keys.keys[0].LastUsedAt)
+
+	_, err = svc.Authenticate(ctx, created.Key+"x")
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	keys.keys[0].ExpiresAt = time.Now().Add(-time.Minute)
+	_, err = svc.Authenticate(ctx, created.Key)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	keys.keys[0].ExpiresAt = time.Now().Add(time.Hour)
+	assert.NoError(t, svc.Revoke(ctx, 7, created.ID))
+	_, err = svc.Authenticate(ctx, created.Key)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+}
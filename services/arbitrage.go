@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const defaultArbitrageThresholdBps = 5
+
+// ArbitrageConfig controls the triangle scanner run after each ingested snapshot.
+//
+// A snapshot quotes every currency against a single base, so every triangle built from it
+// alone closes exactly by construction. Real cycles need independent legs: CrossURLs are
+// extra provider URLs (same response format as EXCONVERT_URL, different base) fetched on
+// each run to supply them. Without them the scanner only checks the snapshot's own quotes.
+type ArbitrageConfig struct {
+	ThresholdBps float64  // cycles deviating less than this from parity are ignored
+	AlertBps     float64  // detections at or above this are logged as alerts; 0 disables
+	CrossURLs    []string // extra snapshots providing non-base legs
+}
+
+// ArbitrageConfigFromEnv reads ARBITRAGE_THRESHOLD_BPS, ARBITRAGE_ALERT_BPS and
+// ARBITRAGE_CROSS_URLS (comma separated), falling back to defaults.
+func ArbitrageConfigFromEnv() ArbitrageConfig {
+	cfg := ArbitrageConfig{ThresholdBps: defaultArbitrageThresholdBps}
+	if raw := strings.TrimSpace(os.Getenv("ARBITRAGE_THRESHOLD_BPS")); raw != "" {
+		bps, err := strconv.ParseFloat(raw, 64)
+		if err != nil || bps < 0 {
+			log.Printf("Ignoring invalid ARBITRAGE_THRESHOLD_BPS %q", raw)
+		} else {
+			cfg.ThresholdBps = bps
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("ARBITRAGE_ALERT_BPS")); raw != "" {
+		bps, err := strconv.ParseFloat(raw, 64)
+		if err != nil || bps < 0 {
+			log.Printf("Ignoring invalid ARBITRAGE_ALERT_BPS %q", raw)
+		} else {
+			cfg.AlertBps = bps
+		}
+	}
+	for _, url := range strings.Split(os.Getenv("ARBITRAGE_CROSS_URLS"), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			cfg.CrossURLs = append(cfg.CrossURLs, url)
+		}
+	}
+	return cfg
+}
+
+type ArbitrageFilter struct {
+	Kind   string
+	MinBps float64
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+}
+
+type ArbitrageService interface {
+	SnapshotListener
+	ListDetections(ctx context.Context, filter ArbitrageFilter) ([]models.ArbitrageDetection, error)
+}
+
+type arbitrageService struct {
+	client *http.Client
+	repo   repository.ArbitrageRepository
+	cfg    ArbitrageConfig
+}
+
+func NewArbitrageService(repo repository.ArbitrageRepository, cfg ArbitrageConfig) ArbitrageService {
+	return &arbitrageService{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// OnSnapshot scans the snapshot (plus any configured cross snapshots) and stores what it finds.
+func (s *arbitrageService) OnSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	snapshots := []*models.Snapshot{snapshot}
+	for _, url := range s.cfg.CrossURLs {
+		cross, err := fetchSnapshot(ctx, s.client, url)
+		if err != nil {
+			log.Printf("Arbitrage scan skipping cross snapshot: %v", err)
+			continue
+		}
+		snapshots = append(snapshots, cross)
+	}
+
+	detections := scanSnapshots(snapshots, s.cfg.ThresholdBps)
+	if len(detections) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range detections {
+		detections[i].DetectedAt = now
+		detections[i].SnapshotTime = snapshot.Timestamp
+		if s.cfg.AlertBps > 0 && math.Abs(detections[i].DeviationBps) >= s.cfg.AlertBps {
+			log.Printf("ARBITRAGE ALERT %s %s deviates %.2f bps (%s)",
+				detections[i].Kind, detections[i].Path, detections[i].DeviationBps, detections[i].Detail)
+		}
+	}
+	if err := s.repo.Store(ctx, detections); err != nil {
+		return fmt.Errorf("arbitrage scan: %w", err)
+	}
+	return nil
+}
+
+func (s *arbitrageService) ListDetections(ctx context.Context, filter ArbitrageFilter) ([]models.ArbitrageDetection, error) {
+	kind := strings.ToLower(strings.TrimSpace(filter.Kind))
+	switch kind {
+	case "", models.ArbitrageKindTriangle, models.ArbitrageKindInvalidQuote:
+	default:
+		return nil, fmt.Errorf("unsupported kind; use one of: %s, %s", models.ArbitrageKindTriangle, models.ArbitrageKindInvalidQuote)
+	}
+	if filter.MinBps < 0 {
+		return nil, fmt.Errorf("min_bps must not be negative")
+	}
+	return s.repo.List(ctx, kind, filter.MinBps, filter.From, filter.To, filter.Limit)
+}
+
+// quoteLeg is a directly quoted rate: one unit of from buys rate units of to.
+type quoteLeg struct {
+	rate   float64
+	source string // base of the snapshot that quoted it
+}
+
+// quoteGraph holds every direct quote across snapshots; missing directions are inverted.
+type quoteGraph struct {
+	direct map[string]map[string]quoteLeg
+	adj    map[string]map[string]bool
+}
+
+func newQuoteGraph() *quoteGraph {
+	return &quoteGraph{
+		direct: make(map[string]map[string]quoteLeg),
+		adj:    make(map[string]map[string]bool),
+	}
+}
+
+func (g *quoteGraph) add(from, to string, leg quoteLeg) {
+	if _, ok := g.direct[from]; !ok {
+		g.direct[from] = make(map[string]quoteLeg)
+	}
+	if _, exists := g.direct[from][to]; exists {
+		return
+	}
+	g.direct[from][to] = leg
+	for _, pair := range [][2]string{{from, to}, {to, from}} {
+		if _, ok := g.adj[pair[0]]; !ok {
+			g.adj[pair[0]] = make(map[string]bool)
+		}
+		g.adj[pair[0]][pair[1]] = true
+	}
+}
+
+// leg returns the rate from->to and a short description of where it came from.
+func (g *quoteGraph) leg(from, to string) (float64, string) {
+	if leg, ok := g.direct[from][to]; ok {
+		return leg.rate, fmt.Sprintf("%s>%s=%g [%s]", from, to, leg.rate, leg.source)
+	}
+	leg := g.direct[to][from]
+	return 1 / leg.rate, fmt.Sprintf("%s>%s=%g [%s inv]", from, to, 1/leg.rate, leg.source)
+}
+
+func (g *quoteGraph) cycle(path ...string) (float64, string) {
+	product := 1.0
+	details := make([]string, 0, len(path))
+	for i := range path {
+		rate, detail := g.leg(path[i], path[(i+1)%len(path)])
+		product *= rate
+		details = append(details, detail)
+	}
+	return product, strings.Join(details, " ")
+}
+
+// scanSnapshots flags unusable quotes and every currency triangle whose best trading
+// direction deviates from parity by more than thresholdBps.
+func scanSnapshots(snapshots []*models.Snapshot, thresholdBps float64) []models.ArbitrageDetection {
+	var out []models.ArbitrageDetection
+	graph := newQuoteGraph()
+
+	for _, snap := range snapshots {
+		if snap == nil {
+			continue
+		}
+		base := strings.ToUpper(strings.TrimSpace(snap.Base))
+		if base == "" {
+			base = "USD"
+		}
+		for ticker, rate := range snap.Result {
+			ticker = strings.ToUpper(strings.TrimSpace(ticker))
+			path := base + ">" + ticker
+			if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+				out = append(out, models.ArbitrageDetection{
+					Kind:   models.ArbitrageKindInvalidQuote,
+					Path:   path,
+					Detail: fmt.Sprintf("non-positive or non-finite rate %g [%s]", rate, base),
+				})
+				continue
+			}
+			if ticker == base {
+				if bps := (rate - 1) * 10000; math.Abs(bps) > thresholdBps {
+					out = append(out, models.ArbitrageDetection{
+						Kind:         models.ArbitrageKindInvalidQuote,
+						Path:         path,
+						Product:      rate,
+						DeviationBps: bps,
+						Detail:       fmt.Sprintf("base quoted against itself at %g [%s]", rate, base),
+					})
+				}
+				continue
+			}
+			graph.add(base, ticker, quoteLeg{rate: rate, source: base})
+		}
+	}
+
+	nodes := make([]string, 0, len(graph.adj))
+	for n := range graph.adj {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+
+	// Visit each triangle once with a < b < c, then keep the more profitable direction.
+	for _, a := range nodes {
+		for _, b := range nodes {
+			if b <= a || !graph.adj[a][b] {
+				continue
+			}
+			for c := range graph.adj[b] {
+				if c <= b || !graph.adj[a][c] {
+					continue
+				}
+				forward, forwardDetail := graph.cycle(a, b, c)
+				backward, backwardDetail := graph.cycle(a, c, b)
+				product, detail, path := forward, forwardDetail, []string{a, b, c, a}
+				if backward > forward {
+					product, detail, path = backward, backwardDetail, []string{a, c, b, a}
+				}
+				bps := (product - 1) * 10000
+				if bps <= thresholdBps {
+					continue
+				}
+				out = append(out, models.ArbitrageDetection{
+					Kind:         models.ArbitrageKindTriangle,
+					Path:         strings.Join(path, ">"),
+					Product:      product,
+					DeviationBps: bps,
+					Detail:       detail,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return math.Abs(out[i].DeviationBps) > math.Abs(out[j].DeviationBps)
+	})
+	return out
+}
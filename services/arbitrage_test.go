@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanSnapshotsSingleBaseOnlyChecksQuotes(t *testing.T) {
+	snap := &models.Snapshot{Base: "USD", Result: map[string]float64{
+		"USD": 1,
+		"EUR": 0.9,
+		"JPY": 150,
+		"GBP": 0,
+	}}
+	found := scanSnapshots([]*models.Snapshot{snap}, 5)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, models.ArbitrageKindInvalidQuote, found[0].Kind)
+		assert.Equal(t, "USD>GBP", found[0].Path)
+	}
+}
+
+func TestScanSnapshotsFlagsInconsistentCrossLeg(t *testing.T) {
+	usd := &models.Snapshot{Base: "USD", Result: map[string]float64{"EUR": 0.9, "JPY": 150}}
+	// Parity EUR>JPY is 150/0.9 = 166.67; quoting 168 leaves ~80 bps on the table.
+	eur := &models.Snapshot{Base: "EUR", Result: map[string]float64{"JPY": 168}}
+
+	found := scanSnapshots([]*models.Snapshot{usd, eur}, 5)
+	if assert.Len(t, found, 1) {
+		d := found[0]
+		assert.Equal(t, models.ArbitrageKindTriangle, d.Kind)
+		assert.Equal(t, "EUR>JPY>USD>EUR", d.Path)
+		assert.InDelta(t, 168.0*0.9/150-1, d.DeviationBps/10000, 1e-12)
+	}
+
+	eur.Result["JPY"] = 150 / 0.9
+	assert.Empty(t, scanSnapshots([]*models.Snapshot{usd, eur}, 5))
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+// AuditService records authentication events with the caller's IP and user agent.
+type AuditService interface {
+	Record(ctx context.Context, event string, userID uint, email, detail string)
+	List(ctx context.Context, userID uint, limit int) ([]models.AuthEvent, error)
+}
+
+type auditService struct {
+	repo repository.AuthEventRepository
+}
+
+func NewAuditService(repo repository.AuthEventRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// Record stores an event; userID 0 means the email matched no account. Failing to write the
+// trail is logged but never fails the request being audited.
+func (s *auditService) Record(ctx context.Context, event string, userID uint, email, detail string) {
+	client := auth.ClientFromContext(ctx)
+	row := &models.AuthEvent{
+		Email:     email,
+		Event:     event,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Detail:    detail,
+	}
+	if userID != 0 {
+		row.UserID = &userID
+	}
+	if err := s.repo.Create(context.WithoutCancel(ctx), row); err != nil {
+		log.Printf("Failed to record auth event %s: %v", event, err)
+	}
+}
+
+func (s *auditService) List(ctx context.Context, userID uint, limit int) ([]models.AuthEvent, error) {
+	return s.repo.ListByUser(ctx, userID, limit)
+}
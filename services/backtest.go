@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/backtest"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"gorm.io/datatypes"
+)
+
+// maxBacktestBars caps a run. loadBars asks for one bar more to tell a range that fits from one
+// that does not, which keeps the query within the repository's 5000-row limit.
+const maxBacktestBars = 4999
+
+type BacktestService interface {
+	Run(ctx context.Context, req *BacktestRequest) (*BacktestRun, error)
+	Get(ctx context.Context, userID uint, id string) (*BacktestRun, error)
+	List(ctx context.Context, userID uint, limit int) ([]models.BacktestRun, error)
+}
+
+type backtestService struct {
+	repo      repository.BacktestRepository
+	analytics AnalyticsService
+	pricing   MarketPricing
+}
+
+func NewBacktestService(repo repository.BacktestRepository, analytics AnalyticsService, pricing MarketPricing) BacktestService {
+	return &backtestService{
+		repo:      repo,
+		analytics: analytics,
+		pricing:   pricing,
+	}
+}
+
+// BacktestRequest replays A/B (units of B per A) between From and To. With an empty Bucket the
+// raw stored snapshots are used; otherwise resampled candles. SpreadBps defaults to the live
+// EXCHANGE_SPREAD_BPS so results match what market-mode exchanges would have paid.
+type BacktestRequest struct {
+	UserID         uint               `json:"user_id"`
+	CurrencyA      string             `json:"currency_a"`
+	CurrencyB      string             `json:"currency_b"`
+	Bucket         string             `json:"bucket,omitempty"`
+	From           time.Time          `json:"from"`
+	To             time.Time          `json:"to"`
+	Strategy       string             `json:"strategy"`
+	Params         map[string]float64 `json:"params,omitempty"`
+	InitialCapital float64            `json:"initial_capital"`
+	SpreadBps      *float64           `json:"spread_bps,omitempty"`
+	FeeAmount      float64            `json:"fee_amount,omitempty"`
+}
+
+// BacktestRun is a stored run with its result decoded.
+type BacktestRun struct {
+	models.BacktestRun
+	Result *backtest.Result `json:"result"`
+}
+
+func (s *backtestService) Run(ctx context.Context, req *BacktestRequest) (*BacktestRun, error) {
+	if req.UserID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if !req.To.After(req.From) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+	strategy, err := backtest.New(req.Strategy, req.Params)
+	if err != nil {
+		return nil, err
+	}
+	cfg := backtest.Config{
+		InitialCapital: req.InitialCapital,
+		SpreadBps:      s.pricing.SpreadBps,
+		FeeAmount:      req.FeeAmount,
+	}
+	if cfg.InitialCapital == 0 {
+		cfg.InitialCapital = 10000
+	}
+	if req.SpreadBps != nil {
+		cfg.SpreadBps = *req.SpreadBps
+	}
+
+	a := strings.ToUpper(strings.TrimSpace(req.CurrencyA))
+	b := strings.ToUpper(strings.TrimSpace(req.CurrencyB))
+	bucket := strings.ToLower(strings.TrimSpace(req.Bucket))
+	bars, err := s.loadBars(ctx, a, b, bucket, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := backtest.Run(bars, strategy, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newTradeID()
+	if err != nil {
+		return nil, fmt.Errorf("generate backtest id: %w", err)
+	}
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, fmt.Errorf("marshal params: %w", err)
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("marshal backtest result: %w", err)
+	}
+
+	run := models.BacktestRun{
+		ID:             id,
+		UserID:         req.UserID,
+		Strategy:       strategy.Name(),
+		Params:         datatypes.JSON(params),
+		CurrencyA:      a,
+		CurrencyB:      b,
+		Bucket:         bucket,
+		From:           req.From,
+		To:             req.To,
+		InitialCapital: cfg.InitialCapital,
+		SpreadBps:      cfg.SpreadBps,
+		FeeAmount:      cfg.FeeAmount,
+		Result:         datatypes.JSON(encoded),
+	}
+	if err := s.repo.Create(ctx, &run); err != nil {
+		return nil, err
+	}
+	return &BacktestRun{BacktestRun: run, Result: result}, nil
+}
+
+func (s *backtestService) Get(ctx context.Context, userID uint, id string) (*BacktestRun, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	run, err := s.repo.Get(ctx, userID, strings.TrimSpace(id))
+	if err != nil {
+		return nil, err
+	}
+	var result backtest.Result
+	if err := json.Unmarshal(run.Result, &result); err != nil {
+		return nil, fmt.Errorf("decode backtest result: %w", err)
+	}
+	return &BacktestRun{BacktestRun: *run, Result: &result}, nil
+}
+
+func (s *backtestService) List(ctx context.Context, userID uint, limit int) ([]models.BacktestRun, error) {
+	return s.repo.ListByUser(ctx, userID, limit)
+}
+
+// loadBars reads the stored series for the pair, either raw snapshots or bucketed candles. A
+// range holding more than maxBacktestBars is refused rather than silently cut short.
+func (s *backtestService) loadBars(ctx context.Context, a, b, bucket string, from, to time.Time) ([]backtest.Bar, error) {
+	var bars []backtest.Bar
+	if bucket == "" {
+		points, err := s.analytics.CrossRateHistory(ctx, a, b, &from, &to, maxBacktestBars+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			bars = append(bars, backtest.Bar{Time: p.Time, Open: p.Rate, High: p.Rate, Low: p.Rate, Close: p.Rate})
+		}
+	} else {
+		candles, err := s.analytics.CrossCandles(ctx, a, b, bucket, &from, &to, maxBacktestBars+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candles {
+			bars = append(bars, backtest.Bar{Time: c.Bucket, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close})
+		}
+	}
+	if len(bars) < 2 {
+		return nil, fmt.Errorf("not enough stored history for %s/%s in the requested range", a, b)
+	}
+	if len(bars) > maxBacktestBars {
+		return nil, fmt.Errorf("the requested range holds more than %d bars; narrow it or use a larger bucket", maxBacktestBars)
+	}
+	return bars, nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCorrelationBucket = "1h"
+	maxCorrelationTickers    = 25
+)
+
+type CorrelationMatrix struct {
+	Tickers []string     `json:"tickers"`
+	Base    string       `json:"base"`
+	Bucket  string       `json:"bucket"`
+	From    *time.Time   `json:"from,omitempty"`
+	To      *time.Time   `json:"to,omitempty"`
+	Samples int          `json:"samples"`
+	Matrix  [][]*float64 `json:"matrix"` // null where a series has zero variance
+}
+
+type RollingCorrelationPoint struct {
+	Time        time.Time `json:"time"`
+	Correlation *float64  `json:"correlation"`
+}
+
+type RollingCorrelation struct {
+	A      string                    `json:"a"`
+	B      string                    `json:"b"`
+	Base   string                    `json:"base"`
+	Bucket string                    `json:"bucket"`
+	Window int                       `json:"window"`
+	Points []RollingCorrelationPoint `json:"points"`
+}
+
+// alignedSeries holds log returns of several tickers on the same bucket grid.
+// times[i] is the bucket that closes returns[*][i].
+type alignedSeries struct {
+	base    string
+	bucket  string
+	times   []time.Time
+	returns [][]float64
+}
+
+// CorrelationMatrix computes the pairwise correlation of bucketed log returns for all tickers.
+func (s *analyticsService) CorrelationMatrix(ctx context.Context, tickers []string, bucket string, from, to *time.Time, limit int) (*CorrelationMatrix, error) {
+	tickers = uniqueStrings(tickers)
+	if len(tickers) < 2 {
+		return nil, fmt.Errorf("at least two tickers are required")
+	}
+	if len(tickers) > maxCorrelationTickers {
+		return nil, fmt.Errorf("at most %d tickers are supported", maxCorrelationTickers)
+	}
+
+	series, err := s.alignedReturns(ctx, tickers, bucket, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(tickers)
+	matrix := make([][]*float64, n)
+	for i := range matrix {
+		matrix[i] = make([]*float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			corr, err := pearsonCorrelation(series.returns[i], series.returns[j])
+			if err != nil {
+				continue
+			}
+			c := corr
+			matrix[i][j] = &c
+			matrix[j][i] = &c
+		}
+	}
+
+	return &CorrelationMatrix{
+		Tickers: tickers,
+		Base:    series.base,
+		Bucket:  series.bucket,
+		From:    from,
+		To:      to,
+		Samples: len(series.times),
+		Matrix:  matrix,
+	}, nil
+}
+
+// RollingCorrelation slides a window of bucketed log returns over the range, one point per bucket.
+func (s *analyticsService) RollingCorrelation(ctx context.Context, currencyA, currencyB, bucket string, window int, from, to *time.Time, limit int) (*RollingCorrelation, error) {
+	a := strings.ToUpper(strings.TrimSpace(currencyA))
+	b := strings.ToUpper(strings.TrimSpace(currencyB))
+	if a == "" || b == "" {
+		return nil, fmt.Errorf("currency_a and currency_b are required")
+	}
+	if a == b {
+		return nil, fmt.Errorf("currencies must be different")
+	}
+	if window < 3 {
+		return nil, fmt.Errorf("window must be at least 3 buckets")
+	}
+
+	series, err := s.alignedReturns(ctx, []string{a, b}, bucket, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(series.times) < window {
+		return nil, fmt.Errorf("not enough samples for a %d-bucket window", window)
+	}
+
+	ra, rb := series.returns[0], series.returns[1]
+	points := make([]RollingCorrelationPoint, 0, len(series.times)-window+1)
+	for end := window; end <= len(series.times); end++ {
+		p := RollingCorrelationPoint{Time: series.times[end-1]}
+		if corr, err := pearsonCorrelation(ra[end-window:end], rb[end-window:end]); err == nil {
+			c := corr
+			p.Correlation = &c
+		}
+		points = append(points, p)
+	}
+
+	return &RollingCorrelation{
+		A:      a,
+		B:      b,
+		Base:   series.base,
+		Bucket: series.bucket,
+		Window: window,
+		Points: points,
+	}, nil
+}
+
+// alignedReturns resamples tickers to a common bucket, keeps only buckets where every ticker
+// has a close, and turns the closes into log returns in one pass.
+func (s *analyticsService) alignedReturns(ctx context.Context, tickers []string, bucket string, from, to *time.Time, limit int) (*alignedSeries, error) {
+	if strings.TrimSpace(bucket) == "" {
+		bucket = defaultCorrelationBucket
+	}
+	interval, err := normalizeCandleBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.currencyRepo.ListBucketedCloses(ctx, tickers, interval, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	base := ""
+	closes := make(map[time.Time]map[string]float64)
+	for _, r := range rows {
+		if base == "" && strings.TrimSpace(r.Base) != "" {
+			base = strings.ToUpper(strings.TrimSpace(r.Base))
+		}
+		if _, ok := closes[r.Bucket]; !ok {
+			closes[r.Bucket] = make(map[string]float64, len(tickers))
+		}
+		closes[r.Bucket][strings.ToUpper(r.Ticker)] = r.Close
+	}
+	if base == "" {
+		base = "USD"
+	}
+
+	buckets := make([]time.Time, 0, len(closes))
+	for b := range closes {
+		buckets = append(buckets, b)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Before(buckets[j]) })
+
+	// The base is not stored as a ticker row; it is 1.0 in every bucket.
+	var aligned []time.Time
+	var values [][]float64
+	for _, b := range buckets {
+		row := make([]float64, len(tickers))
+		complete := true
+		for i, t := range tickers {
+			if t == base {
+				row[i] = 1.0
+				continue
+			}
+			v, ok := closes[b][t]
+			if !ok || v <= 0 {
+				complete = false
+				break
+			}
+			row[i] = v
+		}
+		if complete {
+			aligned = append(aligned, b)
+			values = append(values, row)
+		}
+	}
+	if len(aligned) < 3 {
+		return nil, fmt.Errorf("not enough aligned samples to compute correlation")
+	}
+
+	out := &alignedSeries{
+		base:    base,
+		bucket:  strings.ToLower(strings.TrimSpace(bucket)),
+		times:   aligned[1:],
+		returns: make([][]float64, len(tickers)),
+	}
+	for i := range tickers {
+		out.returns[i] = make([]float64, 0, len(aligned)-1)
+		for k := 1; k < len(values); k++ {
+			out.returns[i] = append(out.returns[i], math.Log(values[k][i]/values[k-1][i]))
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBucketedCloses keeps each ticker's most recent limit buckets, as the repository does.
+type fakeBucketedCloses struct {
+	repository.CurrencyRepository
+	rows []repository.BucketCloseRow
+}
+
+func (f *fakeBucketedCloses) ListBucketedCloses(ctx context.Context, tickers []string, bucketInterval string, from, to *time.Time, limit int) ([]repository.BucketCloseRow, error) {
+	if limit <= 0 {
+		return f.rows, nil
+	}
+	rows := slices.Clone(f.rows)
+	slices.SortFunc(rows, func(a, b repository.BucketCloseRow) int { return b.Bucket.Compare(a.Bucket) })
+	kept := map[string]int{}
+	var out []repository.BucketCloseRow
+	for _, r := range rows {
+		if kept[r.Ticker] < limit {
+			kept[r.Ticker]++
+			out = append(out, r)
+		}
+	}
+	slices.Reverse(out)
+	return out, nil
+}
+
+var correlationStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// closeRows lays each ticker's closes on an hourly grid; zero closes are left out, as if the
+// bucket had no snapshot for that ticker.
+func closeRows(series map[string][]float64) []repository.BucketCloseRow {
+	var rows []repository.BucketCloseRow
+	for ticker, closes := range series {
+		for i, c := range closes {
+			if c == 0 {
+				continue
+			}
+			rows = append(rows, repository.BucketCloseRow{Bucket: hour(i), Ticker: ticker, Close: c, Base: "USD"})
+		}
+	}
+	return rows
+}
+
+func hour(i int) time.Time {
+	return correlationStart.Add(time.Duration(i) * time.Hour)
+}
+
+func TestAlignedReturns(t *testing.T) {
+	tests := []struct {
+		name        string
+		tickers     []string
+		series      map[string][]float64
+		wantTimes   []time.Time
+		wantReturns [][]float64
+		wantErr     bool
+	}{
+		{
+			name:        "complete grid",
+			tickers:     []string{"EUR", "GBP"},
+			series:      map[string][]float64{"EUR": {1, 2, 4}, "GBP": {1, 1, 2}},
+			wantTimes:   []time.Time{hour(1), hour(2)},
+			wantReturns: [][]float64{{math.Ln2, math.Ln2}, {0, math.Ln2}},
+		},
+		{
+			name:        "bucket missing one ticker is dropped and the return spans the gap",
+			tickers:     []string{"EUR", "GBP"},
+			series:      map[string][]float64{"EUR": {1, 2, 0, 8}, "GBP": {1, 1, 3, 2}},
+			wantTimes:   []time.Time{hour(1), hour(3)},
+			wantReturns: [][]float64{{math.Ln2, 2 * math.Ln2}, {0, math.Ln2}},
+		},
+		{
+			name:        "non-positive close is dropped",
+			tickers:     []string{"EUR", "GBP"},
+			series:      map[string][]float64{"EUR": {1, -1, 2, 4}, "GBP": {1, 1, 1, 1}},
+			wantTimes:   []time.Time{hour(2), hour(3)},
+			wantReturns: [][]float64{{math.Ln2, math.Ln2}, {0, 0}},
+		},
+		{
+			name:        "base is a constant series",
+			tickers:     []string{"USD", "EUR"},
+			series:      map[string][]float64{"EUR": {1, 2, 4}},
+			wantTimes:   []time.Time{hour(1), hour(2)},
+			wantReturns: [][]float64{{0, 0}, {math.Ln2, math.Ln2}},
+		},
+		{
+			name:    "fewer than three aligned buckets",
+			tickers: []string{"EUR", "GBP"},
+			series:  map[string][]float64{"EUR": {1, 2, 0}, "GBP": {1, 1, 1}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &analyticsService{currencyRepo: &fakeBucketedCloses{rows: closeRows(tt.series)}}
+			series, err := svc.alignedReturns(context.Background(), tt.tickers, "", nil, nil, 0)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "USD", series.base)
+			assert.Equal(t, defaultCorrelationBucket, series.bucket)
+			assert.Equal(t, tt.wantTimes, series.times)
+			if assert.Len(t, series.returns, len(tt.wantReturns)) {
+				for i := range tt.wantReturns {
+					assert.InDeltaSlice(t, tt.wantReturns[i], series.returns[i], 1e-12, tt.tickers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCorrelationMatrix(t *testing.T) {
+	eur := []float64{1, 1.1, 1.05, 1.2, 1.15, 1.3}
+	gbp := make([]float64, len(eur))
+	jpy := make([]float64, len(eur))
+	for i, v := range eur {
+		gbp[i] = 2 * v
+		jpy[i] = 1 / v
+	}
+	svc := &analyticsService{currencyRepo: &fakeBucketedCloses{rows: closeRows(map[string][]float64{"EUR": eur, "GBP": gbp, "JPY": jpy})}}
+
+	res, err := svc.CorrelationMatrix(context.Background(), []string{"EUR", "GBP", "JPY", "USD", "EUR"}, "", nil, nil, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"EUR", "GBP", "JPY", "USD"}, res.Tickers)
+	assert.Equal(t, len(eur)-1, res.Samples)
+	assert.InDelta(t, 1, *res.Matrix[0][0], 1e-9)
+	assert.InDelta(t, 1, *res.Matrix[0][1], 1e-9)
+	assert.InDelta(t, -1, *res.Matrix[0][2], 1e-9)
+	assert.Equal(t, res.Matrix[0][2], res.Matrix[2][0])
+	// The base never moves, so it has no correlation with anything, itself included.
+	for i := range res.Tickers {
+		assert.Nil(t, res.Matrix[3][i])
+		assert.Nil(t, res.Matrix[i][3])
+	}
+
+	_, err = svc.CorrelationMatrix(context.Background(), []string{"EUR", "eur"}, "", nil, nil, 0)
+	assert.Error(t, err)
+}
+
+func TestRollingCorrelationWindowEdges(t *testing.T) {
+	// Six closes give five returns. CHF is flat for its first three returns.
+	series := map[string][]float64{
+		"EUR": {1, 1.1, 1.05, 1.2, 1.15, 1.3},
+		"CHF": {1, 1, 1, 1, 1.1, 1.05},
+	}
+	svc := &analyticsService{currencyRepo: &fakeBucketedCloses{rows: closeRows(series)}}
+
+	tests := []struct {
+		name      string
+		window    int
+		wantTimes []time.Time
+		wantNil   []bool
+		wantErr   bool
+	}{
+		{name: "window below three", window: 2, wantErr: true},
+		{name: "window longer than the series", window: 6, wantErr: true},
+		{name: "window equal to the series", window: 5, wantTimes: []time.Time{hour(5)}, wantNil: []bool{false}},
+		{
+			name:      "smallest window",
+			window:    3,
+			wantTimes: []time.Time{hour(3), hour(4), hour(5)},
+			wantNil:   []bool{true, false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := svc.RollingCorrelation(context.Background(), "eur", "chf", "", tt.window, nil, nil, 0)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "EUR", res.A)
+			assert.Equal(t, tt.window, res.Window)
+			if !assert.Len(t, res.Points, len(tt.wantTimes)) {
+				return
+			}
+			for i, p := range res.Points {
+				assert.Equal(t, tt.wantTimes[i], p.Time)
+				assert.Equal(t, tt.wantNil[i], p.Correlation == nil, "point %d", i)
+			}
+		})
+	}
+}
+
+func TestRollingCorrelationUsesTheMostRecentBucketsWhenCapped(t *testing.T) {
+	// GBP has no close in bucket 7, so the cap reaches one bucket further back for it than for EUR.
+	series := map[string][]float64{
+		"EUR": {1, 1.1, 1.05, 1.2, 1.15, 1.3, 1.25, 1.4, 1.35},
+		"GBP": {2, 2.1, 2.3, 2.2, 2.4, 2.3, 2.5, 0, 2.6},
+	}
+	svc := &analyticsService{currencyRepo: &fakeBucketedCloses{rows: closeRows(series)}}
+
+	res, err := svc.RollingCorrelation(context.Background(), "EUR", "GBP", "", 3, nil, nil, 5)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// Buckets 4-8 survive for EUR and 3-8 for GBP; 4, 5, 6 and 8 are aligned, giving returns
+	// at 5, 6 and 8 and one window ending at bucket 8.
+	if assert.Len(t, res.Points, 1) {
+		assert.Equal(t, hour(8), res.Points[0].Time)
+	}
+}
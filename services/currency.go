@@ -14,6 +14,7 @@ type CurrencyService interface {
 	FetchLatestRates(ctx context.Context) (*models.Snapshot, error)
 	FetchHistory(ctx context.Context, ticker string, from, to *time.Time, limit int) ([]models.Currency, error)
 	FetchCandles(ctx context.Context, ticker string, bucket string, from, to *time.Time, limit int) ([]repository.CandleRow, error)
+	Movers(ctx context.Context, window, sortBy string, limit int) (*models.MarketMovers, error)
 }
 
 type currencyService struct {
@@ -75,7 +76,9 @@ func normalizeCandleBucket(bucket string) (string, error) {
 		return "4 hours", nil
 	case "1d":
 		return "1 day", nil
+	case "1w":
+		return "1 week", nil
 	default:
-		return "", fmt.Errorf("unsupported bucket; use one of: 1m, 5m, 15m, 30m, 1h, 4h, 1d")
+		return "", fmt.Errorf("unsupported bucket; use one of: 1m, 5m, 15m, 30m, 1h, 4h, 1d, 1w")
 	}
 }
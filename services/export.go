@@ -0,0 +1,614 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const (
+	defaultExportTTL = 24 * time.Hour
+	// exportClaimLease is how long a runner holds an export; every chunk written renews it.
+	exportClaimLease = 15 * time.Minute
+	// exportChunkSize is how much of an archive is held in memory before it is written out.
+	exportChunkSize = 1 << 20
+	// minExportLinkSecret is the shortest EXPORT_LINK_SECRET accepted, in bytes.
+	minExportLinkSecret = 32
+)
+
+var ErrInvalidExportLink = errors.New("invalid or expired download link")
+
+// ExportConfig controls how long a finished export can be downloaded and the key that signs
+// its download links.
+type ExportConfig struct {
+	TTL        time.Duration
+	LinkSecret []byte
+}
+
+// ExportConfigFromEnv reads EXPORT_TTL and EXPORT_LINK_SECRET. The secret is required, and
+// must be shared by every instance, so that links signed by one verify on all of them and
+// survive restarts.
+func ExportConfigFromEnv() (ExportConfig, error) {
+	cfg := ExportConfig{TTL: defaultExportTTL}
+	if raw := strings.TrimSpace(os.Getenv("EXPORT_TTL")); raw != "" {
+		ttl, err := time.ParseDuration(raw)
+		if err != nil || ttl <= 0 {
+			log.Printf("Ignoring invalid EXPORT_TTL %q", raw)
+		} else {
+			cfg.TTL = ttl
+		}
+	}
+	secret := os.Getenv("EXPORT_LINK_SECRET")
+	if len(secret) < minExportLinkSecret {
+		return cfg, fmt.Errorf("EXPORT_LINK_SECRET must be set to at least %d characters", minExportLinkSecret)
+	}
+	cfg.LinkSecret = []byte(secret)
+	return cfg, nil
+}
+
+// ExportService builds downloadable archives of everything held about a user. Requests are
+// queued and built by RunDue in the background; a finished archive is offered through a
+// signed link until it expires.
+type ExportService interface {
+	Request(ctx context.Context, userID uint) (*ExportStatus, error)
+	Status(ctx context.Context, userID, id uint) (*ExportStatus, error)
+	List(ctx context.Context, userID uint) ([]ExportStatus, error)
+	Download(ctx context.Context, id uint, expires, signature string) (*ExportArchive, error)
+	RunDue(ctx context.Context) error
+}
+
+type exportService struct {
+	repo     repository.DataExportRepository
+	personal repository.PersonalDataRepository
+	audit    AuditService
+	cfg      ExportConfig
+	now      func() time.Time
+}
+
+func NewExportService(repo repository.DataExportRepository, personal repository.PersonalDataRepository, audit AuditService, cfg ExportConfig) ExportService {
+	return &exportService{
+		repo:     repo,
+		personal: personal,
+		audit:    audit,
+		cfg:      cfg,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// ExportStatus is an export job; DownloadURL is set while the archive can be downloaded.
+type ExportStatus struct {
+	models.DataExport
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// ExportArchive is a download in waiting: Stream copies the zip to w a chunk at a time.
+type ExportArchive struct {
+	Filename string
+	Size     int64
+	Stream   func(w io.Writer) error
+}
+
+// Request queues an export. While one is still pending or running it is returned instead of
+// queueing another.
+func (s *exportService) Request(ctx context.Context, userID uint) (*ExportStatus, error) {
+	active, err := s.repo.FindActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return s.status(active), nil
+	}
+
+	export := &models.DataExport{
+		UserID:    userID,
+		Status:    models.DataExportPending,
+		CreatedAt: s.now(),
+	}
+	if err := s.repo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuthEventDataExport, userID, "", fmt.Sprintf("export %d", export.ID))
+	return s.status(export), nil
+}
+
+func (s *exportService) Status(ctx context.Context, userID, id uint) (*ExportStatus, error) {
+	export, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.status(export), nil
+}
+
+func (s *exportService) List(ctx context.Context, userID uint) ([]ExportStatus, error) {
+	exports, err := s.repo.ListByUser(ctx, userID, 20)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ExportStatus, 0, len(exports))
+	for i := range exports {
+		out = append(out, *s.status(&exports[i]))
+	}
+	return out, nil
+}
+
+// Download checks a signed link and returns the archive it points to. The link is the only
+// credential, so it can be opened directly in a browser.
+func (s *exportService) Download(ctx context.Context, id uint, expires, signature string) (*ExportArchive, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidExportLink
+	}
+	now := s.now()
+	if !now.Before(time.Unix(unix, 0)) || !hmac.Equal([]byte(signature), []byte(s.sign(id, unix))) {
+		return nil, ErrInvalidExportLink
+	}
+	export, err := s.repo.GetReady(ctx, id, now)
+	if err != nil {
+		if errors.Is(err, repository.ErrDataExportNotFound) {
+			return nil, ErrInvalidExportLink
+		}
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuthEventDataDownload, export.UserID, "", fmt.Sprintf("export %d", export.ID))
+	return &ExportArchive{
+		Filename: fmt.Sprintf("trading-insights-export-%d-%s.zip", export.ID, export.CreatedAt.UTC().Format("20060102")),
+		Size:     export.SizeBytes,
+		Stream: func(w io.Writer) error {
+			return s.repo.EachChunk(ctx, export.ID, func(chunk []byte) error {
+				_, err := w.Write(chunk)
+				return err
+			})
+		},
+	}, nil
+}
+
+// RunDue drops expired archives, then builds queued exports and any whose runner died.
+func (s *exportService) RunDue(ctx context.Context) error {
+	now := s.now()
+	expired, err := s.repo.ExpireDue(ctx, now)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		log.Printf("Expired %d data exports", expired)
+	}
+
+	exports, err := s.repo.ListDue(ctx, now, 10)
+	if err != nil {
+		return err
+	}
+	for i := range exports {
+		token, err := newClaimToken()
+		if err != nil {
+			return err
+		}
+		claimed, err := s.repo.Claim(ctx, exports[i].ID, token, now, now.Add(exportClaimLease))
+		if err != nil {
+			log.Printf("Data export %d not claimed: %v", exports[i].ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		err = s.build(ctx, &exports[i], token)
+		if errors.Is(err, repository.ErrDataExportClaimLost) {
+			log.Printf("Data export %d abandoned: another runner claimed it", exports[i].ID)
+			continue
+		}
+		if err != nil {
+			log.Printf("Data export %d failed: %v", exports[i].ID, err)
+			if err := s.repo.Fail(ctx, exports[i].ID, token, "the export could not be built; please request a new one", s.now()); err != nil {
+				log.Printf("Data export %d failure not recorded: %v", exports[i].ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// build writes the archive under the claim token, renewing the lease with every chunk.
+func (s *exportService) build(ctx context.Context, export *models.DataExport, token string) error {
+	started := s.now()
+	data, err := s.personal.Collect(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+	// A runner that died mid-build may have left chunks behind.
+	if err := s.repo.DeleteChunks(ctx, export.ID, token, s.now().Add(exportClaimLease)); err != nil {
+		return err
+	}
+	chunks := &exportChunkWriter{ctx: ctx, repo: s.repo, id: export.ID, token: token, now: s.now}
+	eachLedger := func(fn func([]models.UserLedgerEntry) error) error {
+		return s.personal.EachLedgerBatch(ctx, export.UserID, fn)
+	}
+	if err := writeExportArchive(chunks, data, eachLedger, started); err != nil {
+		return err
+	}
+	if err := chunks.Close(); err != nil {
+		return err
+	}
+	finished := s.now()
+	if err := s.repo.Complete(ctx, export.ID, token, chunks.size, finished, finished.Add(s.cfg.TTL)); err != nil {
+		return err
+	}
+	log.Printf("📦 Built data export %d for user %d (%d bytes in %s)", export.ID, export.UserID, chunks.size, finished.Sub(started).Round(time.Millisecond))
+	return nil
+}
+
+// exportChunkWriter stores an archive as it is written, exportChunkSize bytes per chunk.
+type exportChunkWriter struct {
+	ctx   context.Context
+	repo  repository.DataExportRepository
+	id    uint
+	token string
+	now   func() time.Time
+	buf   []byte
+	seq   int
+	size  int64
+}
+
+func (w *exportChunkWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= exportChunkSize {
+		if err := w.flush(w.buf[:exportChunkSize]); err != nil {
+			return 0, err
+		}
+		w.buf = append(w.buf[:0], w.buf[exportChunkSize:]...)
+	}
+	return len(p), nil
+}
+
+// Close writes the final, partial chunk.
+func (w *exportChunkWriter) Close() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.flush(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *exportChunkWriter) flush(chunk []byte) error {
+	if err := w.repo.AppendChunk(w.ctx, w.id, w.token, w.seq, append([]byte(nil), chunk...), w.now().Add(exportClaimLease)); err != nil {
+		return err
+	}
+	w.seq++
+	w.size += int64(len(chunk))
+	return nil
+}
+
+func (s *exportService) status(export *models.DataExport) *ExportStatus {
+	status := &ExportStatus{DataExport: *export}
+	if export.Status == models.DataExportReady && export.ExpiresAt != nil && s.now().Before(*export.ExpiresAt) {
+		unix := export.ExpiresAt.Unix()
+		status.DownloadURL = fmt.Sprintf("/me/export/%d/download?expires=%d&signature=%s", export.ID, unix, s.sign(export.ID, unix))
+	}
+	return status
+}
+
+func (s *exportService) sign(id uint, expires int64) string {
+	mac := hmac.New(sha256.New, s.cfg.LinkSecret)
+	fmt.Fprintf(mac, "data-export:%d:%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+const exportReadme = `Trading Insights personal data export
+Generated: %s
+
+Everything we hold about your account. Tabular data is provided as JSON and as CSV with
+the same name; times are UTC in RFC 3339.
+
+profile.json              your account: email, name, role, preferences, verification and 2FA state
+watchlist.json/.csv       tickers on your watchlist
+portfolios.json/.csv      your live and paper portfolios
+ledger_entries.json/.csv  every ledger entry (signed: + inflow, - outflow)
+orders.json/.csv          limit, stop and OCO orders, the rate triggers you have set
+plans.json/.csv           recurring conversion plans
+plan_executions.json/.csv every attempt to run a plan
+backtests.json            backtest runs with their parameters and results (JSON only)
+api_keys.json/.csv        API key metadata; the keys themselves are never stored
+auth_events.json/.csv     sign-ins and other security events, with IP address and user agent
+
+Credentials are left out: password and API key hashes, the two-factor secret and recovery
+codes.
+`
+
+// writeExportArchive writes the zip described in exportReadme. Ledger entries are read twice,
+// batch by batch, once for each format, so large ledgers are never held in memory at once.
+func writeExportArchive(w io.Writer, data *repository.PersonalData, eachLedger func(func([]models.UserLedgerEntry) error) error, generated time.Time) error {
+	zw := zip.NewWriter(w)
+
+	if err := writeZipFile(zw, "README.txt", func(f io.Writer) error {
+		_, err := fmt.Fprintf(f, exportReadme, generated.UTC().Format(time.RFC3339))
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "profile.json", data.User); err != nil {
+		return err
+	}
+
+	watchlist := make([]watchlistRecord, 0, len(data.WatchItems))
+	for _, item := range data.WatchItems {
+		watchlist = append(watchlist, watchlistRecord{Ticker: item.Ticker, AddedAt: item.CreatedAt})
+	}
+	if err := writeZipJSON(zw, "watchlist.json", watchlist); err != nil {
+		return err
+	}
+	if err := writeZipCSV(zw, "watchlist.csv", []string{"ticker", "added_at"}, len(watchlist), func(i int) []string {
+		return []string{watchlist[i].Ticker, exportTime(watchlist[i].AddedAt)}
+	}); err != nil {
+		return err
+	}
+
+	if err := writeZipJSON(zw, "portfolios.json", data.Portfolios); err != nil {
+		return err
+	}
+	if err := writeZipCSV(zw, "portfolios.csv", []string{"id", "name", "kind", "is_default", "created_at"}, len(data.Portfolios), func(i int) []string {
+		p := data.Portfolios[i]
+		return []string{exportUint(p.ID), p.Name, p.Kind, strconv.FormatBool(p.IsDefault), exportTime(p.CreatedAt)}
+	}); err != nil {
+		return err
+	}
+
+	if err := writeLedgerJSON(zw, eachLedger); err != nil {
+		return err
+	}
+	if err := writeLedgerCSV(zw, eachLedger); err != nil {
+		return err
+	}
+
+	if err := writeZipJSON(zw, "orders.json", data.Orders); err != nil {
+		return err
+	}
+	if err := writeZipCSV(zw, "orders.csv", []string{"id", "portfolio_id", "type", "status", "oco_group", "from_currency", "to_currency", "from_amount", "trigger_rate", "good_till", "trigger_mid", "to_amount", "trade_id", "reason", "closed_at", "created_at"}, len(data.Orders), func(i int) []string {
+		o := data.Orders[i]
+		return []string{o.ID, exportUint(o.PortfolioID), o.Type, o.Status, o.OCOGroup, o.FromCurrency, o.ToCurrency, exportFloat(o.FromAmount), exportFloat(o.TriggerRate), exportTimePtr(o.GoodTill), exportFloat(o.TriggerMid), exportFloat(o.ToAmount), o.TradeID, o.Reason, exportTimePtr(o.ClosedAt), exportTime(o.CreatedAt)}
+	}); err != nil {
+		return err
+	}
+
+	if err := writeZipJSON(zw, "plans.json", data.Plans); err != nil {
+		return err
+	}
+	if err := writeZipCSV(zw, "plans.csv", []string{"id", "portfolio_id", "name", "from_currency", "to_currency", "from_amount", "frequency", "weekday", "day_of_month", "hour", "minute", "status", "next_run_at", "last_run_at", "created_at"}, len(data.Plans), func(i int) []string {
+		p := data.Plans[i]
+		return []string{exportUint(p.ID), exportUint(p.PortfolioID), p.Name, p.FromCurrency, p.ToCurrency, exportFloat(p.FromAmount), p.Frequency, strconv.Itoa(p.Weekday), strconv.Itoa(p.DayOfMonth), strconv.Itoa(p.Hour), strconv.Itoa(p.Minute), p.Status, exportTime(p.NextRunAt), exportTimePtr(p.LastRunAt), exportTime(p.CreatedAt)}
+	}); err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "plan_executions.json", data.PlanExecutions); err != nil {
+		return err
+	}
+	if err := writeZipCSV(zw, "plan_executions.csv", []string{"id", "plan_id", "scheduled_for", "executed_at", "attempt", "status", "trade_id", "from_amount", "to_amount", "error"}, len(data.PlanExecutions), func(i int) []string {
+		e := data.PlanExecutions[i]
+		return []string{exportUint(e.ID), exportUint(e.PlanID), exportTime(e.ScheduledFor), exportTime(e.ExecutedAt), strconv.Itoa(e.Attempt), e.Status, e.TradeID, exportFloat(e.FromAmount), exportFloat(e.ToAmount), e.Error}
+	}); err != nil {
+		return err
+	}
+
+	if err := writeZipJSON(zw, "backtests.json", data.Backtests); err != nil {
+		return err
+	}
+
+	if err := writeZipJSON(zw, "api_keys.json", data.APIKeys); err != nil {
+		return err
+	}
+	if err := writeZipCSV(zw, "api_keys.csv", []string{"id", "name", "prefix", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at"}, len(data.APIKeys), func(i int) []string {
+		k := data.APIKeys[i]
+		return []string{exportUint(k.ID), k.Name, k.Prefix, strings.Join(k.Scopes, " "), exportTime(k.ExpiresAt), exportTimePtr(k.LastUsedAt), exportTimePtr(k.RevokedAt), exportTime(k.CreatedAt)}
+	}); err != nil {
+		return err
+	}
+
+	if err := writeZipJSON(zw, "auth_events.json", data.AuthEvents); err != nil {
+		return err
+	}
+	if err := writeZipCSV(zw, "auth_events.csv", []string{"created_at", "event", "email", "ip", "user_agent", "detail"}, len(data.AuthEvents), func(i int) []string {
+		e := data.AuthEvents[i]
+		return []string{exportTime(e.CreatedAt), e.Event, e.Email, e.IP, e.UserAgent, e.Detail}
+	}); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+type watchlistRecord struct {
+	Ticker  string    `json:"ticker"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// ledgerRecord is a ledger entry as exported, with the entry type spelled out.
+type ledgerRecord struct {
+	ID          uint64          `json:"id"`
+	PortfolioID uint            `json:"portfolio_id"`
+	TradeID     string          `json:"trade_id"`
+	EntryType   string          `json:"entry_type"`
+	Currency    string          `json:"currency"`
+	Amount      float64         `json:"amount"`
+	ExecutedAt  time.Time       `json:"executed_at"`
+	Meta        json.RawMessage `json:"meta,omitempty"`
+}
+
+func newLedgerRecord(e models.UserLedgerEntry) ledgerRecord {
+	record := ledgerRecord{
+		ID:          e.ID,
+		PortfolioID: e.PortfolioID,
+		TradeID:     e.TradeID,
+		EntryType:   ledgerEntryTypeName(e.EntryType),
+		Currency:    e.Currency,
+		Amount:      e.Amount,
+		ExecutedAt:  e.ExecutedAt.UTC(),
+	}
+	if len(e.Meta) > 0 {
+		record.Meta = json.RawMessage(e.Meta)
+	}
+	return record
+}
+
+func ledgerEntryTypeName(t models.LedgerEntryType) string {
+	switch t {
+	case models.LedgerEntryExchange:
+		return "exchange"
+	case models.LedgerEntryFee:
+		return "fee"
+	case models.LedgerEntryAdjustment:
+		return "adjustment"
+	default:
+		return strconv.Itoa(int(t))
+	}
+}
+
+// writeLedgerJSON streams the entries as one JSON array.
+func writeLedgerJSON(zw *zip.Writer, eachLedger func(func([]models.UserLedgerEntry) error) error) error {
+	return writeZipFile(zw, "ledger_entries.json", func(f io.Writer) error {
+		if _, err := io.WriteString(f, "["); err != nil {
+			return err
+		}
+		first := true
+		err := eachLedger(func(batch []models.UserLedgerEntry) error {
+			for _, entry := range batch {
+				raw, err := json.Marshal(newLedgerRecord(entry))
+				if err != nil {
+					return err
+				}
+				if !first {
+					if _, err := io.WriteString(f, ",\n"); err != nil {
+						return err
+					}
+				}
+				first = false
+				if _, err := f.Write(raw); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, "]\n")
+		return err
+	})
+}
+
+func writeLedgerCSV(zw *zip.Writer, eachLedger func(func([]models.UserLedgerEntry) error) error) error {
+	return writeZipFile(zw, "ledger_entries.csv", func(f io.Writer) error {
+		cw := csv.NewWriter(f)
+		if err := cw.Write([]string{"id", "portfolio_id", "trade_id", "entry_type", "currency", "amount", "executed_at", "meta"}); err != nil {
+			return err
+		}
+		err := eachLedger(func(batch []models.UserLedgerEntry) error {
+			for _, entry := range batch {
+				r := newLedgerRecord(entry)
+				if err := cw.Write(csvSafe([]string{strconv.FormatUint(r.ID, 10), exportUint(r.PortfolioID), r.TradeID, r.EntryType, r.Currency, exportFloat(r.Amount), exportTime(r.ExecutedAt), string(r.Meta)})); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+func writeZipFile(zw *zip.Writer, name string, write func(io.Writer) error) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("add %s: %w", name, err)
+	}
+	if err := write(f); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v any) error {
+	return writeZipFile(zw, name, func(f io.Writer) error {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	})
+}
+
+func writeZipCSV(zw *zip.Writer, name string, header []string, rows int, row func(int) []string) error {
+	return writeZipFile(zw, name, func(f io.Writer) error {
+		cw := csv.NewWriter(f)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for i := 0; i < rows; i++ {
+			if err := cw.Write(csvSafe(row(i))); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+// csvSafe defuses cells a spreadsheet would run as a formula: anything starting with = + - @
+// or a tab or carriage return gets a leading apostrophe. Plain numbers such as -12.5 are left
+// alone.
+func csvSafe(row []string) []string {
+	for i, cell := range row {
+		if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			continue
+		}
+		if _, err := strconv.ParseFloat(cell, 64); err == nil {
+			continue
+		}
+		row[i] = "'" + cell
+	}
+	return row
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func exportTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return exportTime(*t)
+}
+
+func exportUint(v uint) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func exportFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// newClaimToken identifies one runner's claim on an export.
+func newClaimToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
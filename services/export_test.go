@@ -0,0 +1,349 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDataExportRepo struct {
+	repository.DataExportRepository
+	exports []*models.DataExport
+	chunks  map[uint][][]byte
+}
+
+func (f *fakeDataExportRepo) Create(ctx context.Context, export *models.DataExport) error {
+	export.ID = uint(len(f.exports) + 1)
+	copied := *export
+	f.exports = append(f.exports, &copied)
+	return nil
+}
+
+func (f *fakeDataExportRepo) Get(ctx context.Context, userID, id uint) (*models.DataExport, error) {
+	if id == 0 || int(id) > len(f.exports) || f.exports[id-1].UserID != userID {
+		return nil, repository.ErrDataExportNotFound
+	}
+	copied := *f.exports[id-1]
+	return &copied, nil
+}
+
+func (f *fakeDataExportRepo) FindActive(ctx context.Context, userID uint) (*models.DataExport, error) {
+	for _, e := range f.exports {
+		if e.UserID == userID && (e.Status == models.DataExportPending || e.Status == models.DataExportRunning) {
+			copied := *e
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeDataExportRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error) {
+	var out []models.DataExport
+	for _, e := range f.exports {
+		if e.Status == models.DataExportPending {
+			out = append(out, *e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDataExportRepo) Claim(ctx context.Context, id uint, token string, now, leaseUntil time.Time) (bool, error) {
+	e := f.exports[id-1]
+	if e.Status != models.DataExportPending {
+		return false, nil
+	}
+	e.Status = models.DataExportRunning
+	e.LeaseUntil = &leaseUntil
+	e.ClaimToken = token
+	return true, nil
+}
+
+// claimed returns the export while token holds its claim.
+func (f *fakeDataExportRepo) claimed(id uint, token string) (*models.DataExport, error) {
+	e := f.exports[id-1]
+	if e.Status != models.DataExportRunning || e.ClaimToken != token {
+		return nil, repository.ErrDataExportClaimLost
+	}
+	return e, nil
+}
+
+func (f *fakeDataExportRepo) DeleteChunks(ctx context.Context, id uint, token string, leaseUntil time.Time) error {
+	e, err := f.claimed(id, token)
+	if err != nil {
+		return err
+	}
+	e.LeaseUntil = &leaseUntil
+	delete(f.chunks, id)
+	return nil
+}
+
+func (f *fakeDataExportRepo) AppendChunk(ctx context.Context, id uint, token string, seq int, data []byte, leaseUntil time.Time) error {
+	e, err := f.claimed(id, token)
+	if err != nil {
+		return err
+	}
+	e.LeaseUntil = &leaseUntil
+	if f.chunks == nil {
+		f.chunks = map[uint][][]byte{}
+	}
+	if seq != len(f.chunks[id]) {
+		return fmt.Errorf("chunk %d out of order", seq)
+	}
+	f.chunks[id] = append(f.chunks[id], data)
+	return nil
+}
+
+func (f *fakeDataExportRepo) Complete(ctx context.Context, id uint, token string, size int64, at, expiresAt time.Time) error {
+	e, err := f.claimed(id, token)
+	if err != nil {
+		return err
+	}
+	e.Status = models.DataExportReady
+	e.SizeBytes = size
+	e.CompletedAt = &at
+	e.ExpiresAt = &expiresAt
+	return nil
+}
+
+func (f *fakeDataExportRepo) Fail(ctx context.Context, id uint, token string, reason string, at time.Time) error {
+	e, err := f.claimed(id, token)
+	if err != nil {
+		return err
+	}
+	e.Status = models.DataExportFailed
+	e.Error = reason
+	delete(f.chunks, id)
+	return nil
+}
+
+func (f *fakeDataExportRepo) EachChunk(ctx context.Context, id uint, fn func([]byte) error) error {
+	for _, chunk := range f.chunks[id] {
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeDataExportRepo) GetReady(ctx context.Context, id uint, now time.Time) (*models.DataExport, error) {
+	if id == 0 || int(id) > len(f.exports) {
+		return nil, repository.ErrDataExportNotFound
+	}
+	e := f.exports[id-1]
+	if e.Status != models.DataExportReady || !e.ExpiresAt.After(now) {
+		return nil, repository.ErrDataExportNotFound
+	}
+	return e, nil
+}
+
+func (f *fakeDataExportRepo) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakePersonalData struct {
+	data      *repository.PersonalData
+	ledger    []models.UserLedgerEntry
+	collected func() // runs after Collect, to interleave another runner
+}
+
+func (f *fakePersonalData) Collect(ctx context.Context, userID uint) (*repository.PersonalData, error) {
+	if f.collected != nil {
+		f.collected()
+	}
+	return f.data, nil
+}
+
+func (f *fakePersonalData) EachLedgerBatch(ctx context.Context, userID uint, fn func([]models.UserLedgerEntry) error) error {
+	// Two batches, to exercise the streaming writers.
+	for start := 0; start < len(f.ledger); start += 2 {
+		end := min(start+2, len(f.ledger))
+		if err := fn(f.ledger[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportIsBuiltInBackgroundAndDownloadedThroughSignedLink(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	user := &models.User{Email: "a@example.com", Name: "A"}
+	user.ID = 7
+	executed := now.Add(-time.Hour)
+	personal := &fakePersonalData{
+		data: &repository.PersonalData{
+			User:       user,
+			WatchItems: []models.WatchItem{{Ticker: "EUR"}},
+			APIKeys:    []models.APIKey{{ID: 1, Name: "script", Prefix: "tik_abcd", KeyHash: "secret-hash", Scopes: []string{"market:read"}}},
+		},
+		ledger: []models.UserLedgerEntry{
+			{ID: 1, TradeID: "t1", Currency: "USD", Amount: -100, ExecutedAt: executed},
+			{ID: 2, TradeID: "t1", Currency: "EUR", Amount: 90, ExecutedAt: executed},
+			{ID: 3, TradeID: "t1", Currency: "USD", Amount: -1, ExecutedAt: executed, EntryType: models.LedgerEntryFee},
+		},
+	}
+	repo := &fakeDataExportRepo{}
+	svc := &exportService{
+		repo:     repo,
+		personal: personal,
+		audit:    &fakeAudit{},
+		cfg:      ExportConfig{TTL: time.Hour, LinkSecret: []byte("test-secret")},
+		now:      func() time.Time { return now },
+	}
+	ctx := context.Background()
+
+	queued, err := svc.Request(ctx, 7)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, models.DataExportPending, queued.Status)
+	assert.Empty(t, queued.DownloadURL)
+	again, err := svc.Request(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, queued.ID, again.ID)
+
+	assert.NoError(t, svc.RunDue(ctx))
+	status, err := svc.Status(ctx, 7, queued.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, models.DataExportReady, status.Status)
+	_, err = svc.Status(ctx, 8, queued.ID)
+	assert.ErrorIs(t, err, repository.ErrDataExportNotFound)
+
+	link, err := url.Parse(status.DownloadURL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	expires, signature := link.Query().Get("expires"), link.Query().Get("signature")
+	_, err = svc.Download(ctx, queued.ID, expires, signature+"0")
+	assert.ErrorIs(t, err, ErrInvalidExportLink)
+	_, err = svc.Download(ctx, queued.ID+1, expires, signature)
+	assert.ErrorIs(t, err, ErrInvalidExportLink)
+
+	archive, err := svc.Download(ctx, queued.ID, expires, signature)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var downloaded bytes.Buffer
+	assert.NoError(t, archive.Stream(&downloaded))
+	assert.Equal(t, archive.Size, int64(downloaded.Len()))
+	files := readZip(t, downloaded.Bytes())
+	for _, name := range []string{"README.txt", "profile.json", "watchlist.csv", "ledger_entries.json", "ledger_entries.csv", "orders.csv", "api_keys.json", "auth_events.csv"} {
+		assert.Contains(t, files, name)
+	}
+
+	var ledger []ledgerRecord
+	assert.NoError(t, json.Unmarshal(files["ledger_entries.json"], &ledger))
+	if assert.Len(t, ledger, 3) {
+		assert.Equal(t, "fee", ledger[2].EntryType)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(files["ledger_entries.csv"])).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+	assert.NotContains(t, string(files["api_keys.json"]), "secret-hash")
+
+	// The link stops working once the export expires.
+	now = now.Add(2 * time.Hour)
+	_, err = svc.Download(ctx, queued.ID, expires, signature)
+	assert.ErrorIs(t, err, ErrInvalidExportLink)
+}
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if !assert.NoError(t, err) {
+			continue
+		}
+		files[f.Name], err = io.ReadAll(rc)
+		assert.NoError(t, err)
+		rc.Close()
+	}
+	return files
+}
+
+func TestExportChunkWriterSplitsArchive(t *testing.T) {
+	repo := &fakeDataExportRepo{exports: []*models.DataExport{{ID: 1, Status: models.DataExportRunning, ClaimToken: "runner"}}}
+	w := &exportChunkWriter{ctx: context.Background(), repo: repo, id: 1, token: "runner", now: time.Now}
+	data := bytes.Repeat([]byte("x"), 2*exportChunkSize+10)
+	for start := 0; start < len(data); start += 1000 {
+		_, err := w.Write(data[start:min(start+1000, len(data))])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	if assert.Len(t, repo.chunks[1], 3) {
+		assert.Len(t, repo.chunks[1][0], exportChunkSize)
+		assert.Len(t, repo.chunks[1][2], 10)
+	}
+	assert.Equal(t, int64(len(data)), w.size)
+}
+
+func TestExportRunnerThatLostItsClaimLeavesTheNewBuildAlone(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	user := &models.User{Email: "a@example.com"}
+	user.ID = 7
+	repo := &fakeDataExportRepo{}
+	personal := &fakePersonalData{data: &repository.PersonalData{User: user}}
+	svc := &exportService{
+		repo:     repo,
+		personal: personal,
+		audit:    &fakeAudit{},
+		cfg:      ExportConfig{TTL: time.Hour, LinkSecret: []byte("test-secret")},
+		now:      func() time.Time { return now },
+	}
+	ctx := context.Background()
+	queued, err := svc.Request(ctx, 7)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// While this runner collects, its lease lapses and another runner claims the export and
+	// writes its first chunk.
+	personal.collected = func() {
+		e := repo.exports[queued.ID-1]
+		e.ClaimToken = "other"
+		repo.chunks = map[uint][][]byte{queued.ID: {[]byte("other")}}
+	}
+	assert.NoError(t, svc.RunDue(ctx))
+
+	e := repo.exports[queued.ID-1]
+	assert.Equal(t, models.DataExportRunning, e.Status)
+	assert.Equal(t, "other", e.ClaimToken)
+	assert.Equal(t, [][]byte{[]byte("other")}, repo.chunks[queued.ID])
+}
+
+func TestCSVSafeDefusesFormulas(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"EUR", "EUR"},
+		{"", ""},
+		{"-100", "-100"},
+		{"+1.5", "+1.5"},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+cmd|' /C calc'!A0", "'+cmd|' /C calc'!A0"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tx", "'\tx"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, csvSafe([]string{tt.cell})[0], tt.cell)
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/indicators"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const maxIndicatorPeriod = 500
+
+// defaultIndicators is used when a request does not name any indicators.
+var defaultIndicators = []string{"sma:20", "ema:20", "rsi:14", "macd:12:26:9", "bbands:20:2", "atr:14", "stoch:14:3"}
+
+type IndicatorService interface {
+	Compute(ctx context.Context, req *IndicatorRequest) (*IndicatorSeries, error)
+}
+
+type indicatorService struct {
+	currencyRepo repository.CurrencyRepository
+	analytics    AnalyticsService
+}
+
+func NewIndicatorService(currencyRepo repository.CurrencyRepository, analytics AnalyticsService) IndicatorService {
+	return &indicatorService{
+		currencyRepo: currencyRepo,
+		analytics:    analytics,
+	}
+}
+
+// IndicatorRequest selects the candle series and indicators. When Quote is set the series is the
+// cross pair Ticker/Quote; otherwise it is Ticker against the stored base. Indicators use the
+// "name:param:param" form, e.g. sma:20, macd:12:26:9, bbands:20:2, stoch:14:3.
+type IndicatorRequest struct {
+	Ticker     string
+	Quote      string
+	Bucket     string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Indicators []string
+}
+
+// IndicatorSeries holds the candles and every indicator aligned index-for-index with them.
+// Values are null while an indicator is warming up.
+type IndicatorSeries struct {
+	Ticker     string                 `json:"ticker"`
+	Quote      string                 `json:"quote,omitempty"`
+	Bucket     string                 `json:"bucket"`
+	Candles    []repository.CandleRow `json:"candles"`
+	Indicators map[string][]*float64  `json:"indicators"`
+}
+
+func (s *indicatorService) Compute(ctx context.Context, req *IndicatorRequest) (*IndicatorSeries, error) {
+	ticker := strings.ToUpper(strings.TrimSpace(req.Ticker))
+	quote := strings.ToUpper(strings.TrimSpace(req.Quote))
+	if ticker == "" {
+		return nil, fmt.Errorf("ticker is required")
+	}
+	bucket := strings.ToLower(strings.TrimSpace(req.Bucket))
+	if bucket == "" {
+		bucket = "1m"
+	}
+
+	specs := req.Indicators
+	if len(specs) == 0 {
+		specs = defaultIndicators
+	}
+	parsed := make([]indicatorSpec, 0, len(specs))
+	for _, raw := range specs {
+		spec, err := parseIndicatorSpec(raw)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, spec)
+	}
+
+	candles, err := s.candles(ctx, ticker, quote, bucket, req.From, req.To, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	high := make([]float64, len(candles))
+	low := make([]float64, len(candles))
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		high[i], low[i], closes[i] = c.High, c.Low, c.Close
+	}
+
+	out := &IndicatorSeries{
+		Ticker:     ticker,
+		Quote:      quote,
+		Bucket:     bucket,
+		Candles:    candles,
+		Indicators: make(map[string][]*float64),
+	}
+	for _, spec := range parsed {
+		for name, values := range spec.compute(high, low, closes) {
+			out.Indicators[name] = nullableSeries(values)
+		}
+	}
+	return out, nil
+}
+
+func (s *indicatorService) candles(ctx context.Context, ticker, quote, bucket string, from, to *time.Time, limit int) ([]repository.CandleRow, error) {
+	if quote != "" {
+		return s.analytics.CrossCandles(ctx, ticker, quote, bucket, from, to, limit)
+	}
+	interval, err := normalizeCandleBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	return s.currencyRepo.ListCandles(ctx, ticker, interval, from, to, limit)
+}
+
+type indicatorSpec struct {
+	name   string
+	params []float64
+}
+
+func parseIndicatorSpec(raw string) (indicatorSpec, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(raw)), ":")
+	spec := indicatorSpec{name: parts[0]}
+
+	defaults := map[string][]float64{
+		"sma":    {20},
+		"ema":    {20},
+		"rsi":    {14},
+		"macd":   {12, 26, 9},
+		"bbands": {20, 2},
+		"atr":    {14},
+		"stoch":  {14, 3},
+	}
+	def, ok := defaults[spec.name]
+	if !ok {
+		return spec, fmt.Errorf("unsupported indicator %q; use one of: sma, ema, rsi, macd, bbands, atr, stoch", spec.name)
+	}
+	if len(parts)-1 > len(def) {
+		return spec, fmt.Errorf("too many parameters for %s", spec.name)
+	}
+
+	spec.params = append([]float64(nil), def...)
+	for i, p := range parts[1:] {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v <= 0 {
+			return spec, fmt.Errorf("invalid parameter %q for %s", p, spec.name)
+		}
+		// Only the Bollinger width is fractional; everything else is a bar count.
+		isWidth := spec.name == "bbands" && i == 1
+		if !isWidth && (v != math.Trunc(v) || v > maxIndicatorPeriod) {
+			return spec, fmt.Errorf("periods for %s must be whole numbers up to %d", spec.name, maxIndicatorPeriod)
+		}
+		spec.params[i] = v
+	}
+	return spec, nil
+}
+
+func (spec indicatorSpec) key() string {
+	parts := []string{spec.name}
+	for _, p := range spec.params {
+		parts = append(parts, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	return strings.Join(parts, "_")
+}
+
+func (spec indicatorSpec) compute(high, low, closes []float64) map[string][]float64 {
+	key := spec.key()
+	p := func(i int) int { return int(spec.params[i]) }
+
+	switch spec.name {
+	case "sma":
+		return map[string][]float64{key: indicators.SMA(closes, p(0))}
+	case "ema":
+		return map[string][]float64{key: indicators.EMA(closes, p(0))}
+	case "rsi":
+		return map[string][]float64{key: indicators.RSI(closes, p(0))}
+	case "macd":
+		line, signal, hist := indicators.MACD(closes, p(0), p(1), p(2))
+		return map[string][]float64{key: line, key + "_signal": signal, key + "_hist": hist}
+	case "bbands":
+		middle, upper, lower := indicators.Bollinger(closes, p(0), spec.params[1])
+		return map[string][]float64{key + "_middle": middle, key + "_upper": upper, key + "_lower": lower}
+	case "atr":
+		return map[string][]float64{key: indicators.ATR(high, low, closes, p(0))}
+	case "stoch":
+		k, d := indicators.Stochastic(high, low, closes, p(0), p(1))
+		return map[string][]float64{key + "_k": k, key + "_d": d}
+	}
+	return nil
+}
+
+// nullableSeries swaps NaN for nil so the series can be JSON-encoded.
+func nullableSeries(values []float64) []*float64 {
+	out := make([]*float64, len(values))
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		v := v
+		out[i] = &v
+	}
+	return out
+}
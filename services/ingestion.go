@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/ODawah/Trading-Insights/models"
 	"github.com/ODawah/Trading-Insights/repository"
+	"log"
 	"net/http"
 	"os"
 	"time"
@@ -15,17 +16,25 @@ type IngestionService interface {
 	FetchRates(ctx context.Context) (*models.Snapshot, error)
 }
 
+// SnapshotListener is notified after every snapshot has been stored. Listener errors are
+// logged and never fail ingestion.
+type SnapshotListener interface {
+	OnSnapshot(ctx context.Context, snapshot *models.Snapshot) error
+}
+
 type ingestionService struct {
 	client       *http.Client
 	currencyRepo repository.CurrencyRepository
+	listeners    []SnapshotListener
 }
 
-func NewIngestionAPIClient(currencyRepo repository.CurrencyRepository) IngestionService {
+func NewIngestionAPIClient(currencyRepo repository.CurrencyRepository, listeners ...SnapshotListener) IngestionService {
 	return &ingestionService{
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 		currencyRepo: currencyRepo,
+		listeners:    listeners,
 	}
 }
 
@@ -42,6 +51,11 @@ func (s *ingestionService) FetchRates(ctx context.Context) (*models.Snapshot, er
 	if err != nil {
 		return nil, err
 	}
+	for _, listener := range s.listeners {
+		if err := listener.OnSnapshot(ctx, snapshot); err != nil {
+			log.Printf("Snapshot listener failed: %v", err)
+		}
+	}
 	return snapshot, nil
 }
 
@@ -50,11 +64,16 @@ func (s *ingestionService) FetchFromEXCONVERT(ctx context.Context) (*models.Snap
 	if url == "" {
 		return nil, fmt.Errorf(" EXCONVERT_URL environment variable is not set")
 	}
+	return fetchSnapshot(ctx, s.client, url)
+}
+
+// fetchSnapshot downloads one provider snapshot in the EXCONVERT response format.
+func fetchSnapshot(ctx context.Context, client *http.Client, url string) (*models.Snapshot, error) {
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	resp, err := s.client.Do(request)
+	resp, err := client.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch data from EXCONVERT: %w", err)
 	}
@@ -16,48 +16,94 @@ import (
 
 type LedgerService interface {
 	RecordExchange(ctx context.Context, req *ExchangeRequest) (string, error)
-	ListEntries(ctx context.Context, userID uint, currency string, limit int) ([]models.UserLedgerEntry, error)
+	ListEntries(ctx context.Context, userID uint, portfolios PortfolioSelector, currency string, limit int) ([]models.UserLedgerEntry, error)
 	GetTradeEntries(ctx context.Context, userID uint, tradeID string) ([]models.UserLedgerEntry, error)
 }
 
 type ledgerService struct {
-	repo repository.LedgerRepository
+	repo       repository.LedgerRepository
+	portfolios repository.PortfolioRepository
+	quotes     repository.QuoteRepository
+	rates      CrossRateSource
+	pricing    MarketPricing
+	now        func() time.Time
 }
 
-func NewLedgerService(repo repository.LedgerRepository) LedgerService {
-	return &ledgerService{repo: repo}
+func NewLedgerService(repo repository.LedgerRepository, portfolios repository.PortfolioRepository, quotes repository.QuoteRepository, rates CrossRateSource, pricing MarketPricing) LedgerService {
+	return &ledgerService{
+		repo:       repo,
+		portfolios: portfolios,
+		quotes:     quotes,
+		rates:      rates,
+		pricing:    pricing,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
 }
 
+// Execution modes for ExchangeRequest.Mode.
+const (
+	// ExecutionModeManual records the client-supplied FromAmount and ToAmount as-is.
+	ExecutionModeManual = "manual"
+	// ExecutionModeMarket takes one side from the client and prices the other off the latest stored rate.
+	ExecutionModeMarket = "market"
+	// ExecutionModeQuote executes at the rate locked by a previously issued quote (see QuoteService).
+	ExecutionModeQuote = "quote"
+)
+
 type ExchangeRequest struct {
-	UserID      uint                   `json:"user_id"`
-	TradeID     string                 `json:"trade_id,omitempty"`
-	FromCurrency string                `json:"from_currency"`
-	FromAmount   float64               `json:"from_amount"`
-	ToCurrency   string                `json:"to_currency"`
-	ToAmount     float64               `json:"to_amount"`
-	FeeCurrency  string                `json:"fee_currency,omitempty"`
-	FeeAmount    float64               `json:"fee_amount,omitempty"`
-	ExecutedAt   time.Time             `json:"executed_at"`
-	Meta         map[string]any        `json:"meta,omitempty"`
+	UserID       uint           `json:"user_id"`
+	PortfolioID  uint           `json:"portfolio_id,omitempty"` // defaults to the user's live portfolio
+	TradeID      string         `json:"trade_id,omitempty"`
+	Mode         string         `json:"mode,omitempty"`     // manual (default) or market
+	QuoteID      string         `json:"quote_id,omitempty"` // executes a locked quote; overrides mode and amounts
+	FromCurrency string         `json:"from_currency"`
+	FromAmount   float64        `json:"from_amount"`
+	ToCurrency   string         `json:"to_currency"`
+	ToAmount     float64        `json:"to_amount"`
+	FeeCurrency  string         `json:"fee_currency,omitempty"`
+	FeeAmount    float64        `json:"fee_amount,omitempty"`
+	ExecutedAt   time.Time      `json:"executed_at"` // manual mode only; market and quote trades execute now
+	Meta         map[string]any `json:"meta,omitempty"`
 }
 
 func (s *ledgerService) RecordExchange(ctx context.Context, req *ExchangeRequest) (string, error) {
+	if strings.TrimSpace(req.QuoteID) != "" {
+		if err := s.applyQuote(ctx, req); err != nil {
+			return "", err
+		}
+	}
 	if err := validateExchangeRequest(req); err != nil {
 		return "", err
 	}
+	portfolio, err := resolvePortfolio(ctx, s.portfolios, req.UserID, req.PortfolioID)
+	if err != nil {
+		return "", err
+	}
 
 	tradeID := req.TradeID
 	if strings.TrimSpace(tradeID) == "" {
-		var err error
 		tradeID, err = newTradeID()
 		if err != nil {
 			return "", fmt.Errorf("generate trade id: %w", err)
 		}
 	}
 
+	// Priced trades happen at the server's clock: a client-chosen time would let a market
+	// trade fill at any stored historical rate.
 	executedAt := req.ExecutedAt
-	if executedAt.IsZero() {
-		executedAt = time.Now().UTC()
+	switch normalizeExecutionMode(req.Mode) {
+	case ExecutionModeMarket, ExecutionModeQuote:
+		executedAt = s.now()
+	default:
+		if executedAt.IsZero() {
+			executedAt = s.now()
+		}
+	}
+
+	if normalizeExecutionMode(req.Mode) == ExecutionModeMarket {
+		if err := s.fillAtMarket(ctx, req, executedAt); err != nil {
+			return "", err
+		}
 	}
 
 	metaBytes, err := json.Marshal(req.Meta)
@@ -67,22 +113,24 @@ func (s *ledgerService) RecordExchange(ctx context.Context, req *ExchangeRequest
 
 	entries := []models.UserLedgerEntry{
 		{
-			UserID:     req.UserID,
-			TradeID:    tradeID,
-			Currency:   strings.ToUpper(req.FromCurrency),
-			Amount:     -req.FromAmount,
-			ExecutedAt: executedAt,
-			EntryType:  models.LedgerEntryExchange,
-			Meta:       datatypes.JSON(metaBytes),
+			UserID:      req.UserID,
+			PortfolioID: portfolio.ID,
+			TradeID:     tradeID,
+			Currency:    strings.ToUpper(req.FromCurrency),
+			Amount:      -req.FromAmount,
+			ExecutedAt:  executedAt,
+			EntryType:   models.LedgerEntryExchange,
+			Meta:        datatypes.JSON(metaBytes),
 		},
 		{
-			UserID:     req.UserID,
-			TradeID:    tradeID,
-			Currency:   strings.ToUpper(req.ToCurrency),
-			Amount:     req.ToAmount,
-			ExecutedAt: executedAt,
-			EntryType:  models.LedgerEntryExchange,
-			Meta:       datatypes.JSON(metaBytes),
+			UserID:      req.UserID,
+			PortfolioID: portfolio.ID,
+			TradeID:     tradeID,
+			Currency:    strings.ToUpper(req.ToCurrency),
+			Amount:      req.ToAmount,
+			ExecutedAt:  executedAt,
+			EntryType:   models.LedgerEntryExchange,
+			Meta:        datatypes.JSON(metaBytes),
 		},
 	}
 
@@ -92,13 +140,14 @@ func (s *ledgerService) RecordExchange(ctx context.Context, req *ExchangeRequest
 			feeCurrency = req.FromCurrency
 		}
 		entries = append(entries, models.UserLedgerEntry{
-			UserID:     req.UserID,
-			TradeID:    tradeID,
-			Currency:   strings.ToUpper(feeCurrency),
-			Amount:     -req.FeeAmount,
-			ExecutedAt: executedAt,
-			EntryType:  models.LedgerEntryFee,
-			Meta:       datatypes.JSON(metaBytes),
+			UserID:      req.UserID,
+			PortfolioID: portfolio.ID,
+			TradeID:     tradeID,
+			Currency:    strings.ToUpper(feeCurrency),
+			Amount:      -req.FeeAmount,
+			ExecutedAt:  executedAt,
+			EntryType:   models.LedgerEntryFee,
+			Meta:        datatypes.JSON(metaBytes),
 		})
 	}
 
@@ -108,9 +157,13 @@ func (s *ledgerService) RecordExchange(ctx context.Context, req *ExchangeRequest
 	return tradeID, nil
 }
 
-func (s *ledgerService) ListEntries(ctx context.Context, userID uint, currency string, limit int) ([]models.UserLedgerEntry, error) {
+func (s *ledgerService) ListEntries(ctx context.Context, userID uint, portfolios PortfolioSelector, currency string, limit int) ([]models.UserLedgerEntry, error) {
+	portfolioIDs, err := resolvePortfolioIDs(ctx, s.portfolios, userID, portfolios)
+	if err != nil {
+		return nil, err
+	}
 	currency = strings.ToUpper(strings.TrimSpace(currency))
-	return s.repo.ListByUser(ctx, userID, currency, limit)
+	return s.repo.ListByUser(ctx, userID, portfolioIDs, currency, limit)
 }
 
 func (s *ledgerService) GetTradeEntries(ctx context.Context, userID uint, tradeID string) ([]models.UserLedgerEntry, error) {
@@ -120,6 +173,89 @@ func (s *ledgerService) GetTradeEntries(ctx context.Context, userID uint, tradeI
 	return s.repo.GetByTradeID(ctx, userID, tradeID)
 }
 
+// fillAtMarket derives the missing side of a market-mode request from the stored cross rate
+// and records the price it used in req.Meta.
+func (s *ledgerService) fillAtMarket(ctx context.Context, req *ExchangeRequest, at time.Time) error {
+	price, err := s.pricing.Price(ctx, s.rates, req.FromCurrency, req.ToCurrency, at)
+	if err != nil {
+		return fmt.Errorf("price exchange: %w", err)
+	}
+	if price.Rate <= 0 {
+		return fmt.Errorf("price exchange: non-positive rate for %s/%s", price.From, price.To)
+	}
+
+	if req.FromAmount > 0 {
+		req.ToAmount = req.FromAmount * price.Rate
+	} else {
+		req.FromAmount = req.ToAmount / price.Rate
+	}
+
+	if req.Meta == nil {
+		req.Meta = make(map[string]any)
+	}
+	for k, v := range price.Meta() {
+		req.Meta[k] = v
+	}
+	req.Meta["execution_mode"] = ExecutionModeMarket
+	return nil
+}
+
+// applyQuote consumes the referenced quote and copies its locked amounts onto req. The quote
+// is checked against req before it is taken, so a mismatched request leaves it usable; once
+// taken it is gone even if the trade is later rejected.
+func (s *ledgerService) applyQuote(ctx context.Context, req *ExchangeRequest) error {
+	if req.UserID == 0 {
+		return fmt.Errorf("user_id is required")
+	}
+	if s.quotes == nil {
+		return fmt.Errorf("quotes are not configured")
+	}
+	quoteID := strings.TrimSpace(req.QuoteID)
+	quote, err := s.quotes.Get(ctx, req.UserID, quoteID)
+	if err != nil {
+		return err
+	}
+	if s.now().After(quote.ExpiresAt) {
+		return repository.ErrQuoteNotFound
+	}
+	if c := strings.TrimSpace(req.FromCurrency); c != "" && !strings.EqualFold(c, quote.FromCurrency) {
+		return fmt.Errorf("from_currency does not match quote")
+	}
+	if c := strings.TrimSpace(req.ToCurrency); c != "" && !strings.EqualFold(c, quote.ToCurrency) {
+		return fmt.Errorf("to_currency does not match quote")
+	}
+	// Taking it is what makes the quote single use; a concurrent request may have got there first.
+	if quote, err = s.quotes.Take(ctx, req.UserID, quoteID); err != nil {
+		return err
+	}
+
+	req.Mode = ExecutionModeQuote
+	req.FromCurrency = quote.FromCurrency
+	req.FromAmount = quote.FromAmount
+	req.ToCurrency = quote.ToCurrency
+	req.ToAmount = quote.ToAmount
+
+	if req.Meta == nil {
+		req.Meta = make(map[string]any)
+	}
+	req.Meta["execution_mode"] = ExecutionModeQuote
+	req.Meta["quote_id"] = quote.ID
+	req.Meta["mid_rate"] = quote.MidRate
+	req.Meta["rate"] = quote.Rate
+	req.Meta["spread_bps"] = quote.SpreadBps
+	req.Meta["quote_time"] = quote.QuoteTime
+	req.Meta["quote_expires_at"] = quote.ExpiresAt
+	return nil
+}
+
+func normalizeExecutionMode(mode string) string {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		return ExecutionModeManual
+	}
+	return mode
+}
+
 func validateExchangeRequest(req *ExchangeRequest) error {
 	if req.UserID == 0 {
 		return fmt.Errorf("user_id is required")
@@ -127,8 +263,23 @@ func validateExchangeRequest(req *ExchangeRequest) error {
 	if strings.TrimSpace(req.FromCurrency) == "" || strings.TrimSpace(req.ToCurrency) == "" {
 		return fmt.Errorf("from_currency and to_currency are required")
 	}
-	if req.FromAmount <= 0 || req.ToAmount <= 0 {
-		return fmt.Errorf("amounts must be positive values")
+	switch normalizeExecutionMode(req.Mode) {
+	case ExecutionModeManual, ExecutionModeQuote:
+		if normalizeExecutionMode(req.Mode) == ExecutionModeQuote && strings.TrimSpace(req.QuoteID) == "" {
+			return fmt.Errorf("quote mode requires quote_id")
+		}
+		if req.FromAmount <= 0 || req.ToAmount <= 0 {
+			return fmt.Errorf("amounts must be positive values")
+		}
+	case ExecutionModeMarket:
+		if req.FromAmount < 0 || req.ToAmount < 0 {
+			return fmt.Errorf("amounts must be positive values")
+		}
+		if (req.FromAmount > 0) == (req.ToAmount > 0) {
+			return fmt.Errorf("market mode takes exactly one of from_amount or to_amount")
+		}
+	default:
+		return fmt.Errorf("unsupported mode; use manual or market")
 	}
 	return nil
 }
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLedgerRepo struct {
+	repository.LedgerRepository
+	appended []models.UserLedgerEntry
+}
+
+func (f *fakeLedgerRepo) Append(ctx context.Context, entries []models.UserLedgerEntry) error {
+	f.appended = append(f.appended, entries...)
+	return nil
+}
+
+type fakePortfolioRepo struct {
+	repository.PortfolioRepository
+	portfolios []models.Portfolio
+}
+
+func (f *fakePortfolioRepo) GetOrCreateDefault(ctx context.Context, userID uint) (*models.Portfolio, error) {
+	for _, p := range f.portfolios {
+		if p.UserID == userID && p.IsDefault {
+			return &p, nil
+		}
+	}
+	p := models.Portfolio{ID: uint(len(f.portfolios) + 1), UserID: userID, Name: "Live", Kind: models.PortfolioKindLive, IsDefault: true}
+	f.portfolios = append(f.portfolios, p)
+	return &p, nil
+}
+
+func (f *fakePortfolioRepo) Get(ctx context.Context, userID, portfolioID uint) (*models.Portfolio, error) {
+	for _, p := range f.portfolios {
+		if p.ID == portfolioID && p.UserID == userID {
+			return &p, nil
+		}
+	}
+	return nil, repository.ErrPortfolioNotFound
+}
+
+func (f *fakePortfolioRepo) ListByUser(ctx context.Context, userID uint, kind string) ([]models.Portfolio, error) {
+	var out []models.Portfolio
+	for _, p := range f.portfolios {
+		if p.UserID == userID && (kind == "" || p.Kind == kind) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+type fakeCrossRates struct {
+	point CrossPoint
+	at    time.Time // time of the last lookup
+}
+
+func (f *fakeCrossRates) CrossRateAt(ctx context.Context, currencyA, currencyB string, at time.Time) (*CrossPoint, error) {
+	f.at = at
+	p := f.point
+	return &p, nil
+}
+
+func TestRecordExchangeMarketFillsToAmount(t *testing.T) {
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	repo := &fakeLedgerRepo{}
+	rates := &fakeCrossRates{point: CrossPoint{Time: now.Add(-30 * time.Second), Rate: 0.9, A: "USD", B: "EUR"}}
+	svc := NewLedgerService(repo, &fakePortfolioRepo{}, nil, rates, MarketPricing{SpreadBps: 100, MaxQuoteAge: time.Minute})
+	svc.(*ledgerService).now = func() time.Time { return now }
+
+	_, err := svc.RecordExchange(context.Background(), &ExchangeRequest{
+		UserID:       1,
+		Mode:         ExecutionModeMarket,
+		FromCurrency: "USD",
+		FromAmount:   1000,
+		ToCurrency:   "EUR",
+		ExecutedAt:   now,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, repo.appended, 2)
+	assert.Equal(t, -1000.0, repo.appended[0].Amount)
+	assert.InDelta(t, 891.0, repo.appended[1].Amount, 1e-9)
+
+	var meta map[string]any
+	assert.NoError(t, json.Unmarshal(repo.appended[1].Meta, &meta))
+	assert.Equal(t, "market", meta["execution_mode"])
+	assert.InDelta(t, 0.9, meta["mid_rate"], 1e-12)
+	assert.InDelta(t, 30.0, meta["quote_age_seconds"], 1e-9)
+}
+
+func TestRecordExchangeMarketRefusesStaleQuote(t *testing.T) {
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	repo := &fakeLedgerRepo{}
+	rates := &fakeCrossRates{point: CrossPoint{Time: now.Add(-10 * time.Minute), Rate: 0.9, A: "USD", B: "EUR"}}
+	svc := NewLedgerService(repo, &fakePortfolioRepo{}, nil, rates, MarketPricing{MaxQuoteAge: time.Minute})
+	svc.(*ledgerService).now = func() time.Time { return now }
+
+	_, err := svc.RecordExchange(context.Background(), &ExchangeRequest{
+		UserID:       1,
+		Mode:         ExecutionModeMarket,
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+		ToAmount:     500,
+		ExecutedAt:   now,
+	})
+	assert.Error(t, err)
+	assert.Empty(t, repo.appended)
+}
+
+func TestRecordExchangeMarketIgnoresBackdatedExecutedAt(t *testing.T) {
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	repo := &fakeLedgerRepo{}
+	rates := &fakeCrossRates{point: CrossPoint{Time: now.Add(-30 * time.Second), Rate: 0.9, A: "USD", B: "EUR"}}
+	svc := NewLedgerService(repo, &fakePortfolioRepo{}, nil, rates, MarketPricing{MaxQuoteAge: time.Minute})
+	svc.(*ledgerService).now = func() time.Time { return now }
+
+	_, err := svc.RecordExchange(context.Background(), &ExchangeRequest{
+		UserID:       1,
+		Mode:         ExecutionModeMarket,
+		FromCurrency: "USD",
+		FromAmount:   100,
+		ToCurrency:   "EUR",
+		ExecutedAt:   now.Add(-30 * 24 * time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, now, rates.at)
+	if assert.Len(t, repo.appended, 2) {
+		assert.Equal(t, now, repo.appended[0].ExecutedAt)
+	}
+}
+
+func TestRecordExchangeScopesEntriesToPortfolio(t *testing.T) {
+	repo := &fakeLedgerRepo{}
+	portfolios := &fakePortfolioRepo{portfolios: []models.Portfolio{
+		{ID: 4, UserID: 1, Name: "Practice", Kind: models.PortfolioKindPaper},
+		{ID: 5, UserID: 2, Name: "Practice", Kind: models.PortfolioKindPaper},
+	}}
+	svc := NewLedgerService(repo, portfolios, nil, nil, MarketPricing{})
+	req := func(portfolioID uint) *ExchangeRequest {
+		return &ExchangeRequest{UserID: 1, PortfolioID: portfolioID, FromCurrency: "USD", FromAmount: 10, ToCurrency: "EUR", ToAmount: 9}
+	}
+
+	_, err := svc.RecordExchange(context.Background(), req(4))
+	assert.NoError(t, err)
+	_, err = svc.RecordExchange(context.Background(), req(0))
+	assert.NoError(t, err)
+	_, err = svc.RecordExchange(context.Background(), req(5))
+	assert.ErrorIs(t, err, repository.ErrPortfolioNotFound)
+
+	if assert.Len(t, repo.appended, 4) {
+		assert.Equal(t, uint(4), repo.appended[0].PortfolioID)
+		assert.Equal(t, uint(3), repo.appended[2].PortfolioID) // live portfolio created on first use
+	}
+}
+
+func TestResolvePortfolioIDsKeepsKindsApart(t *testing.T) {
+	portfolios := &fakePortfolioRepo{portfolios: []models.Portfolio{
+		{ID: 1, UserID: 1, Name: "Main", Kind: models.PortfolioKindLive, IsDefault: true},
+		{ID: 2, UserID: 1, Name: "Savings", Kind: models.PortfolioKindLive},
+		{ID: 3, UserID: 1, Name: "Practice", Kind: models.PortfolioKindPaper},
+		{ID: 4, UserID: 2, Name: "Other", Kind: models.PortfolioKindLive, IsDefault: true},
+	}}
+	ctx := context.Background()
+
+	ids, err := resolvePortfolioIDs(ctx, portfolios, 1, PortfolioSelector{})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1}, ids)
+
+	ids, err = resolvePortfolioIDs(ctx, portfolios, 1, PortfolioSelector{All: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1, 2}, ids)
+
+	ids, err = resolvePortfolioIDs(ctx, portfolios, 1, PortfolioSelector{All: true, Kind: "paper"})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{3}, ids)
+
+	_, err = resolvePortfolioIDs(ctx, portfolios, 1, PortfolioSelector{ID: 4})
+	assert.ErrorIs(t, err, repository.ErrPortfolioNotFound)
+}
+
+func TestValidateExchangeRequestMarketNeedsOneSide(t *testing.T) {
+	err := validateExchangeRequest(&ExchangeRequest{
+		UserID:       1,
+		Mode:         ExecutionModeMarket,
+		FromCurrency: "USD",
+		FromAmount:   10,
+		ToCurrency:   "EUR",
+		ToAmount:     9,
+	})
+	assert.Error(t, err)
+}
+
+type fakeQuoteRepo struct {
+	repository.QuoteRepository
+	quotes map[string]*models.Quote
+}
+
+func (f *fakeQuoteRepo) Get(ctx context.Context, userID uint, quoteID string) (*models.Quote, error) {
+	q, ok := f.quotes[quoteID]
+	if !ok || q.UserID != userID {
+		return nil, repository.ErrQuoteNotFound
+	}
+	return q, nil
+}
+
+func (f *fakeQuoteRepo) Take(ctx context.Context, userID uint, quoteID string) (*models.Quote, error) {
+	q, ok := f.quotes[quoteID]
+	if !ok || q.UserID != userID {
+		return nil, repository.ErrQuoteNotFound
+	}
+	delete(f.quotes, quoteID)
+	return q, nil
+}
+
+func TestRecordExchangeExecutesLockedQuoteOnce(t *testing.T) {
+	repo := &fakeLedgerRepo{}
+	quotes := &fakeQuoteRepo{quotes: map[string]*models.Quote{
+		"q1": {
+			ID:           "q1",
+			UserID:       7,
+			FromCurrency: "EUR",
+			FromAmount:   100,
+			ToCurrency:   "JPY",
+			ToAmount:     16000,
+			Rate:         160,
+			ExpiresAt:    time.Now().Add(time.Minute),
+		},
+	}}
+	svc := NewLedgerService(repo, &fakePortfolioRepo{}, quotes, nil, MarketPricing{})
+
+	// A request that does not match the quote is refused without using it up.
+	_, err := svc.RecordExchange(context.Background(), &ExchangeRequest{UserID: 7, QuoteID: "q1", FromCurrency: "USD"})
+	assert.ErrorContains(t, err, "from_currency")
+	assert.Contains(t, quotes.quotes, "q1")
+
+	_, err = svc.RecordExchange(context.Background(), &ExchangeRequest{UserID: 7, QuoteID: "q1"})
+	assert.NoError(t, err)
+	assert.Len(t, repo.appended, 2)
+	assert.Equal(t, "JPY", repo.appended[1].Currency)
+	assert.Equal(t, 16000.0, repo.appended[1].Amount)
+
+	_, err = svc.RecordExchange(context.Background(), &ExchangeRequest{UserID: 7, QuoteID: "q1"})
+	assert.ErrorIs(t, err, repository.ErrQuoteNotFound)
+}
+
+func TestRecordExchangeRefusesExpiredQuoteByServiceClock(t *testing.T) {
+	expires := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	quotes := &fakeQuoteRepo{quotes: map[string]*models.Quote{
+		"q1": {ID: "q1", UserID: 7, FromCurrency: "EUR", FromAmount: 100, ToCurrency: "JPY", ToAmount: 16000, ExpiresAt: expires},
+	}}
+	svc := NewLedgerService(&fakeLedgerRepo{}, &fakePortfolioRepo{}, quotes, nil, MarketPricing{}).(*ledgerService)
+	svc.now = func() time.Time { return expires.Add(time.Second) }
+
+	_, err := svc.RecordExchange(context.Background(), &ExchangeRequest{UserID: 7, QuoteID: "q1"})
+	assert.ErrorIs(t, err, repository.ErrQuoteNotFound)
+}
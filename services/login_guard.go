@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+// ErrTooManyAttempts is wrapped by TooManyAttemptsError.
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// TooManyAttemptsError tells the client how long to wait before trying again. Locked is set
+// when this attempt is the one that locked the email or IP out.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("%v; try again in %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *TooManyAttemptsError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// LoginGuardConfig bounds failed logins. Failures are counted in a sliding Window per email
+// and per IP. After FreeAttempts failures for an email each further attempt must wait
+// BaseDelay, doubling per failure up to MaxDelay; reaching MaxPerEmail or MaxPerIP locks that
+// email or IP out for Lockout.
+type LoginGuardConfig struct {
+	Window       time.Duration
+	FreeAttempts int64
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	MaxPerEmail  int64
+	MaxPerIP     int64
+	Lockout      time.Duration
+}
+
+// LoginGuardConfigFromEnv reads LOGIN_FAILURE_WINDOW (15m), LOGIN_MAX_FAILURES (5 per email),
+// LOGIN_MAX_IP_FAILURES (20), LOGIN_LOCKOUT (15m) and LOGIN_BASE_DELAY (1s).
+func LoginGuardConfigFromEnv() LoginGuardConfig {
+	cfg := LoginGuardConfig{
+		Window:       15 * time.Minute,
+		FreeAttempts: 2,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		MaxPerEmail:  5,
+		MaxPerIP:     20,
+		Lockout:      15 * time.Minute,
+	}
+	for key, dst := range map[string]*time.Duration{
+		"LOGIN_FAILURE_WINDOW": &cfg.Window,
+		"LOGIN_LOCKOUT":        &cfg.Lockout,
+		"LOGIN_BASE_DELAY":     &cfg.BaseDelay,
+	} {
+		if raw := strings.TrimSpace(os.Getenv(key)); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil || d <= 0 {
+				log.Printf("Ignoring invalid %s %q", key, raw)
+			} else {
+				*dst = d
+			}
+		}
+	}
+	for key, dst := range map[string]*int64{
+		"LOGIN_MAX_FAILURES":    &cfg.MaxPerEmail,
+		"LOGIN_MAX_IP_FAILURES": &cfg.MaxPerIP,
+	} {
+		if raw := strings.TrimSpace(os.Getenv(key)); raw != "" {
+			n, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || n <= 0 {
+				log.Printf("Ignoring invalid %s %q", key, raw)
+			} else {
+				*dst = n
+			}
+		}
+	}
+	return cfg
+}
+
+// LoginGuard throttles logins per email and per IP. Every attempt is counted as a failure
+// before the password is checked, so parallel guesses cannot all slip under the limit; the
+// count is withdrawn again only for attempts that succeed.
+type LoginGuard interface {
+	// Begin counts an attempt, or returns a *TooManyAttemptsError when the email or IP must
+	// wait or has just used up its attempts.
+	Begin(ctx context.Context, email, ip string) (*LoginAttempt, error)
+	// PasswordAccepted withdraws the attempt from the IP's window. The email's count stands
+	// until the whole login, second factor included, has succeeded.
+	PasswordAccepted(ctx context.Context, attempt *LoginAttempt) error
+	// Failure records a failure after the fact, such as a wrong second-factor code, and
+	// reports whether it triggered a lockout.
+	Failure(ctx context.Context, email, ip string) (bool, error)
+	// Success clears the email's failures once the user is fully authenticated.
+	Success(ctx context.Context, email string) error
+}
+
+// LoginAttempt is an attempt counted by LoginGuard.Begin.
+type LoginAttempt struct {
+	email string
+	ip    string
+	at    time.Time
+}
+
+type loginGuard struct {
+	attempts repository.LoginAttemptRepository
+	cfg      LoginGuardConfig
+	now      func() time.Time
+}
+
+func NewLoginGuard(attempts repository.LoginAttemptRepository, cfg LoginGuardConfig) LoginGuard {
+	return &loginGuard{attempts: attempts, cfg: cfg, now: time.Now}
+}
+
+func emailSubject(email string) string { return "email:" + email }
+func ipSubject(ip string) string       { return "ip:" + ip }
+
+func (g *loginGuard) Begin(ctx context.Context, email, ip string) (*LoginAttempt, error) {
+	now := g.now()
+	var wait time.Duration
+	for _, subject := range g.subjects(email, ip) {
+		locked, err := g.attempts.LockRemaining(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		if locked > wait {
+			wait = locked
+		}
+	}
+	// Delays apply per email only; many users can share an IP, so IPs just have the lockout.
+	failures, last, err := g.attempts.Failures(ctx, emailSubject(email), now, g.cfg.Window)
+	if err != nil {
+		return nil, err
+	}
+	if remaining := last.Add(loginDelay(failures, g.cfg)).Sub(now); remaining > wait {
+		wait = remaining
+	}
+	if wait > 0 {
+		return nil, &TooManyAttemptsError{RetryAfter: wait}
+	}
+
+	// The limits are enforced on the atomic count returned here, not on the read above, so
+	// concurrent attempts past the limit are refused before any password is compared.
+	attempt := &LoginAttempt{email: email, ip: ip, at: now}
+	for _, subject := range g.subjects(email, ip) {
+		count, err := g.attempts.AddFailure(ctx, subject, now, g.cfg.Window)
+		if err != nil {
+			return nil, err
+		}
+		if count > g.limit(subject) {
+			if err := g.attempts.Lock(ctx, subject, g.cfg.Lockout); err != nil {
+				return nil, err
+			}
+			return nil, &TooManyAttemptsError{RetryAfter: g.cfg.Lockout, Locked: true}
+		}
+	}
+	return attempt, nil
+}
+
+func (g *loginGuard) PasswordAccepted(ctx context.Context, attempt *LoginAttempt) error {
+	if attempt.ip == "" {
+		return nil
+	}
+	return g.attempts.RemoveFailure(ctx, ipSubject(attempt.ip), attempt.at)
+}
+
+func (g *loginGuard) Failure(ctx context.Context, email, ip string) (bool, error) {
+	now := g.now()
+	locked := false
+	for _, subject := range g.subjects(email, ip) {
+		failures, err := g.attempts.AddFailure(ctx, subject, now, g.cfg.Window)
+		if err != nil {
+			return false, err
+		}
+		if failures >= g.limit(subject) {
+			if err := g.attempts.Lock(ctx, subject, g.cfg.Lockout); err != nil {
+				return false, err
+			}
+			locked = true
+		}
+	}
+	return locked, nil
+}
+
+func (g *loginGuard) Success(ctx context.Context, email string) error {
+	return g.attempts.Clear(ctx, emailSubject(email))
+}
+
+func (g *loginGuard) limit(subject string) int64 {
+	if strings.HasPrefix(subject, "ip:") {
+		return g.cfg.MaxPerIP
+	}
+	return g.cfg.MaxPerEmail
+}
+
+func (g *loginGuard) subjects(email, ip string) []string {
+	subjects := []string{emailSubject(email)}
+	if ip != "" {
+		subjects = append(subjects, ipSubject(ip))
+	}
+	return subjects
+}
+
+// loginDelay is how long to wait after the latest of failures: nothing for the first
+// FreeAttempts, then BaseDelay doubling per failure, capped at MaxDelay.
+func loginDelay(failures int64, cfg LoginGuardConfig) time.Duration {
+	excess := failures - cfg.FreeAttempts
+	if excess <= 0 {
+		return 0
+	}
+	delay := cfg.BaseDelay
+	for i := int64(1); i < excess && delay < cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > cfg.MaxDelay {
+		delay = cfg.MaxDelay
+	}
+	return delay
+}
+
+// reauthenticator re-checks a signed-in user before a sensitive account change. The checks
+// are throttled and audited like a login, so a stolen session neither gets unlimited guesses
+// at the password nor a way around the second factor.
+type reauthenticator struct {
+	guard LoginGuard
+	mfa   MFAService
+	audit AuditService
+}
+
+// password checks the user's password. The email's attempt stays counted until passed.
+func (r reauthenticator) password(ctx context.Context, user *models.User, password string) error {
+	ip := auth.ClientFromContext(ctx).IP
+	attempt, err := r.guard.Begin(ctx, user.Email, ip)
+	if err != nil {
+		var throttled *TooManyAttemptsError
+		if errors.As(err, &throttled) {
+			if throttled.Locked {
+				r.audit.Record(ctx, models.AuthEventLockout, user.ID, user.Email, "ip "+ip)
+			}
+			r.audit.Record(ctx, models.AuthEventLoginThrottled, user.ID, user.Email, err.Error())
+		}
+		return err
+	}
+	if !user.CheckPassword(password) {
+		log.Printf("⚠️  Failed re-authentication for: %s (wrong password)", user.Email)
+		r.audit.Record(ctx, models.AuthEventLoginFailure, user.ID, user.Email, "wrong password on re-authentication")
+		return ErrInvalidCredentials
+	}
+	if err := r.guard.PasswordAccepted(ctx, attempt); err != nil {
+		log.Printf("⚠️  Failed to withdraw login attempt for %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// secondFactor checks a TOTP or recovery code when two-factor is enabled. Wrong codes count
+// against the email and IP like a wrong password.
+func (r reauthenticator) secondFactor(ctx context.Context, user *models.User, code, recoveryCode string) error {
+	err := r.mfa.CheckCode(ctx, user.ID, code, recoveryCode)
+	if errors.Is(err, ErrInvalidMFACode) {
+		countSecondFactorFailure(ctx, r.guard, r.audit, user.ID, user.Email)
+	}
+	return err
+}
+
+// passed clears the email's failures once every check has succeeded.
+func (r reauthenticator) passed(ctx context.Context, user *models.User) {
+	if err := r.guard.Success(ctx, user.Email); err != nil {
+		log.Printf("⚠️  Failed to clear login failures for %s: %v", user.Email, err)
+	}
+}
+
+// countSecondFactorFailure audits a wrong second-factor code and counts it against the user's
+// email and the caller's IP.
+func countSecondFactorFailure(ctx context.Context, guard LoginGuard, audit AuditService, userID uint, email string) {
+	audit.Record(ctx, models.AuthEventMFAFailure, userID, email, "")
+	ip := auth.ClientFromContext(ctx).IP
+	locked, err := guard.Failure(ctx, email, ip)
+	if err != nil {
+		log.Printf("⚠️  Failed to count second-factor failure for %s: %v", email, err)
+		return
+	}
+	if locked {
+		audit.Record(ctx, models.AuthEventLockout, userID, email, "ip "+ip)
+	}
+}
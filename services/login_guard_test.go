@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAudit struct {
+	events []string
+}
+
+func (f *fakeAudit) Record(ctx context.Context, event string, userID uint, email, detail string) {
+	f.events = append(f.events, event)
+}
+
+func (f *fakeAudit) List(ctx context.Context, userID uint, limit int) ([]models.AuthEvent, error) {
+	return nil, nil
+}
+
+type fakeLoginAttempts struct {
+	failures map[string][]time.Time
+	locks    map[string]time.Time
+	now      *time.Time
+}
+
+func newFakeLoginAttempts(now *time.Time) *fakeLoginAttempts {
+	return &fakeLoginAttempts{failures: map[string][]time.Time{}, locks: map[string]time.Time{}, now: now}
+}
+
+func (f *fakeLoginAttempts) AddFailure(ctx context.Context, subject string, at time.Time, window time.Duration) (int64, error) {
+	f.failures[subject] = append(f.failures[subject], at)
+	n, _, err := f.Failures(ctx, subject, at, window)
+	return n, err
+}
+
+func (f *fakeLoginAttempts) RemoveFailure(ctx context.Context, subject string, at time.Time) error {
+	for i, t := range f.failures[subject] {
+		if t.Equal(at) {
+			f.failures[subject] = append(f.failures[subject][:i], f.failures[subject][i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeLoginAttempts) Failures(ctx context.Context, subject string, at time.Time, window time.Duration) (int64, time.Time, error) {
+	var n int64
+	var last time.Time
+	for _, t := range f.failures[subject] {
+		if t.After(at.Add(-window)) {
+			n++
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	return n, last, nil
+}
+
+func (f *fakeLoginAttempts) Clear(ctx context.Context, subject string) error {
+	delete(f.failures, subject)
+	delete(f.locks, subject)
+	return nil
+}
+
+func (f *fakeLoginAttempts) Lock(ctx context.Context, subject string, d time.Duration) error {
+	f.locks[subject] = f.now.Add(d)
+	return nil
+}
+
+func (f *fakeLoginAttempts) LockRemaining(ctx context.Context, subject string) (time.Duration, error) {
+	if until, ok := f.locks[subject]; ok && until.After(*f.now) {
+		return until.Sub(*f.now), nil
+	}
+	return 0, nil
+}
+
+// newTestLoginGuard locks an email out after maxPerEmail failures, with no delays before that.
+func newTestLoginGuard(maxPerEmail int64) *loginGuard {
+	now := time.Unix(1_700_000_000, 0)
+	return &loginGuard{
+		attempts: newFakeLoginAttempts(&now),
+		cfg: LoginGuardConfig{
+			Window: 15 * time.Minute, FreeAttempts: maxPerEmail, BaseDelay: time.Second, MaxDelay: time.Minute,
+			MaxPerEmail: maxPerEmail, MaxPerIP: 100, Lockout: 15 * time.Minute,
+		},
+		now: func() time.Time { return now },
+	}
+}
+
+func TestLoginDelayDoublesAfterFreeAttempts(t *testing.T) {
+	cfg := LoginGuardConfig{FreeAttempts: 2, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	want := []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for failures, delay := range want {
+		assert.Equal(t, delay, loginDelay(int64(failures), cfg), "failures=%d", failures)
+	}
+}
+
+func TestLoginThrottlesAndDoesNotRevealUnknownEmails(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	attempts := newFakeLoginAttempts(&now)
+	guard := &loginGuard{
+		attempts: attempts,
+		cfg: LoginGuardConfig{
+			Window: 15 * time.Minute, FreeAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Minute,
+			MaxPerEmail: 3, MaxPerIP: 100, Lockout: 15 * time.Minute,
+		},
+		now: func() time.Time { return now },
+	}
+	user := &models.User{Email: "a@example.com"}
+	user.ID = 7
+	assert.NoError(t, user.HashPassword("correct-password"))
+	audit := &fakeAudit{}
+	svc := &authService{
+		userRepo: &fakeUserRepo{users: map[uint]*models.User{7: user}},
+		guard:    guard,
+		audit:    audit,
+	}
+	ctx := auth.WithClient(context.Background(), auth.Client{IP: "203.0.113.7"})
+
+	// A missing account fails exactly like a wrong password (and does not panic).
+	_, _, unknown := svc.Login(ctx, &LoginRequest{Email: "nobody@example.com", Password: "x"})
+	_, _, wrong := svc.Login(ctx, &LoginRequest{Email: "a@example.com", Password: "x"})
+	assert.ErrorIs(t, unknown, ErrInvalidCredentials)
+	assert.Equal(t, unknown, wrong)
+
+	// The first failure is free; after the second the next attempt must wait a second.
+	_, _, err := svc.Login(ctx, &LoginRequest{Email: "A@example.com", Password: "x"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, _, err = svc.Login(ctx, &LoginRequest{Email: "a@example.com", Password: "correct-password"})
+	var throttled *TooManyAttemptsError
+	assert.True(t, errors.As(err, &throttled))
+	assert.Equal(t, time.Second, throttled.RetryAfter)
+
+	// Three failures use up the email's attempts; the next one locks it, even with the right password.
+	now = now.Add(time.Second)
+	_, _, err = svc.Login(ctx, &LoginRequest{Email: "a@example.com", Password: "x"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	now = now.Add(5 * time.Minute)
+	_, _, err = svc.Login(ctx, &LoginRequest{Email: "a@example.com", Password: "correct-password"})
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.Contains(t, audit.events, models.AuthEventLockout)
+	assert.Contains(t, audit.events, models.AuthEventLoginThrottled)
+}
+
+func TestLoginGuardCountsAttemptsBeforeTheyAreChecked(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	attempts := newFakeLoginAttempts(&now)
+	guard := &loginGuard{
+		attempts: attempts,
+		cfg: LoginGuardConfig{
+			Window: 15 * time.Minute, FreeAttempts: 10, BaseDelay: time.Second, MaxDelay: time.Minute,
+			MaxPerEmail: 2, MaxPerIP: 100, Lockout: 15 * time.Minute,
+		},
+		now: func() time.Time { return now },
+	}
+	ctx := context.Background()
+
+	// Attempts still in flight count, so a burst of parallel guesses cannot pass the limit.
+	first, err := guard.Begin(ctx, "a@example.com", "203.0.113.7")
+	assert.NoError(t, err)
+	_, err = guard.Begin(ctx, "a@example.com", "203.0.113.7")
+	assert.NoError(t, err)
+	_, err = guard.Begin(ctx, "a@example.com", "203.0.113.7")
+	var throttled *TooManyAttemptsError
+	if assert.True(t, errors.As(err, &throttled)) {
+		assert.True(t, throttled.Locked)
+	}
+
+	// A right password takes the attempt off the IP, but the email waits for the second factor.
+	assert.NoError(t, guard.PasswordAccepted(ctx, first))
+	assert.Len(t, attempts.failures["ip:203.0.113.7"], 1)
+	assert.Len(t, attempts.failures["email:a@example.com"], 3)
+	assert.NoError(t, guard.Success(ctx, "a@example.com"))
+	_, err = guard.Begin(ctx, "a@example.com", "198.51.100.1")
+	assert.NoError(t, err)
+}
+
+func TestLoginMFACountsWrongCodesAndClearsFailuresOnlyOnSuccess(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	attempts := newFakeLoginAttempts(&now)
+	guard := &loginGuard{
+		attempts: attempts,
+		cfg: LoginGuardConfig{
+			Window: 15 * time.Minute, FreeAttempts: 10, BaseDelay: time.Second, MaxDelay: time.Minute,
+			MaxPerEmail: 5, MaxPerIP: 100, Lockout: 15 * time.Minute,
+		},
+		now: func() time.Time { return now },
+	}
+	user := &models.User{Email: "a@example.com", TOTPEnabled: true}
+	user.ID = 7
+	assert.NoError(t, user.HashPassword("correct-password"))
+	users := &fakeUserRepo{users: map[uint]*models.User{7: user}}
+	audit := &fakeAudit{}
+	svc := &authService{
+		userRepo: users,
+		mfa:      fakeLoginMFA{userID: 7},
+		guard:    guard,
+		audit:    audit,
+	}
+	ctx := auth.WithClient(context.Background(), auth.Client{IP: "203.0.113.7"})
+
+	_, challenge, err := svc.Login(ctx, &LoginRequest{Email: "a@example.com", Password: "correct-password"})
+	if !assert.NoError(t, err) || !assert.NotNil(t, challenge) {
+		return
+	}
+	assert.Len(t, attempts.failures["email:a@example.com"], 1)
+
+	_, err = svc.LoginMFA(ctx, &MFALoginRequest{ChallengeToken: challenge.ChallengeToken, Code: "000000"})
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	assert.Len(t, attempts.failures["email:a@example.com"], 2)
+	assert.Len(t, attempts.failures["ip:203.0.113.7"], 1)
+	assert.Contains(t, audit.events, models.AuthEventMFAFailure)
+}
+
+type fakeLoginMFA struct {
+	MFAService
+	userID uint
+}
+
+func (f fakeLoginMFA) StartChallenge(ctx context.Context, userID uint) (*MFAChallenge, error) {
+	return &MFAChallenge{MFARequired: true, ChallengeToken: "challenge"}, nil
+}
+
+func (f fakeLoginMFA) CompleteChallenge(ctx context.Context, req *MFALoginRequest) (uint, error) {
+	return f.userID, ErrInvalidMFACode
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const (
+	recoveryCodeCount    = 10
+	challengeTTL         = 5 * time.Minute
+	maxChallengeAttempts = 5
+
+	// Re-authentication with a second factor outside login (CheckCode, Disable,
+	// RegenerateRecoveryCodes) locks for codeLockout after maxCodeFailures within
+	// codeFailureWindow.
+	maxCodeFailures   = 5
+	codeFailureWindow = 15 * time.Minute
+	codeLockout       = 15 * time.Minute
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+	ErrInvalidChallenge  = errors.New("invalid or expired login challenge")
+)
+
+// MFAService manages TOTP enrollment and recovery codes, and the challenge that stands
+// between a correct password and a session for users with two-factor enabled.
+type MFAService interface {
+	Status(ctx context.Context, userID uint) (*MFAStatus, error)
+	Enroll(ctx context.Context, userID uint) (*TOTPEnrollment, error)
+	Verify(ctx context.Context, userID uint, code string) (*RecoveryCodes, error)
+	Disable(ctx context.Context, userID uint, req *DisableMFARequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) (*RecoveryCodes, error)
+	StartChallenge(ctx context.Context, userID uint) (*MFAChallenge, error)
+	CompleteChallenge(ctx context.Context, req *MFALoginRequest) (uint, error)
+	CheckCode(ctx context.Context, userID uint, code, recoveryCode string) error
+}
+
+type mfaService struct {
+	users         repository.UserRepository
+	recoveryCodes repository.RecoveryCodeRepository
+	challenges    repository.ChallengeRepository
+	attempts      repository.LoginAttemptRepository
+}
+
+func NewMFAService(users repository.UserRepository, recoveryCodes repository.RecoveryCodeRepository, challenges repository.ChallengeRepository, attempts repository.LoginAttemptRepository) MFAService {
+	return &mfaService{
+		users:         users,
+		recoveryCodes: recoveryCodes,
+		challenges:    challenges,
+		attempts:      attempts,
+	}
+}
+
+func codeSubject(userID uint) string { return fmt.Sprintf("mfa:%d", userID) }
+
+type MFAStatus struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TOTPEnrollment is shown once so the user can add the secret to an authenticator app.
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes are shown once; only their hashes are stored.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge is returned by login instead of tokens when a second factor is required.
+type MFAChallenge struct {
+	MFARequired    bool      `json:"mfa_required"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// MFALoginRequest completes a login with either a TOTP code or a recovery code.
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type DisableMFARequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func (s *mfaService) Status(ctx context.Context, userID uint) (*MFAStatus, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &MFAStatus{Enabled: user.TOTPEnabled}
+	if user.TOTPEnabled {
+		if status.RecoveryCodesRemaining, err = s.recoveryCodes.CountUnused(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// Enroll creates a new TOTP secret. It is not enforced until Verify confirms the user's app
+// produces matching codes; enrolling again before that replaces the pending secret.
+func (s *mfaService) Enroll(ctx context.Context, userID uint) (*TOTPEnrollment, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.SetTOTP(ctx, userID, secret, false); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{Secret: secret, OTPAuthURI: auth.TOTPURI(user.Email, secret)}, nil
+}
+
+// Verify turns two-factor on once the user proves their app holds the pending secret, and
+// issues the first set of recovery codes.
+func (s *mfaService) Verify(ctx context.Context, userID uint, code string) (*RecoveryCodes, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, fmt.Errorf("start enrollment first")
+	}
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	if err := s.users.SetTOTP(ctx, userID, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+	if _, err := s.users.AdvanceTOTPStep(ctx, userID, step); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(ctx, userID)
+}
+
+// Disable turns two-factor off. It takes the password and a second factor, so a stolen
+// session alone cannot remove it; both count towards the user's code failures.
+func (s *mfaService) Disable(ctx context.Context, userID uint, req *DisableMFARequest) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+	if err := s.beginCodeAttempt(ctx, userID); err != nil {
+		return err
+	}
+	if !user.CheckPassword(req.Password) {
+		return ErrInvalidCredentials
+	}
+	if err := s.checkSecondFactor(ctx, userID, user.TOTPSecret, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+	s.codeAttemptPassed(ctx, userID)
+	if err := s.users.SetTOTP(ctx, userID, "", false); err != nil {
+		return err
+	}
+	return s.recoveryCodes.DeleteByUser(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not, after a TOTP check.
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) (*RecoveryCodes, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrMFANotEnabled
+	}
+	if err := s.beginCodeAttempt(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.checkSecondFactor(ctx, userID, user.TOTPSecret, code, ""); err != nil {
+		return nil, err
+	}
+	s.codeAttemptPassed(ctx, userID)
+	return s.issueRecoveryCodes(ctx, userID)
+}
+
+// StartChallenge is called after a correct password. The returned token must be exchanged
+// with a second factor within five minutes and at most five attempts.
+func (s *mfaService) StartChallenge(ctx context.Context, userID uint) (*MFAChallenge, error) {
+	token, hash, err := auth.NewChallengeToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.challenges.Create(ctx, hash, userID, challengeTTL); err != nil {
+		return nil, err
+	}
+	return &MFAChallenge{
+		MFARequired:    true,
+		ChallengeToken: token,
+		ExpiresAt:      time.Now().UTC().Add(challengeTTL),
+	}, nil
+}
+
+// CompleteChallenge checks the second factor for a pending login and returns the user it
+// belongs to, also alongside ErrInvalidMFACode for auditing. The challenge is single use.
+// Attempts are counted before the code is checked, so concurrent guesses cannot get past the
+// limit.
+func (s *mfaService) CompleteChallenge(ctx context.Context, req *MFALoginRequest) (uint, error) {
+	token := strings.TrimSpace(req.ChallengeToken)
+	if token == "" {
+		return 0, ErrInvalidChallenge
+	}
+	hash := auth.HashRefreshToken(token)
+	userID, attempts, err := s.challenges.Attempt(ctx, hash)
+	if errors.Is(err, repository.ErrChallengeNotFound) {
+		return 0, ErrInvalidChallenge
+	}
+	if err != nil {
+		return 0, err
+	}
+	if attempts > maxChallengeAttempts {
+		if _, err := s.challenges.Delete(ctx, hash); err != nil {
+			return 0, err
+		}
+		return userID, ErrInvalidChallenge
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return 0, ErrInvalidChallenge
+	}
+
+	if err := s.checkSecondFactor(ctx, userID, user.TOTPSecret, req.Code, req.RecoveryCode); err != nil {
+		if !errors.Is(err, ErrInvalidMFACode) {
+			return 0, err
+		}
+		if attempts == maxChallengeAttempts {
+			if _, delErr := s.challenges.Delete(ctx, hash); delErr != nil {
+				return 0, delErr
+			}
+		}
+		return userID, err
+	}
+
+	existed, err := s.challenges.Delete(ctx, hash)
+	if err != nil {
+		return 0, err
+	}
+	if !existed {
+		return 0, ErrInvalidChallenge
+	}
+	return userID, nil
+}
+
+// CheckCode re-authenticates a sensitive action with the second factor. It passes when
+// two-factor is not enabled.
+func (s *mfaService) CheckCode(ctx context.Context, userID uint, code, recoveryCode string) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return nil
+	}
+	if err := s.beginCodeAttempt(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.checkSecondFactor(ctx, userID, user.TOTPSecret, code, recoveryCode); err != nil {
+		return err
+	}
+	s.codeAttemptPassed(ctx, userID)
+	return nil
+}
+
+// beginCodeAttempt counts a re-authentication attempt as a failure before it is checked, so
+// parallel guesses cannot get past the limit, and refuses it with a *TooManyAttemptsError once
+// the user is locked out. A wrong guess does not consume a TOTP step, so without this a
+// session holder could try every code.
+func (s *mfaService) beginCodeAttempt(ctx context.Context, userID uint) error {
+	subject := codeSubject(userID)
+	locked, err := s.attempts.LockRemaining(ctx, subject)
+	if err != nil {
+		return err
+	}
+	if locked > 0 {
+		return &TooManyAttemptsError{RetryAfter: locked}
+	}
+	failures, err := s.attempts.AddFailure(ctx, subject, time.Now(), codeFailureWindow)
+	if err != nil {
+		return err
+	}
+	if failures > maxCodeFailures {
+		if err := s.attempts.Lock(ctx, subject, codeLockout); err != nil {
+			return err
+		}
+		return &TooManyAttemptsError{RetryAfter: codeLockout, Locked: true}
+	}
+	return nil
+}
+
+// codeAttemptPassed clears the user's code failures after a successful re-authentication.
+func (s *mfaService) codeAttemptPassed(ctx context.Context, userID uint) {
+	if err := s.attempts.Clear(ctx, codeSubject(userID)); err != nil {
+		log.Printf("⚠️  Failed to clear two-factor failures for user %d: %v", userID, err)
+	}
+}
+
+// checkSecondFactor accepts a recovery code (consumed) or a TOTP code whose time step has not
+// been used before.
+func (s *mfaService) checkSecondFactor(ctx context.Context, userID uint, secret, code, recoveryCode string) error {
+	if strings.TrimSpace(recoveryCode) != "" {
+		ok, err := s.recoveryCodes.Consume(ctx, userID, auth.HashRecoveryCode(recoveryCode), time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	fresh, err := s.users.AdvanceTOTPStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *mfaService) issueRecoveryCodes(ctx context.Context, userID uint) (*RecoveryCodes, error) {
+	codes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+	if err := s.recoveryCodes.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &RecoveryCodes{Codes: codes}, nil
+}
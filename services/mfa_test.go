@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeUserRepo) SetTOTP(ctx context.Context, id uint, secret string, enabled bool) error {
+	user := f.users[id]
+	user.TOTPSecret, user.TOTPEnabled, user.TOTPLastStep = secret, enabled, 0
+	return nil
+}
+
+func (f *fakeUserRepo) AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	user := f.users[id]
+	if user.TOTPLastStep >= step {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	return true, nil
+}
+
+type fakeRecoveryCodes map[string]bool // hash -> used
+
+func (f fakeRecoveryCodes) Replace(ctx context.Context, userID uint, hashes []string) error {
+	for k := range f {
+		delete(f, k)
+	}
+	for _, h := range hashes {
+		f[h] = false
+	}
+	return nil
+}
+
+func (f fakeRecoveryCodes) Consume(ctx context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	used, ok := f[hash]
+	if !ok || used {
+		return false, nil
+	}
+	f[hash] = true
+	return true, nil
+}
+
+func (f fakeRecoveryCodes) CountUnused(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	for _, used := range f {
+		if !used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f fakeRecoveryCodes) DeleteByUser(ctx context.Context, userID uint) error {
+	return f.Replace(ctx, userID, nil)
+}
+
+type fakeChallenges struct {
+	users    map[string]uint
+	attempts map[string]int64
+}
+
+func (f *fakeChallenges) Create(ctx context.Context, hash string, userID uint, ttl time.Duration) error {
+	f.users[hash] = userID
+	return nil
+}
+
+func (f *fakeChallenges) Attempt(ctx context.Context, hash string) (uint, int64, error) {
+	id, ok := f.users[hash]
+	if !ok {
+		return 0, 0, repository.ErrChallengeNotFound
+	}
+	f.attempts[hash]++
+	return id, f.attempts[hash], nil
+}
+
+func (f *fakeChallenges) Delete(ctx context.Context, hash string) (bool, error) {
+	_, ok := f.users[hash]
+	delete(f.users, hash)
+	return ok, nil
+}
+
+func TestTwoFactorEnrollmentAndChallenge(t *testing.T) {
+	user := &models.User{Email: "a@example.com"}
+	user.ID = 7
+	users := &fakeUserRepo{users: map[uint]*models.User{7: user}}
+	codes := fakeRecoveryCodes{}
+	challenges := &fakeChallenges{users: map[string]uint{}, attempts: map[string]int64{}}
+	svc := NewMFAService(users, codes, challenges, nil)
+	ctx := context.Background()
+
+	enrollment, err := svc.Enroll(ctx, 7)
+	assert.NoError(t, err)
+	assert.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+	assert.False(t, user.TOTPEnabled, "not enforced before verification")
+
+	_, err = svc.Verify(ctx, 7, "000000")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	now := time.Now()
+	previous, _ := auth.TOTPCode(enrollment.Secret, auth.TOTPStep(now)-1)
+	recovery, err := svc.Verify(ctx, 7, previous)
+	assert.NoError(t, err)
+	assert.True(t, user.TOTPEnabled)
+	assert.Len(t, recovery.Codes, recoveryCodeCount)
+
+	// The code that enabled 2FA cannot be replayed to log in.
+	challenge, err := svc.StartChallenge(ctx, 7)
+	assert.NoError(t, err)
+	_, err = svc.CompleteChallenge(ctx, &MFALoginRequest{ChallengeToken: challenge.ChallengeToken, Code: previous})
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	current, _ := auth.TOTPCode(enrollment.Secret, auth.TOTPStep(now))
+	id, err := svc.CompleteChallenge(ctx, &MFALoginRequest{ChallengeToken: challenge.ChallengeToken, Code: current})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), id)
+
+	// Challenges are single use; recovery codes too.
+	_, err = svc.CompleteChallenge(ctx, &MFALoginRequest{ChallengeToken: challenge.ChallengeToken, RecoveryCode: recovery.Codes[0]})
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	challenge, _ = svc.StartChallenge(ctx, 7)
+	_, err = svc.CompleteChallenge(ctx, &MFALoginRequest{ChallengeToken: challenge.ChallengeToken, RecoveryCode: recovery.Codes[0]})
+	assert.NoError(t, err)
+	challenge, _ = svc.StartChallenge(ctx, 7)
+	_, err = svc.CompleteChallenge(ctx, &MFALoginRequest{ChallengeToken: challenge.ChallengeToken, RecoveryCode: recovery.Codes[0]})
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	status, err := svc.Status(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(recoveryCodeCount-1), status.RecoveryCodesRemaining)
+}
+
+func TestChallengeDroppedAfterTooManyWrongCodes(t *testing.T) {
+	user := &models.User{Email: "a@example.com", TOTPEnabled: true}
+	user.ID = 7
+	user.TOTPSecret, _ = auth.GenerateTOTPSecret()
+	challenges := &fakeChallenges{users: map[string]uint{}, attempts: map[string]int64{}}
+	svc := NewMFAService(&fakeUserRepo{users: map[uint]*models.User{7: user}}, fakeRecoveryCodes{}, challenges, nil)
+	ctx := context.Background()
+
+	challenge, err := svc.StartChallenge(ctx, 7)
+	assert.NoError(t, err)
+	for i := 0; i < maxChallengeAttempts; i++ {
+		_, err = svc.CompleteChallenge(ctx, &MFALoginRequest{ChallengeToken: challenge.ChallengeToken, Code: "abcdef"})
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+
+	code, _ := auth.TOTPCode(user.TOTPSecret, auth.TOTPStep(time.Now()))
+	_, err = svc.CompleteChallenge(ctx, &MFALoginRequest{ChallengeToken: challenge.ChallengeToken, Code: code})
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+	// Attempts are counted before the code is checked: once concurrent guesses have used up
+	// the budget, even a correct code on the still-present challenge is refused.
+	challenge, err = svc.StartChallenge(ctx, 7)
+	assert.NoError(t, err)
+	challenges.attempts[auth.HashRefreshToken(challenge.ChallengeToken)] = maxChallengeAttempts
+	_, err = svc.CompleteChallenge(ctx, &MFALoginRequest{ChallengeToken: challenge.ChallengeToken, Code: code})
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+	assert.Empty(t, challenges.users)
+}
+
+func TestCodeChecksLockAfterTooManyFailures(t *testing.T) {
+	user := &models.User{Email: "a@example.com", TOTPEnabled: true}
+	user.ID = 7
+	user.TOTPSecret, _ = auth.GenerateTOTPSecret()
+	assert.NoError(t, user.HashPassword("password"))
+	now := time.Now()
+	svc := NewMFAService(&fakeUserRepo{users: map[uint]*models.User{7: user}}, fakeRecoveryCodes{}, nil, newFakeLoginAttempts(&now))
+	ctx := context.Background()
+
+	// A wrong password is reported as such, and counts like a wrong code.
+	assert.ErrorIs(t, svc.Disable(ctx, 7, &DisableMFARequest{Password: "wrong", Code: "abcdef"}), ErrInvalidCredentials)
+	for i := 1; i < maxCodeFailures; i++ {
+		_, err := svc.RegenerateRecoveryCodes(ctx, 7, "abcdef")
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+
+	// Every path shares the limit, and once it is reached even the right code is refused.
+	code, _ := auth.TOTPCode(user.TOTPSecret, auth.TOTPStep(now))
+	var throttled *TooManyAttemptsError
+	if assert.True(t, errors.As(svc.CheckCode(ctx, 7, code, ""), &throttled)) {
+		assert.True(t, throttled.Locked)
+	}
+	_, err := svc.RegenerateRecoveryCodes(ctx, 7, code)
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.True(t, user.TOTPEnabled)
+}
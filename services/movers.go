@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+)
+
+// moversCacheTTL matches the ingestion cadence so a cached overview is at most one snapshot behind.
+const moversCacheTTL = 30 * time.Second
+
+// Sort keys accepted by Movers.
+const (
+	MoversSortPercent    = "percent"
+	MoversSortAbsolute   = "absolute"
+	MoversSortVolatility = "volatility"
+)
+
+type moversWindow struct {
+	span   time.Duration
+	bucket string // resampling for the volatility estimate
+	step   time.Duration
+}
+
+var moversWindows = map[string]moversWindow{
+	"1h":  {span: time.Hour, bucket: "1 minute", step: time.Minute},
+	"24h": {span: 24 * time.Hour, bucket: "15 minutes", step: 15 * time.Minute},
+	"7d":  {span: 7 * 24 * time.Hour, bucket: "1 hour", step: time.Hour},
+}
+
+// Movers ranks every ticker over the window by percent change, absolute change and volatility,
+// comparing the snapshot at the window start with the latest stored one. Results are cached per window.
+func (s *currencyService) Movers(ctx context.Context, window, sortBy string, limit int) (*models.MarketMovers, error) {
+	window = strings.ToLower(strings.TrimSpace(window))
+	if window == "" {
+		window = "24h"
+	}
+	spec, ok := moversWindows[window]
+	if !ok {
+		return nil, fmt.Errorf("unsupported window; use one of: 1h, 24h, 7d")
+	}
+	sortBy = strings.ToLower(strings.TrimSpace(sortBy))
+	switch sortBy {
+	case "":
+		sortBy = MoversSortPercent
+	case MoversSortPercent, MoversSortAbsolute, MoversSortVolatility:
+	default:
+		return nil, fmt.Errorf("unsupported sort; use one of: percent, absolute, volatility")
+	}
+
+	movers, err := s.currencyRepo.GetMoversCache(ctx, window)
+	if err != nil {
+		movers, err = s.computeMovers(ctx, window, spec)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.currencyRepo.StoreMoversCache(ctx, movers, moversCacheTTL); err != nil {
+			log.Printf("Failed to cache movers for %s: %v", window, err)
+		}
+	}
+
+	sortMovers(movers.Movers, sortBy)
+	if limit > 0 && limit < len(movers.Movers) {
+		movers.Movers = movers.Movers[:limit]
+	}
+	return movers, nil
+}
+
+func (s *currencyService) computeMovers(ctx context.Context, window string, spec moversWindow) (*models.MarketMovers, error) {
+	latest, err := s.currencyRepo.GetSnapShotPG(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start, err := s.currencyRepo.GetSnapShotAtOrBefore(ctx, latest.Timestamp.Add(-spec.span))
+	if err != nil {
+		return nil, fmt.Errorf("not enough history for the %s window: %w", window, err)
+	}
+	vols, err := s.currencyRepo.ListReturnVolatility(ctx, spec.bucket, start.Timestamp, latest.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	annualize := math.Sqrt(float64(365*24*time.Hour) / float64(spec.step))
+	volatility := make(map[string]float64, len(vols))
+	for _, v := range vols {
+		volatility[v.Ticker] = v.StdDev * annualize
+	}
+
+	out := &models.MarketMovers{
+		Window: window,
+		Base:   latest.Base,
+		From:   start.Timestamp,
+		To:     latest.Timestamp,
+	}
+	for ticker, end := range latest.Result {
+		begin, ok := start.Result[ticker]
+		if !ok || begin <= 0 {
+			continue
+		}
+		out.Movers = append(out.Movers, models.MarketMover{
+			Ticker:        ticker,
+			StartRate:     begin,
+			EndRate:       end,
+			Change:        end - begin,
+			ChangePercent: (end/begin - 1) * 100,
+			Volatility:    volatility[ticker],
+		})
+	}
+	rankMovers(out.Movers)
+	return out, nil
+}
+
+// rankMovers fills the three rank fields; ties keep ticker order so ranks are stable.
+func rankMovers(movers []models.MarketMover) {
+	for _, key := range []string{MoversSortPercent, MoversSortAbsolute, MoversSortVolatility} {
+		sortMovers(movers, key)
+		for i := range movers {
+			switch key {
+			case MoversSortPercent:
+				movers[i].RankPercent = i + 1
+			case MoversSortAbsolute:
+				movers[i].RankAbsolute = i + 1
+			case MoversSortVolatility:
+				movers[i].RankVolatility = i + 1
+			}
+		}
+	}
+}
+
+func sortMovers(movers []models.MarketMover, key string) {
+	metric := func(m models.MarketMover) float64 {
+		switch key {
+		case MoversSortAbsolute:
+			return math.Abs(m.Change)
+		case MoversSortVolatility:
+			return m.Volatility
+		default:
+			return math.Abs(m.ChangePercent)
+		}
+	}
+	sort.Slice(movers, func(i, j int) bool {
+		mi, mj := metric(movers[i]), metric(movers[j])
+		if mi != mj {
+			return mi > mj
+		}
+		return movers[i].Ticker < movers[j].Ticker
+	})
+}
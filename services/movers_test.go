@@ -0,0 +1,25 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankMoversByEachMetric(t *testing.T) {
+	movers := []models.MarketMover{
+		{Ticker: "EUR", Change: -0.01, ChangePercent: -1.1, Volatility: 0.05},
+		{Ticker: "JPY", Change: 2.5, ChangePercent: 1.7, Volatility: 0.09},
+		{Ticker: "GBP", Change: 0.004, ChangePercent: 0.5, Volatility: 0.12},
+	}
+	rankMovers(movers)
+
+	ranks := make(map[string][3]int)
+	for _, m := range movers {
+		ranks[m.Ticker] = [3]int{m.RankPercent, m.RankAbsolute, m.RankVolatility}
+	}
+	assert.Equal(t, [3]int{2, 2, 3}, ranks["EUR"])
+	assert.Equal(t, [3]int{1, 1, 2}, ranks["JPY"])
+	assert.Equal(t, [3]int{3, 3, 1}, ranks["GBP"])
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+// Order request types. An OCO request places a limit and a stop leg that cancel each other.
+const (
+	OrderRequestLimit = models.OrderTypeLimit
+	OrderRequestStop  = models.OrderTypeStop
+	OrderRequestOCO   = "oco"
+)
+
+type OrderService interface {
+	SnapshotListener
+	Place(ctx context.Context, req *OrderRequest) ([]models.Order, error)
+	Get(ctx context.Context, userID uint, id string) (*models.Order, error)
+	List(ctx context.Context, userID uint, status string, limit int) ([]models.Order, error)
+	Cancel(ctx context.Context, userID uint, id string) (*models.Order, error)
+}
+
+type orderService struct {
+	repo       repository.OrderRepository
+	portfolios repository.PortfolioRepository
+	ledger     LedgerService
+}
+
+func NewOrderService(repo repository.OrderRepository, portfolios repository.PortfolioRepository, ledger LedgerService) OrderService {
+	return &orderService{
+		repo:       repo,
+		portfolios: portfolios,
+		ledger:     ledger,
+	}
+}
+
+// OrderRequest sells FromAmount of FromCurrency for ToCurrency when the mid rate (units of
+// ToCurrency per one FromCurrency) reaches LimitRate from below or StopRate from above.
+// Limit orders use LimitRate, stop orders StopRate and OCO orders both.
+type OrderRequest struct {
+	UserID       uint       `json:"user_id"`
+	PortfolioID  uint       `json:"portfolio_id,omitempty"` // defaults to the user's default portfolio
+	Type         string     `json:"type"`
+	FromCurrency string     `json:"from_currency"`
+	ToCurrency   string     `json:"to_currency"`
+	FromAmount   float64    `json:"from_amount"`
+	LimitRate    float64    `json:"limit_rate,omitempty"`
+	StopRate     float64    `json:"stop_rate,omitempty"`
+	GoodTill     *time.Time `json:"good_till,omitempty"`
+}
+
+func (s *orderService) Place(ctx context.Context, req *OrderRequest) ([]models.Order, error) {
+	orderType, err := validateOrderRequest(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	portfolio, err := resolvePortfolio(ctx, s.portfolios, req.UserID, req.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	base := models.Order{
+		UserID:       req.UserID,
+		PortfolioID:  portfolio.ID,
+		Status:       models.OrderStatusOpen,
+		FromCurrency: strings.ToUpper(strings.TrimSpace(req.FromCurrency)),
+		ToCurrency:   strings.ToUpper(strings.TrimSpace(req.ToCurrency)),
+		FromAmount:   req.FromAmount,
+		GoodTill:     req.GoodTill,
+	}
+	legs := map[string]float64{}
+	switch orderType {
+	case OrderRequestLimit:
+		legs[models.OrderTypeLimit] = req.LimitRate
+	case OrderRequestStop:
+		legs[models.OrderTypeStop] = req.StopRate
+	case OrderRequestOCO:
+		legs[models.OrderTypeLimit] = req.LimitRate
+		legs[models.OrderTypeStop] = req.StopRate
+		if base.OCOGroup, err = newTradeID(); err != nil {
+			return nil, fmt.Errorf("generate oco group: %w", err)
+		}
+	}
+
+	var orders []models.Order
+	for _, t := range []string{models.OrderTypeLimit, models.OrderTypeStop} {
+		rate, ok := legs[t]
+		if !ok {
+			continue
+		}
+		order := base
+		if order.ID, err = newTradeID(); err != nil {
+			return nil, fmt.Errorf("generate order id: %w", err)
+		}
+		order.Type = t
+		order.TriggerRate = rate
+		orders = append(orders, order)
+	}
+	if err := s.repo.Create(ctx, orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (s *orderService) Get(ctx context.Context, userID uint, id string) (*models.Order, error) {
+	return s.repo.Get(ctx, userID, strings.TrimSpace(id))
+}
+
+func (s *orderService) List(ctx context.Context, userID uint, status string, limit int) ([]models.Order, error) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	switch status {
+	case "", models.OrderStatusOpen, models.OrderStatusFilled, models.OrderStatusCancelled, models.OrderStatusExpired:
+	default:
+		return nil, fmt.Errorf("unsupported status; use open, filled, cancelled or expired")
+	}
+	return s.repo.ListByUser(ctx, userID, status, limit)
+}
+
+// Cancel closes an open order (both legs for OCO) and returns its final state.
+func (s *orderService) Cancel(ctx context.Context, userID uint, id string) (*models.Order, error) {
+	id = strings.TrimSpace(id)
+	if err := s.repo.Cancel(ctx, userID, id, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, userID, id)
+}
+
+// OnSnapshot expires stale orders and fills every open order the snapshot's mid rates trigger.
+// Fills go through RecordExchange in market mode, so they pay the same spread as any other
+// market exchange and land in the order's portfolio.
+func (s *orderService) OnSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	now := time.Now().UTC()
+	if expired, err := s.repo.ExpireDue(ctx, now); err != nil {
+		return err
+	} else if expired > 0 {
+		log.Printf("Expired %d orders", expired)
+	}
+
+	orders, err := s.repo.ListOpen(ctx)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		mid, ok := snapshotMid(snapshot, order.FromCurrency, order.ToCurrency)
+		if !ok || !orderTriggered(order, mid) {
+			continue
+		}
+		if err := s.fill(ctx, order, mid, now); err != nil {
+			log.Printf("Order %s not filled: %v", order.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *orderService) fill(ctx context.Context, order models.Order, mid float64, at time.Time) error {
+	tradeID, err := newTradeID()
+	if err != nil {
+		return fmt.Errorf("generate trade id: %w", err)
+	}
+	claimed, err := s.repo.Claim(ctx, order.ID, tradeID, mid, at)
+	if err != nil || !claimed {
+		return err
+	}
+
+	req := &ExchangeRequest{
+		UserID:       order.UserID,
+		PortfolioID:  order.PortfolioID,
+		TradeID:      tradeID,
+		Mode:         ExecutionModeMarket,
+		FromCurrency: order.FromCurrency,
+		FromAmount:   order.FromAmount,
+		ToCurrency:   order.ToCurrency,
+		ExecutedAt:   at,
+		Meta: map[string]any{
+			"order_id":     order.ID,
+			"order_type":   order.Type,
+			"trigger_rate": order.TriggerRate,
+			"trigger_mid":  mid,
+		},
+	}
+	if _, err := s.ledger.RecordExchange(ctx, req); err != nil {
+		if failErr := s.repo.Fail(ctx, order.ID, "fill failed: "+err.Error()); failErr != nil {
+			log.Printf("Order %s could not be marked failed: %v", order.ID, failErr)
+		}
+		return err
+	}
+	return s.repo.SetFilledAmount(ctx, order.ID, req.ToAmount)
+}
+
+// orderTriggered reports whether mid (units of To per From) crosses the order's trigger.
+func orderTriggered(order models.Order, mid float64) bool {
+	switch order.Type {
+	case models.OrderTypeLimit:
+		return mid >= order.TriggerRate
+	case models.OrderTypeStop:
+		return mid <= order.TriggerRate
+	default:
+		return false
+	}
+}
+
+// snapshotMid reads the from->to mid rate out of a single-base snapshot.
+func snapshotMid(snapshot *models.Snapshot, from, to string) (float64, bool) {
+	if snapshot == nil {
+		return 0, false
+	}
+	base := strings.ToUpper(strings.TrimSpace(snapshot.Base))
+	rate := func(c string) (float64, bool) {
+		if c == base {
+			return 1, true
+		}
+		r, ok := snapshot.Result[c]
+		return r, ok && r > 0
+	}
+	fromRate, ok := rate(from)
+	if !ok {
+		return 0, false
+	}
+	toRate, ok := rate(to)
+	if !ok {
+		return 0, false
+	}
+	return toRate / fromRate, true
+}
+
+func validateOrderRequest(req *OrderRequest, now time.Time) (string, error) {
+	if req.UserID == 0 {
+		return "", fmt.Errorf("user_id is required")
+	}
+	from := strings.TrimSpace(req.FromCurrency)
+	to := strings.TrimSpace(req.ToCurrency)
+	if from == "" || to == "" {
+		return "", fmt.Errorf("from_currency and to_currency are required")
+	}
+	if strings.EqualFold(from, to) {
+		return "", fmt.Errorf("from_currency and to_currency must differ")
+	}
+	if req.FromAmount <= 0 {
+		return "", fmt.Errorf("from_amount must be positive")
+	}
+	if req.GoodTill != nil && !req.GoodTill.After(now) {
+		return "", fmt.Errorf("good_till must be in the future")
+	}
+
+	orderType := strings.ToLower(strings.TrimSpace(req.Type))
+	switch orderType {
+	case OrderRequestLimit:
+		if req.LimitRate <= 0 || req.StopRate != 0 {
+			return "", fmt.Errorf("limit orders take a positive limit_rate only")
+		}
+	case OrderRequestStop:
+		if req.StopRate <= 0 || req.LimitRate != 0 {
+			return "", fmt.Errorf("stop orders take a positive stop_rate only")
+		}
+	case OrderRequestOCO:
+		if req.StopRate <= 0 || req.LimitRate <= req.StopRate {
+			return "", fmt.Errorf("oco orders need 0 < stop_rate < limit_rate")
+		}
+	default:
+		return "", fmt.Errorf("unsupported type; use limit, stop or oco")
+	}
+	return orderType, nil
+}
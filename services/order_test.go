@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeOrderRepo struct {
+	repository.OrderRepository
+	orders []models.Order
+}
+
+func (f *fakeOrderRepo) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeOrderRepo) ListOpen(ctx context.Context) ([]models.Order, error) {
+	var out []models.Order
+	for _, o := range f.orders {
+		if o.Status == models.OrderStatusOpen {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOrderRepo) Claim(ctx context.Context, id, tradeID string, mid float64, at time.Time) (bool, error) {
+	for i := range f.orders {
+		if f.orders[i].ID != id || f.orders[i].Status != models.OrderStatusOpen {
+			continue
+		}
+		f.orders[i].Status = models.OrderStatusFilled
+		f.orders[i].TradeID = tradeID
+		for j := range f.orders {
+			if j != i && f.orders[i].OCOGroup != "" && f.orders[j].OCOGroup == f.orders[i].OCOGroup {
+				f.orders[j].Status = models.OrderStatusCancelled
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *fakeOrderRepo) SetFilledAmount(ctx context.Context, id string, toAmount float64) error {
+	for i := range f.orders {
+		if f.orders[i].ID == id {
+			f.orders[i].ToAmount = toAmount
+		}
+	}
+	return nil
+}
+
+type fakeLedgerService struct {
+	LedgerService
+	requests []ExchangeRequest
+	err      error
+}
+
+func (f *fakeLedgerService) RecordExchange(ctx context.Context, req *ExchangeRequest) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	req.ToAmount = req.FromAmount * 2
+	f.requests = append(f.requests, *req)
+	return req.TradeID, nil
+}
+
+func TestSnapshotMidCrossesThroughBase(t *testing.T) {
+	snap := &models.Snapshot{Base: "USD", Result: map[string]float64{"EUR": 0.5, "JPY": 150}}
+
+	mid, ok := snapshotMid(snap, "EUR", "JPY")
+	assert.True(t, ok)
+	assert.Equal(t, 300.0, mid)
+
+	mid, ok = snapshotMid(snap, "EUR", "USD")
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, mid)
+
+	_, ok = snapshotMid(snap, "EUR", "GBP")
+	assert.False(t, ok)
+}
+
+func TestOnSnapshotFillsOneOCOLeg(t *testing.T) {
+	repo := &fakeOrderRepo{orders: []models.Order{
+		{ID: "limit", UserID: 1, PortfolioID: 3, Type: models.OrderTypeLimit, Status: models.OrderStatusOpen, OCOGroup: "g", FromCurrency: "EUR", ToCurrency: "USD", FromAmount: 10, TriggerRate: 1.9},
+		{ID: "stop", UserID: 1, PortfolioID: 3, Type: models.OrderTypeStop, Status: models.OrderStatusOpen, OCOGroup: "g", FromCurrency: "EUR", ToCurrency: "USD", FromAmount: 10, TriggerRate: 1.5},
+		{ID: "idle", UserID: 1, PortfolioID: 3, Type: models.OrderTypeLimit, Status: models.OrderStatusOpen, FromCurrency: "EUR", ToCurrency: "USD", FromAmount: 5, TriggerRate: 2.5},
+	}}
+	ledger := &fakeLedgerService{}
+	svc := NewOrderService(repo, nil, ledger)
+
+	err := svc.OnSnapshot(context.Background(), &models.Snapshot{Base: "USD", Result: map[string]float64{"EUR": 0.5}})
+	assert.NoError(t, err)
+
+	if assert.Len(t, ledger.requests, 1) {
+		req := ledger.requests[0]
+		assert.Equal(t, ExecutionModeMarket, req.Mode)
+		assert.Equal(t, uint(3), req.PortfolioID)
+		assert.Equal(t, "limit", req.Meta["order_id"])
+	}
+	assert.Equal(t, models.OrderStatusFilled, repo.orders[0].Status)
+	assert.Equal(t, 20.0, repo.orders[0].ToAmount)
+	assert.Equal(t, models.OrderStatusCancelled, repo.orders[1].Status)
+	assert.Equal(t, models.OrderStatusOpen, repo.orders[2].Status)
+}
+
+func TestValidateOrderRequest(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	req := OrderRequest{UserID: 1, Type: "OCO", FromCurrency: "eur", ToCurrency: "usd", FromAmount: 10, LimitRate: 1.2, StopRate: 1.0}
+
+	orderType, err := validateOrderRequest(&req, now)
+	assert.NoError(t, err)
+	assert.Equal(t, OrderRequestOCO, orderType)
+
+	req.StopRate = 1.3
+	_, err = validateOrderRequest(&req, now)
+	assert.Error(t, err)
+
+	past := now.Add(-time.Minute)
+	_, err = validateOrderRequest(&OrderRequest{UserID: 1, Type: "limit", FromCurrency: "EUR", ToCurrency: "USD", FromAmount: 1, LimitRate: 1, GoodTill: &past}, now)
+	assert.EqualError(t, err, "good_till must be in the future")
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const (
+	defaultPlanRetryDelay  = 5 * time.Minute
+	defaultPlanMaxAttempts = 3
+	planClaimLease         = 10 * time.Minute
+)
+
+// PlanConfig controls how failed plan runs (usually missing or stale rates) are retried.
+type PlanConfig struct {
+	RetryDelay  time.Duration // wait between attempts at the same occurrence
+	MaxAttempts int           // attempts before the occurrence is skipped
+}
+
+// PlanConfigFromEnv reads PLAN_RETRY_DELAY and PLAN_MAX_ATTEMPTS, falling back to defaults.
+func PlanConfigFromEnv() PlanConfig {
+	cfg := PlanConfig{
+		RetryDelay:  defaultPlanRetryDelay,
+		MaxAttempts: defaultPlanMaxAttempts,
+	}
+	if raw := strings.TrimSpace(os.Getenv("PLAN_RETRY_DELAY")); raw != "" {
+		delay, err := time.ParseDuration(raw)
+		if err != nil || delay <= 0 {
+			log.Printf("Ignoring invalid PLAN_RETRY_DELAY %q", raw)
+		} else {
+			cfg.RetryDelay = delay
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("PLAN_MAX_ATTEMPTS")); raw != "" {
+		attempts, err := strconv.Atoi(raw)
+		if err != nil || attempts < 1 {
+			log.Printf("Ignoring invalid PLAN_MAX_ATTEMPTS %q", raw)
+		} else {
+			cfg.MaxAttempts = attempts
+		}
+	}
+	return cfg
+}
+
+type PlanService interface {
+	Create(ctx context.Context, req *PlanRequest) (*models.ConversionPlan, error)
+	Get(ctx context.Context, userID, id uint) (*models.ConversionPlan, error)
+	List(ctx context.Context, userID uint) ([]models.ConversionPlan, error)
+	Update(ctx context.Context, userID, id uint, req *PlanUpdate) (*models.ConversionPlan, error)
+	Delete(ctx context.Context, userID, id uint) error
+	Pause(ctx context.Context, userID, id uint) (*models.ConversionPlan, error)
+	Resume(ctx context.Context, userID, id uint) (*models.ConversionPlan, error)
+	Executions(ctx context.Context, userID, id uint, limit int) ([]models.PlanExecution, error)
+	RunDue(ctx context.Context) error
+}
+
+type planService struct {
+	repo       repository.PlanRepository
+	portfolios repository.PortfolioRepository
+	ledger     LedgerService
+	cfg        PlanConfig
+	now        func() time.Time
+}
+
+func NewPlanService(repo repository.PlanRepository, portfolios repository.PortfolioRepository, ledger LedgerService, cfg PlanConfig) PlanService {
+	return &planService{
+		repo:       repo,
+		portfolios: portfolios,
+		ledger:     ledger,
+		cfg:        cfg,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// PlanRequest creates a recurring conversion of FromAmount FromCurrency into ToCurrency.
+// Schedules run in UTC at Hour:Minute; weekly plans use Weekday (0 = Sunday) and monthly
+// plans DayOfMonth, falling back to the last day of shorter months.
+type PlanRequest struct {
+	UserID       uint    `json:"user_id"`
+	PortfolioID  uint    `json:"portfolio_id,omitempty"` // defaults to the user's default portfolio
+	Name         string  `json:"name,omitempty"`
+	FromCurrency string  `json:"from_currency"`
+	ToCurrency   string  `json:"to_currency"`
+	FromAmount   float64 `json:"from_amount"`
+	Frequency    string  `json:"frequency"`
+	Weekday      int     `json:"weekday,omitempty"`
+	DayOfMonth   int     `json:"day_of_month,omitempty"`
+	Hour         int     `json:"hour,omitempty"`
+	Minute       int     `json:"minute,omitempty"`
+}
+
+// PlanUpdate changes the fields that are set; a schedule change moves the next run.
+type PlanUpdate struct {
+	Name       *string  `json:"name,omitempty"`
+	FromAmount *float64 `json:"from_amount,omitempty"`
+	Frequency  *string  `json:"frequency,omitempty"`
+	Weekday    *int     `json:"weekday,omitempty"`
+	DayOfMonth *int     `json:"day_of_month,omitempty"`
+	Hour       *int     `json:"hour,omitempty"`
+	Minute     *int     `json:"minute,omitempty"`
+}
+
+func (s *planService) Create(ctx context.Context, req *PlanRequest) (*models.ConversionPlan, error) {
+	if req.UserID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	from := strings.ToUpper(strings.TrimSpace(req.FromCurrency))
+	to := strings.ToUpper(strings.TrimSpace(req.ToCurrency))
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("from_currency and to_currency are required")
+	}
+	if from == to {
+		return nil, fmt.Errorf("from_currency and to_currency must differ")
+	}
+	portfolio, err := resolvePortfolio(ctx, s.portfolios, req.UserID, req.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.ConversionPlan{
+		UserID:       req.UserID,
+		PortfolioID:  portfolio.ID,
+		Name:         strings.TrimSpace(req.Name),
+		FromCurrency: from,
+		ToCurrency:   to,
+		FromAmount:   req.FromAmount,
+		Frequency:    strings.ToLower(strings.TrimSpace(req.Frequency)),
+		Weekday:      req.Weekday,
+		DayOfMonth:   req.DayOfMonth,
+		Hour:         req.Hour,
+		Minute:       req.Minute,
+		Status:       models.PlanStatusActive,
+	}
+	if err := validatePlan(plan); err != nil {
+		return nil, err
+	}
+	plan.NextRunAt = nextPlanRun(plan, s.now())
+	if err := s.repo.Create(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *planService) Get(ctx context.Context, userID, id uint) (*models.ConversionPlan, error) {
+	return s.repo.Get(ctx, userID, id)
+}
+
+func (s *planService) List(ctx context.Context, userID uint) ([]models.ConversionPlan, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *planService) Update(ctx context.Context, userID, id uint, req *PlanUpdate) (*models.ConversionPlan, error) {
+	plan, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		plan.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.FromAmount != nil {
+		plan.FromAmount = *req.FromAmount
+	}
+	rescheduled := false
+	if req.Frequency != nil {
+		plan.Frequency = strings.ToLower(strings.TrimSpace(*req.Frequency))
+		rescheduled = true
+	}
+	for _, field := range []struct {
+		value *int
+		dst   *int
+	}{
+		{req.Weekday, &plan.Weekday},
+		{req.DayOfMonth, &plan.DayOfMonth},
+		{req.Hour, &plan.Hour},
+		{req.Minute, &plan.Minute},
+	} {
+		if field.value != nil {
+			*field.dst = *field.value
+			rescheduled = true
+		}
+	}
+	if err := validatePlan(plan); err != nil {
+		return nil, err
+	}
+	if rescheduled {
+		plan.NextRunAt = nextPlanRun(plan, s.now())
+		plan.RetryAt = nil
+		plan.Attempts = 0
+	}
+	if err := s.repo.UpdateSettings(ctx, plan, rescheduled); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, userID, id)
+}
+
+func (s *planService) Delete(ctx context.Context, userID, id uint) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+// Pause stops a plan and drops any pending retry.
+func (s *planService) Pause(ctx context.Context, userID, id uint) (*models.ConversionPlan, error) {
+	if err := s.repo.Pause(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, userID, id)
+}
+
+// Resume reactivates a plan from its next occurrence; runs missed while paused are not caught up.
+func (s *planService) Resume(ctx context.Context, userID, id uint) (*models.ConversionPlan, error) {
+	plan, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status == models.PlanStatusActive {
+		return plan, nil
+	}
+	// A plan resumed concurrently is no longer paused; report it as it stands.
+	err = s.repo.Resume(ctx, userID, id, nextPlanRun(plan, s.now()))
+	if err != nil && !errors.Is(err, repository.ErrPlanNotFound) {
+		return nil, err
+	}
+	return s.repo.Get(ctx, userID, id)
+}
+
+func (s *planService) Executions(ctx context.Context, userID, id uint, limit int) ([]models.PlanExecution, error) {
+	if _, err := s.repo.Get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListExecutions(ctx, userID, id, limit)
+}
+
+// RunDue executes every active plan whose occurrence or retry is due. It is driven by the
+// scheduler in main.go; failures are recorded on the plan's history rather than returned.
+func (s *planService) RunDue(ctx context.Context) error {
+	now := s.now()
+	plans, err := s.repo.ListDue(ctx, now, 100)
+	if err != nil {
+		return err
+	}
+	for i := range plans {
+		claimed, err := s.repo.Claim(ctx, plans[i].ID, now, now.Add(planClaimLease))
+		if err != nil {
+			log.Printf("Plan %d not claimed: %v", plans[i].ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.execute(ctx, &plans[i], now); err != nil {
+			log.Printf("Plan %d run not recorded: %v", plans[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// execute attempts the plan's current occurrence and moves its schedule on: to the next
+// occurrence after a fill or once attempts run out, otherwise to a retry.
+func (s *planService) execute(ctx context.Context, plan *models.ConversionPlan, now time.Time) error {
+	execution := &models.PlanExecution{
+		PlanID:       plan.ID,
+		UserID:       plan.UserID,
+		ScheduledFor: plan.NextRunAt,
+		ExecutedAt:   now,
+		Attempt:      plan.Attempts + 1,
+		FromAmount:   plan.FromAmount,
+	}
+
+	req := &ExchangeRequest{
+		UserID:       plan.UserID,
+		PortfolioID:  plan.PortfolioID,
+		Mode:         ExecutionModeMarket,
+		FromCurrency: plan.FromCurrency,
+		FromAmount:   plan.FromAmount,
+		ToCurrency:   plan.ToCurrency,
+		ExecutedAt:   now,
+		Meta: map[string]any{
+			"plan_id":       plan.ID,
+			"scheduled_for": plan.NextRunAt,
+		},
+	}
+	tradeID, err := s.ledger.RecordExchange(ctx, req)
+
+	next := nextPlanRun(plan, now)
+	switch {
+	case err == nil:
+		execution.Status = models.PlanExecutionFilled
+		execution.TradeID = tradeID
+		execution.ToAmount = req.ToAmount
+		plan.LastRunAt = &now
+		plan.NextRunAt = next
+		plan.RetryAt = nil
+		plan.Attempts = 0
+	case execution.Attempt < s.cfg.MaxAttempts && now.Add(s.cfg.RetryDelay).Before(next):
+		execution.Status = models.PlanExecutionRetry
+		execution.Error = err.Error()
+		retryAt := now.Add(s.cfg.RetryDelay)
+		plan.RetryAt = &retryAt
+		plan.Attempts = execution.Attempt
+	default:
+		execution.Status = models.PlanExecutionSkipped
+		execution.Error = err.Error()
+		plan.NextRunAt = next
+		plan.RetryAt = nil
+		plan.Attempts = 0
+	}
+
+	if err := s.repo.AddExecution(ctx, execution); err != nil {
+		return err
+	}
+	return s.repo.RecordRun(ctx, plan)
+}
+
+func validatePlan(plan *models.ConversionPlan) error {
+	if plan.FromAmount <= 0 {
+		return fmt.Errorf("from_amount must be positive")
+	}
+	switch plan.Frequency {
+	case models.PlanFrequencyDaily:
+	case models.PlanFrequencyWeekly:
+		if plan.Weekday < 0 || plan.Weekday > 6 {
+			return fmt.Errorf("weekday must be between 0 (Sunday) and 6")
+		}
+	case models.PlanFrequencyMonthly:
+		if plan.DayOfMonth < 1 || plan.DayOfMonth > 31 {
+			return fmt.Errorf("day_of_month must be between 1 and 31")
+		}
+	default:
+		return fmt.Errorf("unsupported frequency; use daily, weekly or monthly")
+	}
+	if plan.Hour < 0 || plan.Hour > 23 || plan.Minute < 0 || plan.Minute > 59 {
+		return fmt.Errorf("hour and minute must be a valid UTC time of day")
+	}
+	return nil
+}
+
+// nextPlanRun returns the plan's first scheduled occurrence strictly after 'after'.
+func nextPlanRun(plan *models.ConversionPlan, after time.Time) time.Time {
+	after = after.UTC()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, plan.Hour, plan.Minute, 0, 0, time.UTC)
+	}
+
+	switch plan.Frequency {
+	case models.PlanFrequencyWeekly:
+		offset := (plan.Weekday - int(after.Weekday()) + 7) % 7
+		next := at(after.Year(), after.Month(), after.Day()+offset)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	case models.PlanFrequencyMonthly:
+		for months := 0; ; months++ {
+			first := time.Date(after.Year(), after.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+			lastDay := first.AddDate(0, 1, -1).Day()
+			next := at(first.Year(), first.Month(), min(plan.DayOfMonth, lastDay))
+			if next.After(after) {
+				return next
+			}
+		}
+	default:
+		next := at(after.Year(), after.Month(), after.Day())
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	}
+}
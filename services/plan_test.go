@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePlanRepo struct {
+	repository.PlanRepository
+	saved      []models.ConversionPlan
+	executions []models.PlanExecution
+}
+
+func (f *fakePlanRepo) RecordRun(ctx context.Context, plan *models.ConversionPlan) error {
+	f.saved = append(f.saved, *plan)
+	return nil
+}
+
+func (f *fakePlanRepo) AddExecution(ctx context.Context, execution *models.PlanExecution) error {
+	f.executions = append(f.executions, *execution)
+	return nil
+}
+
+func TestNextPlanRun(t *testing.T) {
+	// Wednesday 2025-01-15 10:00 UTC.
+	after := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	daily := &models.ConversionPlan{Frequency: models.PlanFrequencyDaily, Hour: 9}
+	assert.Equal(t, time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC), nextPlanRun(daily, after))
+
+	weekly := &models.ConversionPlan{Frequency: models.PlanFrequencyWeekly, Weekday: int(time.Monday)}
+	assert.Equal(t, time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), nextPlanRun(weekly, after))
+	weekly.Weekday, weekly.Hour = int(time.Wednesday), 10
+	assert.Equal(t, time.Date(2025, 1, 22, 10, 0, 0, 0, time.UTC), nextPlanRun(weekly, after))
+
+	monthly := &models.ConversionPlan{Frequency: models.PlanFrequencyMonthly, DayOfMonth: 31}
+	assert.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), nextPlanRun(monthly, after))
+	assert.Equal(t, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), nextPlanRun(monthly, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestPlanExecuteRetriesThenSkips(t *testing.T) {
+	repo := &fakePlanRepo{}
+	ledger := &fakeLedgerService{err: errors.New("no stored rate")}
+	svc := &planService{repo: repo, ledger: ledger, cfg: PlanConfig{RetryDelay: time.Minute, MaxAttempts: 2}}
+
+	due := time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)
+	plan := &models.ConversionPlan{ID: 1, UserID: 1, FromCurrency: "USD", ToCurrency: "EUR", FromAmount: 500,
+		Frequency: models.PlanFrequencyWeekly, Weekday: int(time.Monday), Status: models.PlanStatusActive, NextRunAt: due}
+
+	assert.NoError(t, svc.execute(context.Background(), plan, due))
+	assert.Equal(t, models.PlanExecutionRetry, repo.executions[0].Status)
+	assert.Equal(t, due.Add(time.Minute), *plan.RetryAt)
+	assert.Equal(t, due, plan.NextRunAt)
+
+	assert.NoError(t, svc.execute(context.Background(), plan, due.Add(time.Minute)))
+	assert.Equal(t, models.PlanExecutionSkipped, repo.executions[1].Status)
+	assert.Equal(t, due, repo.executions[1].ScheduledFor)
+	assert.Nil(t, plan.RetryAt)
+	assert.Equal(t, due.AddDate(0, 0, 7), plan.NextRunAt)
+
+	ledger.err = nil
+	assert.NoError(t, svc.execute(context.Background(), plan, plan.NextRunAt))
+	assert.Equal(t, models.PlanExecutionFilled, repo.executions[2].Status)
+	assert.Equal(t, 1000.0, repo.executions[2].ToAmount)
+	assert.Equal(t, due.AddDate(0, 0, 14), plan.NextRunAt)
+}
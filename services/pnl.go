@@ -0,0 +1,519 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+)
+
+// Lot matching methods accepted by PortfolioPnL.
+const (
+	CostMethodFIFO    = "fifo"
+	CostMethodLIFO    = "lifo"
+	CostMethodAverage = "average"
+)
+
+// lotEpsilon absorbs float residue so fully consumed lots are dropped.
+const lotEpsilon = 1e-9
+
+type PnLReport struct {
+	In         string        `json:"in"`
+	Method     string        `json:"method"`
+	At         time.Time     `json:"at"`
+	Realized   float64       `json:"realized"`
+	Unrealized float64       `json:"unrealized"`
+	Currencies []CurrencyPnL `json:"currencies"`
+	Trades     []TradePnL    `json:"trades"`
+}
+
+// CurrencyPnL summarizes one holding; amounts other than Quantity are in the reporting currency.
+type CurrencyPnL struct {
+	Currency    string  `json:"currency"`
+	Quantity    float64 `json:"quantity"`
+	CostBasis   float64 `json:"cost_basis"`
+	MarketValue float64 `json:"market_value"`
+	Realized    float64 `json:"realized"`
+	Unrealized  float64 `json:"unrealized"`
+}
+
+// TradePnL is the realized result of what a trade sold and the unrealized result of what it
+// bought and still holds. Amounts other than quantities are in the reporting currency.
+type TradePnL struct {
+	TradeID        string    `json:"trade_id"`
+	ExecutedAt     time.Time `json:"executed_at"`
+	Sold           string    `json:"sold,omitempty"`
+	SoldQuantity   float64   `json:"sold_quantity"`
+	Proceeds       float64   `json:"proceeds"`
+	CostBasis      float64   `json:"cost_basis"`
+	Fees           float64   `json:"fees"`
+	Realized       float64   `json:"realized"`
+	Bought         string    `json:"bought,omitempty"`
+	BoughtQuantity float64   `json:"bought_quantity"`
+	OpenQuantity   float64   `json:"open_quantity"`
+	Unrealized     float64   `json:"unrealized"`
+}
+
+// PortfolioPnL replays the user's ledger up to 'at', matching disposals against acquisition lots
+// with the given method, and marks the remaining lots to market at 'at'.
+//
+// Cost basis is tracked in the reporting currency using the stored rates at each trade's time.
+// An exchange's consideration is the reporting-currency leg when there is one, otherwise the
+// market value of what was received. Fees release cost basis with no proceeds. Adjustments are
+// treated as transfers: deposits open lots at market value, withdrawals release lots at cost.
+func (s *analyticsService) PortfolioPnL(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency, method string, at time.Time) (*PnLReport, error) {
+	if userID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	in := strings.ToUpper(strings.TrimSpace(inCurrency))
+	if in == "" {
+		in = "USD"
+	}
+	method, err := normalizeCostMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolioIDs, err := resolvePortfolioIDs(ctx, s.portfolios, userID, portfolios)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.ledgerRepo.ListByUserBetween(ctx, userID, portfolioIDs, time.Time{}, at)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rates for every trade come from one batched lookup rather than a query per trade.
+	trades := groupLedgerTrades(entries)
+	tickers := []string{in}
+	times := make([]time.Time, 0, len(trades))
+	for _, trade := range trades {
+		for _, e := range trade.entries {
+			tickers = append(tickers, e.Currency)
+		}
+		times = append(times, trade.executedAt)
+	}
+	ratesAtTrade, err := s.currencyRepo.LatestRatesAtOrBeforeEach(ctx, uniqueStrings(tickers), times)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := newPnLEngine(in, method)
+	for i, trade := range trades {
+		rateByTicker, baseCurrency := indexRates(ratesAtTrade[i])
+		if err := engine.apply(trade, ratesValuer(rateByTicker, baseCurrency, in)); err != nil {
+			return nil, fmt.Errorf("trade %s: %w", trade.tradeID, err)
+		}
+	}
+
+	valuer, err := s.valuerAt(ctx, uniqueStrings(append(engine.currencies(), in)), in, at)
+	if err != nil {
+		return nil, err
+	}
+	report, err := engine.report(valuer)
+	if err != nil {
+		return nil, err
+	}
+	report.At = at
+	return report, nil
+}
+
+// valuer converts an amount of a currency into the reporting currency.
+type valuer func(currency string, amount float64) (float64, error)
+
+func (s *analyticsService) valuerAt(ctx context.Context, tickers []string, in string, at time.Time) (valuer, error) {
+	rateByTicker, baseCurrency, err := s.ratesAt(ctx, tickers, at)
+	if err != nil {
+		return nil, err
+	}
+	return ratesValuer(rateByTicker, baseCurrency, in), nil
+}
+
+func ratesValuer(rateByTicker map[string]float64, baseCurrency, in string) valuer {
+	return func(currency string, amount float64) (float64, error) {
+		return valueBalancesIn(map[string]float64{currency: amount}, rateByTicker, baseCurrency, in)
+	}
+}
+
+func normalizeCostMethod(method string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(method)) {
+	case "", CostMethodFIFO:
+		return CostMethodFIFO, nil
+	case CostMethodLIFO:
+		return CostMethodLIFO, nil
+	case CostMethodAverage, "avg", "wac":
+		return CostMethodAverage, nil
+	default:
+		return "", fmt.Errorf("unsupported method; use one of: fifo, lifo, average")
+	}
+}
+
+type ledgerTrade struct {
+	tradeID    string
+	executedAt time.Time
+	entries    []models.UserLedgerEntry
+}
+
+// groupLedgerTrades groups time-ordered ledger rows by trade, keeping first-seen order.
+func groupLedgerTrades(entries []models.UserLedgerEntry) []*ledgerTrade {
+	var trades []*ledgerTrade
+	byID := make(map[string]*ledgerTrade)
+	for _, e := range entries {
+		e.Currency = strings.ToUpper(strings.TrimSpace(e.Currency))
+		t, ok := byID[e.TradeID]
+		if !ok {
+			t = &ledgerTrade{tradeID: e.TradeID, executedAt: e.ExecutedAt}
+			byID[e.TradeID] = t
+			trades = append(trades, t)
+		}
+		t.entries = append(t.entries, e)
+	}
+	return trades
+}
+
+type lot struct {
+	tradeID string
+	qty     float64
+	cost    float64 // total cost of qty in the reporting currency
+}
+
+// lotBook holds the open lots of one currency.
+type lotBook struct {
+	method string
+	lots   []lot
+}
+
+func (b *lotBook) acquire(l lot) {
+	if l.qty <= lotEpsilon {
+		return
+	}
+	b.lots = append(b.lots, l)
+}
+
+func (b *lotBook) quantity() float64 {
+	total := 0.0
+	for _, l := range b.lots {
+		total += l.qty
+	}
+	return total
+}
+
+func (b *lotBook) costBasis() float64 {
+	total := 0.0
+	for _, l := range b.lots {
+		total += l.cost
+	}
+	return total
+}
+
+// dispose removes qty from the book and returns the cost basis released along with any
+// quantity the open lots could not cover.
+func (b *lotBook) dispose(qty float64) (cost float64, uncovered float64) {
+	if qty <= 0 {
+		return 0, 0
+	}
+	held := b.quantity()
+	if held <= lotEpsilon {
+		return 0, qty
+	}
+	if qty > held {
+		uncovered = qty - held
+		qty = held
+	}
+
+	switch b.method {
+	case CostMethodAverage:
+		// Shrink every lot by the same fraction so each lot keeps the pooled average cost.
+		frac := qty / held
+		for i := range b.lots {
+			c := b.lots[i].cost * frac
+			cost += c
+			b.lots[i].cost -= c
+			b.lots[i].qty -= b.lots[i].qty * frac
+		}
+	case CostMethodLIFO:
+		for qty > lotEpsilon && len(b.lots) > 0 {
+			last := &b.lots[len(b.lots)-1]
+			take := math.Min(qty, last.qty)
+			c := last.cost * take / last.qty
+			cost += c
+			last.cost -= c
+			last.qty -= take
+			qty -= take
+			if last.qty <= lotEpsilon {
+				b.lots = b.lots[:len(b.lots)-1]
+			}
+		}
+	default:
+		for qty > lotEpsilon && len(b.lots) > 0 {
+			first := &b.lots[0]
+			take := math.Min(qty, first.qty)
+			c := first.cost * take / first.qty
+			cost += c
+			first.cost -= c
+			first.qty -= take
+			qty -= take
+			if first.qty <= lotEpsilon {
+				b.lots = b.lots[1:]
+			}
+		}
+	}
+
+	b.compact()
+	return cost, uncovered
+}
+
+func (b *lotBook) compact() {
+	out := b.lots[:0]
+	for _, l := range b.lots {
+		if l.qty > lotEpsilon {
+			out = append(out, l)
+		}
+	}
+	b.lots = out
+}
+
+// pnlEngine applies trades to per-currency lot books. Holdings of the reporting currency are
+// cash: they carry no lots and never produce P&L.
+type pnlEngine struct {
+	in        string
+	method    string
+	books     map[string]*lotBook
+	realized  map[string]float64
+	cash      float64
+	trades    []*TradePnL
+	tradeByID map[string]*TradePnL
+}
+
+func newPnLEngine(in, method string) *pnlEngine {
+	return &pnlEngine{
+		in:        in,
+		method:    method,
+		books:     make(map[string]*lotBook),
+		realized:  make(map[string]float64),
+		tradeByID: make(map[string]*TradePnL),
+	}
+}
+
+func (e *pnlEngine) book(currency string) *lotBook {
+	b, ok := e.books[currency]
+	if !ok {
+		b = &lotBook{method: e.method}
+		e.books[currency] = b
+	}
+	return b
+}
+
+func (e *pnlEngine) currencies() []string {
+	out := make([]string, 0, len(e.books))
+	for c := range e.books {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (e *pnlEngine) apply(trade *ledgerTrade, value valuer) error {
+	var outflows, inflows, fees, adjustments []models.UserLedgerEntry
+	for _, entry := range trade.entries {
+		switch {
+		case entry.EntryType == models.LedgerEntryExchange && entry.Amount < 0:
+			outflows = append(outflows, entry)
+		case entry.EntryType == models.LedgerEntryExchange && entry.Amount > 0:
+			inflows = append(inflows, entry)
+		case entry.EntryType == models.LedgerEntryFee:
+			fees = append(fees, entry)
+		default:
+			adjustments = append(adjustments, entry)
+		}
+	}
+
+	var tp *TradePnL
+	if len(outflows)+len(inflows)+len(fees) > 0 {
+		tp = &TradePnL{TradeID: trade.tradeID, ExecutedAt: trade.executedAt}
+		e.trades = append(e.trades, tp)
+		e.tradeByID[trade.tradeID] = tp
+	}
+
+	if len(outflows) == 1 && len(inflows) == 1 {
+		out, inflow := outflows[0], inflows[0]
+		var consideration float64
+		switch {
+		case out.Currency == e.in:
+			consideration = -out.Amount
+		case inflow.Currency == e.in:
+			consideration = inflow.Amount
+		default:
+			v, err := value(inflow.Currency, inflow.Amount)
+			if err != nil {
+				return err
+			}
+			consideration = v
+		}
+		e.dispose(tp, out.Currency, -out.Amount, consideration)
+		e.acquire(tp, trade.tradeID, inflow.Currency, inflow.Amount, consideration)
+	} else {
+		// Legs that do not form a simple pair are each valued at market.
+		for _, out := range outflows {
+			proceeds, err := value(out.Currency, -out.Amount)
+			if err != nil {
+				return err
+			}
+			e.dispose(tp, out.Currency, -out.Amount, proceeds)
+		}
+		for _, inflow := range inflows {
+			cost, err := value(inflow.Currency, inflow.Amount)
+			if err != nil {
+				return err
+			}
+			e.acquire(tp, trade.tradeID, inflow.Currency, inflow.Amount, cost)
+		}
+	}
+
+	for _, fee := range fees {
+		e.chargeFee(tp, fee.Currency, -fee.Amount)
+	}
+
+	for _, adj := range adjustments {
+		if adj.Amount > 0 {
+			cost := adj.Amount
+			if adj.Currency != e.in {
+				v, err := value(adj.Currency, adj.Amount)
+				if err != nil {
+					return err
+				}
+				cost = v
+			}
+			e.acquire(nil, trade.tradeID, adj.Currency, adj.Amount, cost)
+			continue
+		}
+		if adj.Currency == e.in {
+			e.cash += adj.Amount
+			continue
+		}
+		e.book(adj.Currency).dispose(-adj.Amount)
+	}
+	return nil
+}
+
+func (e *pnlEngine) acquire(tp *TradePnL, tradeID, currency string, qty, cost float64) {
+	if tp != nil {
+		tp.Bought = currency
+		tp.BoughtQuantity += qty
+	}
+	if currency == e.in {
+		e.cash += qty
+		return
+	}
+	e.book(currency).acquire(lot{tradeID: tradeID, qty: qty, cost: cost})
+}
+
+// dispose releases qty of currency for proceeds. Quantity not covered by open lots is
+// assumed to have cost what it fetched, so it realizes nothing.
+func (e *pnlEngine) dispose(tp *TradePnL, currency string, qty, proceeds float64) {
+	if currency == e.in {
+		e.cash -= qty
+		if tp != nil {
+			tp.Sold = currency
+			tp.SoldQuantity += qty
+			tp.Proceeds += proceeds
+			tp.CostBasis += proceeds
+		}
+		return
+	}
+
+	cost, uncovered := e.book(currency).dispose(qty)
+	if uncovered > 0 && qty > 0 {
+		cost += proceeds * uncovered / qty
+	}
+	realized := proceeds - cost
+	e.realized[currency] += realized
+	if tp != nil {
+		tp.Sold = currency
+		tp.SoldQuantity += qty
+		tp.Proceeds += proceeds
+		tp.CostBasis += cost
+		tp.Realized += realized
+	}
+}
+
+func (e *pnlEngine) chargeFee(tp *TradePnL, currency string, qty float64) {
+	cost := qty
+	if currency == e.in {
+		e.cash -= qty
+	} else {
+		cost, _ = e.book(currency).dispose(qty)
+	}
+	e.realized[currency] -= cost
+	if tp != nil {
+		tp.Fees += cost
+		tp.Realized -= cost
+	}
+}
+
+func (e *pnlEngine) report(value valuer) (*PnLReport, error) {
+	report := &PnLReport{
+		In:     e.in,
+		Method: e.method,
+	}
+
+	currencySet := make(map[string]struct{}, len(e.books)+len(e.realized))
+	for c := range e.books {
+		currencySet[c] = struct{}{}
+	}
+	for c := range e.realized {
+		currencySet[c] = struct{}{}
+	}
+	if math.Abs(e.cash) > lotEpsilon {
+		currencySet[e.in] = struct{}{}
+	}
+	currencies := make([]string, 0, len(currencySet))
+	for c := range currencySet {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+
+	for _, c := range currencies {
+		row := CurrencyPnL{Currency: c, Realized: e.realized[c]}
+		if c == e.in {
+			row.Quantity = e.cash
+			row.CostBasis = e.cash
+			row.MarketValue = e.cash
+		} else if b, ok := e.books[c]; ok {
+			row.Quantity = b.quantity()
+			row.CostBasis = b.costBasis()
+			mv, err := value(c, row.Quantity)
+			if err != nil {
+				return nil, err
+			}
+			row.MarketValue = mv
+			row.Unrealized = mv - row.CostBasis
+
+			for _, l := range b.lots {
+				tp, ok := e.tradeByID[l.tradeID]
+				if !ok {
+					continue
+				}
+				lotValue, err := value(c, l.qty)
+				if err != nil {
+					return nil, err
+				}
+				tp.OpenQuantity += l.qty
+				tp.Unrealized += lotValue - l.cost
+			}
+		}
+		report.Realized += row.Realized
+		report.Unrealized += row.Unrealized
+		report.Currencies = append(report.Currencies, row)
+	}
+
+	report.Trades = make([]TradePnL, 0, len(e.trades))
+	for _, tp := range e.trades {
+		report.Trades = append(report.Trades, *tp)
+	}
+	return report, nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func exchangeTrade(id string, at time.Time, from string, fromAmt float64, to string, toAmt float64) *ledgerTrade {
+	return &ledgerTrade{
+		tradeID:    id,
+		executedAt: at,
+		entries: []models.UserLedgerEntry{
+			{TradeID: id, Currency: from, Amount: -fromAmt, ExecutedAt: at, EntryType: models.LedgerEntryExchange},
+			{TradeID: id, Currency: to, Amount: toAmt, ExecutedAt: at, EntryType: models.LedgerEntryExchange},
+		},
+	}
+}
+
+func TestPnLEngineLotMethods(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	trades := []*ledgerTrade{
+		exchangeTrade("t1", t0, "USD", 110, "EUR", 100),
+		exchangeTrade("t2", t0.Add(time.Hour), "USD", 120, "EUR", 100),
+		exchangeTrade("t3", t0.Add(2*time.Hour), "EUR", 150, "USD", 180),
+	}
+	noRates := func(currency string, amount float64) (float64, error) {
+		t.Fatalf("unexpected valuation of %s", currency)
+		return 0, nil
+	}
+	markAt := func(currency string, amount float64) (float64, error) {
+		return amount * 1.3, nil
+	}
+
+	cases := []struct {
+		method     string
+		realized   float64
+		unrealized float64
+	}{
+		{CostMethodFIFO, 10, 5},
+		{CostMethodLIFO, 5, 10},
+		{CostMethodAverage, 7.5, 7.5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method, func(t *testing.T) {
+			engine := newPnLEngine("USD", tc.method)
+			for _, trade := range trades {
+				assert.NoError(t, engine.apply(trade, noRates))
+			}
+			report, err := engine.report(markAt)
+			assert.NoError(t, err)
+			assert.InDelta(t, tc.realized, report.Realized, 1e-9)
+			assert.InDelta(t, tc.unrealized, report.Unrealized, 1e-9)
+			assert.InDelta(t, tc.realized, report.Trades[2].Realized, 1e-9)
+			assert.InDelta(t, tc.unrealized, report.Trades[0].Unrealized+report.Trades[1].Unrealized, 1e-9)
+		})
+	}
+}
+
+func TestPnLEngineFeeRealizesCost(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	trade := exchangeTrade("t1", t0, "USD", 100, "EUR", 90)
+	trade.entries = append(trade.entries, models.UserLedgerEntry{
+		TradeID: "t1", Currency: "USD", Amount: -2, ExecutedAt: t0, EntryType: models.LedgerEntryFee,
+	})
+
+	engine := newPnLEngine("USD", CostMethodFIFO)
+	assert.NoError(t, engine.apply(trade, nil))
+	report, err := engine.report(func(currency string, amount float64) (float64, error) {
+		return amount * 100.0 / 90.0, nil
+	})
+	assert.NoError(t, err)
+	assert.InDelta(t, -2, report.Realized, 1e-9)
+	assert.InDelta(t, 0, report.Unrealized, 1e-9)
+	assert.InDelta(t, 2, report.Trades[0].Fees, 1e-9)
+}
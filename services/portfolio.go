@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"gorm.io/datatypes"
+)
+
+// PortfolioSelector picks the portfolios a ledger or analytics call covers. The zero value is
+// the user's default portfolio. All aggregates every portfolio of Kind (live when empty), so an
+// aggregate view never mixes paper and live balances.
+type PortfolioSelector struct {
+	ID   uint
+	All  bool
+	Kind string
+}
+
+// resolvePortfolio returns the single portfolio a write is scoped to: portfolioID when it
+// belongs to the user, or the user's default portfolio when portfolioID is 0.
+func resolvePortfolio(ctx context.Context, portfolios repository.PortfolioRepository, userID, portfolioID uint) (*models.Portfolio, error) {
+	if portfolios == nil {
+		return nil, fmt.Errorf("portfolios are not configured")
+	}
+	if portfolioID == 0 {
+		return portfolios.GetOrCreateDefault(ctx, userID)
+	}
+	return portfolios.Get(ctx, userID, portfolioID)
+}
+
+// resolvePortfolioIDs expands a selector into the IDs of portfolios the user owns.
+func resolvePortfolioIDs(ctx context.Context, portfolios repository.PortfolioRepository, userID uint, sel PortfolioSelector) ([]uint, error) {
+	if !sel.All {
+		portfolio, err := resolvePortfolio(ctx, portfolios, userID, sel.ID)
+		if err != nil {
+			return nil, err
+		}
+		return []uint{portfolio.ID}, nil
+	}
+
+	kind, err := normalizePortfolioKind(sel.Kind)
+	if err != nil {
+		return nil, err
+	}
+	if kind == models.PortfolioKindLive {
+		if _, err := portfolios.GetOrCreateDefault(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := portfolios.ListByUser(ctx, userID, kind)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(rows))
+	for _, p := range rows {
+		ids = append(ids, p.ID)
+	}
+	return ids, nil
+}
+
+func normalizePortfolioKind(kind string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", models.PortfolioKindLive:
+		return models.PortfolioKindLive, nil
+	case models.PortfolioKindPaper:
+		return models.PortfolioKindPaper, nil
+	default:
+		return "", fmt.Errorf("unsupported portfolio kind; use live or paper")
+	}
+}
+
+type PortfolioService interface {
+	List(ctx context.Context, userID uint) ([]models.Portfolio, error)
+	Get(ctx context.Context, userID, portfolioID uint) (*models.Portfolio, error)
+	Create(ctx context.Context, req *CreatePortfolioRequest) (*models.Portfolio, error)
+	Rename(ctx context.Context, userID, portfolioID uint, name string) (*models.Portfolio, error)
+	Delete(ctx context.Context, userID, portfolioID uint) error
+	DepositPaper(ctx context.Context, req *PaperDepositRequest) (string, error)
+}
+
+type portfolioService struct {
+	repo   repository.PortfolioRepository
+	ledger repository.LedgerRepository
+}
+
+func NewPortfolioService(repo repository.PortfolioRepository, ledger repository.LedgerRepository) PortfolioService {
+	return &portfolioService{
+		repo:   repo,
+		ledger: ledger,
+	}
+}
+
+// CreatePortfolioRequest opens a named portfolio. Paper portfolios must be funded with a
+// virtual deposit of FundingAmount FundingCurrency; live portfolios start empty.
+type CreatePortfolioRequest struct {
+	UserID          uint    `json:"user_id"`
+	Name            string  `json:"name"`
+	Kind            string  `json:"kind"`
+	FundingCurrency string  `json:"funding_currency,omitempty"`
+	FundingAmount   float64 `json:"funding_amount,omitempty"`
+}
+
+type PaperDepositRequest struct {
+	UserID      uint    `json:"user_id"`
+	PortfolioID uint    `json:"portfolio_id"`
+	Currency    string  `json:"currency"`
+	Amount      float64 `json:"amount"`
+}
+
+// List returns the user's portfolios, default first; the default portfolio is created if missing.
+func (s *portfolioService) List(ctx context.Context, userID uint) ([]models.Portfolio, error) {
+	if userID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if _, err := s.repo.GetOrCreateDefault(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByUser(ctx, userID, "")
+}
+
+func (s *portfolioService) Get(ctx context.Context, userID, portfolioID uint) (*models.Portfolio, error) {
+	return s.repo.Get(ctx, userID, portfolioID)
+}
+
+func (s *portfolioService) Create(ctx context.Context, req *CreatePortfolioRequest) (*models.Portfolio, error) {
+	if req.UserID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	kind, err := normalizePortfolioKind(req.Kind)
+	if err != nil {
+		return nil, err
+	}
+	currency := strings.ToUpper(strings.TrimSpace(req.FundingCurrency))
+	switch kind {
+	case models.PortfolioKindPaper:
+		if currency == "" || req.FundingAmount <= 0 {
+			return nil, fmt.Errorf("funding_currency and a positive funding_amount are required for paper portfolios")
+		}
+	case models.PortfolioKindLive:
+		if currency != "" || req.FundingAmount != 0 {
+			return nil, fmt.Errorf("live portfolios cannot be funded with virtual deposits")
+		}
+		// Make sure the user's first portfolio stays the default one.
+		if _, err := s.repo.GetOrCreateDefault(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	portfolio := &models.Portfolio{UserID: req.UserID, Name: name, Kind: kind}
+	if err := s.repo.Create(ctx, portfolio); err != nil {
+		return nil, err
+	}
+	if kind == models.PortfolioKindPaper {
+		if _, err := s.virtualDeposit(ctx, portfolio, currency, req.FundingAmount); err != nil {
+			return nil, err
+		}
+	}
+	return portfolio, nil
+}
+
+func (s *portfolioService) Rename(ctx context.Context, userID, portfolioID uint, name string) (*models.Portfolio, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	return s.repo.Rename(ctx, userID, portfolioID, name)
+}
+
+// Delete removes a portfolio. The default portfolio cannot be deleted; paper portfolios take
+// their practice entries with them, while live portfolios must be empty.
+func (s *portfolioService) Delete(ctx context.Context, userID, portfolioID uint) error {
+	portfolio, err := s.repo.Get(ctx, userID, portfolioID)
+	if err != nil {
+		return err
+	}
+	if portfolio.IsDefault {
+		return fmt.Errorf("the default portfolio cannot be deleted")
+	}
+	return s.repo.Delete(ctx, userID, portfolioID, portfolio.Kind == models.PortfolioKindPaper)
+}
+
+// DepositPaper tops up a paper portfolio. Live portfolios only move through real ledger activity.
+func (s *portfolioService) DepositPaper(ctx context.Context, req *PaperDepositRequest) (string, error) {
+	if req.UserID == 0 {
+		return "", fmt.Errorf("user_id is required")
+	}
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" || req.Amount <= 0 {
+		return "", fmt.Errorf("currency and a positive amount are required")
+	}
+	portfolio, err := s.repo.Get(ctx, req.UserID, req.PortfolioID)
+	if err != nil {
+		return "", err
+	}
+	if portfolio.Kind != models.PortfolioKindPaper {
+		return "", fmt.Errorf("virtual deposits are only allowed on paper portfolios")
+	}
+	return s.virtualDeposit(ctx, portfolio, currency, req.Amount)
+}
+
+// virtualDeposit books an adjustment entry, so returns analytics treat it as an external cash flow.
+func (s *portfolioService) virtualDeposit(ctx context.Context, portfolio *models.Portfolio, currency string, amount float64) (string, error) {
+	tradeID, err := newTradeID()
+	if err != nil {
+		return "", fmt.Errorf("generate trade id: %w", err)
+	}
+	meta, err := json.Marshal(map[string]any{"virtual_deposit": true})
+	if err != nil {
+		return "", fmt.Errorf("marshal meta: %w", err)
+	}
+	entry := models.UserLedgerEntry{
+		UserID:      portfolio.UserID,
+		PortfolioID: portfolio.ID,
+		TradeID:     tradeID,
+		Currency:    currency,
+		Amount:      amount,
+		ExecutedAt:  time.Now().UTC(),
+		EntryType:   models.LedgerEntryAdjustment,
+		Meta:        datatypes.JSON(meta),
+	}
+	if err := s.ledger.Append(ctx, []models.UserLedgerEntry{entry}); err != nil {
+		return "", err
+	}
+	return tradeID, nil
+}
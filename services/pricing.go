@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSpreadBps   = 0
+	defaultMaxQuoteAge = 2 * time.Minute
+)
+
+// CrossRateSource resolves the latest stored cross rate for a pair at a point in time.
+// AnalyticsService satisfies it.
+type CrossRateSource interface {
+	CrossRateAt(ctx context.Context, currencyA, currencyB string, at time.Time) (*CrossPoint, error)
+}
+
+// MarketPricing controls how exchanges are priced off stored rates instead of client amounts.
+type MarketPricing struct {
+	SpreadBps   float64       // markup taken from the mid rate, in basis points
+	MaxQuoteAge time.Duration // snapshots older than this are refused
+}
+
+// MarketPricingFromEnv reads EXCHANGE_SPREAD_BPS and EXCHANGE_MAX_QUOTE_AGE, falling back to defaults.
+func MarketPricingFromEnv() MarketPricing {
+	pricing := MarketPricing{
+		SpreadBps:   defaultSpreadBps,
+		MaxQuoteAge: defaultMaxQuoteAge,
+	}
+	if raw := strings.TrimSpace(os.Getenv("EXCHANGE_SPREAD_BPS")); raw != "" {
+		bps, err := strconv.ParseFloat(raw, 64)
+		if err != nil || bps < 0 {
+			log.Printf("Ignoring invalid EXCHANGE_SPREAD_BPS %q", raw)
+		} else {
+			pricing.SpreadBps = bps
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("EXCHANGE_MAX_QUOTE_AGE")); raw != "" {
+		age, err := time.ParseDuration(raw)
+		if err != nil || age <= 0 {
+			log.Printf("Ignoring invalid EXCHANGE_MAX_QUOTE_AGE %q", raw)
+		} else {
+			pricing.MaxQuoteAge = age
+		}
+	}
+	return pricing
+}
+
+// MarketPrice is the executable rate for one unit of the from currency, in the to currency.
+type MarketPrice struct {
+	From      string
+	To        string
+	MidRate   float64
+	Rate      float64
+	SpreadBps float64
+	QuoteTime time.Time
+	QuoteAge  time.Duration
+}
+
+// Price looks up the stored cross rate at 'at' and applies the spread against the client.
+func (p MarketPricing) Price(ctx context.Context, rates CrossRateSource, from, to string, at time.Time) (*MarketPrice, error) {
+	if rates == nil {
+		return nil, fmt.Errorf("market pricing is not configured")
+	}
+	point, err := rates.CrossRateAt(ctx, from, to, at)
+	if err != nil {
+		return nil, err
+	}
+
+	age := at.Sub(point.Time)
+	if age < 0 {
+		age = 0
+	}
+	if p.MaxQuoteAge > 0 && age > p.MaxQuoteAge {
+		return nil, fmt.Errorf("latest %s/%s rate is stale (age %s exceeds %s)", point.A, point.B, age.Round(time.Second), p.MaxQuoteAge)
+	}
+
+	return &MarketPrice{
+		From:      point.A,
+		To:        point.B,
+		MidRate:   point.Rate,
+		Rate:      point.Rate * (1 - p.SpreadBps/10000),
+		SpreadBps: p.SpreadBps,
+		QuoteTime: point.Time,
+		QuoteAge:  age,
+	}, nil
+}
+
+// Meta describes the price in the shape stored on ledger entries.
+func (m *MarketPrice) Meta() map[string]any {
+	return map[string]any{
+		"mid_rate":          m.MidRate,
+		"rate":              m.Rate,
+		"spread_bps":        m.SpreadBps,
+		"quote_time":        m.QuoteTime,
+		"quote_age_seconds": m.QuoteAge.Seconds(),
+	}
+}
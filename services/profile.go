@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const defaultReportingCurrency = "USD"
+
+// ProfileService backs /me: the caller's profile and preferences, and closing the account.
+type ProfileService interface {
+	Get(ctx context.Context, userID uint) (*models.User, error)
+	Update(ctx context.Context, userID uint, req *ProfileUpdate) (*models.User, error)
+	ReportingCurrency(ctx context.Context, userID uint) (string, error)
+	Delete(ctx context.Context, userID uint, req *DeleteAccountRequest) error
+	Erase(ctx context.Context, userID uint) error
+}
+
+type profileService struct {
+	users         repository.UserRepository
+	refreshTokens repository.RefreshTokenRepository
+	revocations   repository.RevocationRepository
+	mfa           MFAService
+	accounts      AccountService
+	guard         LoginGuard
+	audit         AuditService
+}
+
+func NewProfileService(users repository.UserRepository, refreshTokens repository.RefreshTokenRepository, revocations repository.RevocationRepository, mfa MFAService, accounts AccountService, guard LoginGuard, audit AuditService) ProfileService {
+	return &profileService{
+		users:         users,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		mfa:           mfa,
+		accounts:      accounts,
+		guard:         guard,
+		audit:         audit,
+	}
+}
+
+func (s *profileService) reauth() reauthenticator {
+	return reauthenticator{guard: s.guard, mfa: s.mfa, audit: s.audit}
+}
+
+// ProfileUpdate is a partial update: omitted fields are left alone. Changing the email needs
+// the current password and leaves the new address unverified until its link is followed.
+type ProfileUpdate struct {
+	Name              *string `json:"name"`
+	Email             *string `json:"email"`
+	ReportingCurrency *string `json:"reporting_currency"`
+	Timezone          *string `json:"timezone"`
+	CurrentPassword   string  `json:"current_password"`
+}
+
+// DeleteAccountRequest re-authenticates with the password and, when enabled, a second factor.
+type DeleteAccountRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func (s *profileService) Get(ctx context.Context, userID uint) (*models.User, error) {
+	return s.users.FindByID(ctx, userID)
+}
+
+func (s *profileService) Update(ctx context.Context, userID uint, req *ProfileUpdate) (*models.User, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("name is required")
+		}
+		user.Name = name
+	}
+	if req.ReportingCurrency != nil {
+		currency, err := normalizeReportingCurrency(*req.ReportingCurrency)
+		if err != nil {
+			return nil, err
+		}
+		user.ReportingCurrency = currency
+	}
+	if req.Timezone != nil {
+		zone, err := normalizeTimezone(*req.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		user.Timezone = zone
+	}
+
+	emailChanged := false
+	if req.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*req.Email))
+		if email != user.Email {
+			if !validEmail(email) {
+				return nil, fmt.Errorf("invalid email format")
+			}
+			reauth := s.reauth()
+			if err := reauth.password(ctx, user, req.CurrentPassword); err != nil {
+				return nil, err
+			}
+			reauth.passed(ctx, user)
+			existing, err := s.users.FindByEmail(ctx, email)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check existing user: %v", err)
+			}
+			if existing != nil {
+				return nil, fmt.Errorf("email is not available")
+			}
+			user.Email = email
+			user.EmailVerifiedAt = nil
+			emailChanged = true
+		}
+	}
+
+	if err := s.users.UpdateProfile(ctx, user); err != nil {
+		return nil, err
+	}
+	if emailChanged {
+		s.audit.Record(ctx, models.AuthEventEmailChanged, user.ID, user.Email, "")
+		if err := s.accounts.SendVerification(ctx, user.ID); err != nil {
+			log.Printf("⚠️  Failed to send verification email to %s: %v", user.Email, err)
+		}
+	}
+	return user, nil
+}
+
+// ReportingCurrency is the user's default valuation currency for analytics.
+func (s *profileService) ReportingCurrency(ctx context.Context, userID uint) (string, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user.ReportingCurrency == "" {
+		return defaultReportingCurrency, nil
+	}
+	return user.ReportingCurrency, nil
+}
+
+// Delete closes the caller's own account after re-checking the password and second factor.
+func (s *profileService) Delete(ctx context.Context, userID uint, req *DeleteAccountRequest) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	reauth := s.reauth()
+	if err := reauth.password(ctx, user, req.Password); err != nil {
+		return err
+	}
+	if err := reauth.secondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+	reauth.passed(ctx, user)
+	return s.Erase(ctx, userID)
+}
+
+// Erase signs the user out everywhere and removes the account with everything it owns; see
+// UserRepository.DeleteAccount for what is deleted and what is anonymized. It does no
+// re-authentication and is shared with the admin API.
+func (s *profileService) Erase(ctx context.Context, userID uint) error {
+	sessions, err := revokeSessions(ctx, s.refreshTokens, s.revocations, userID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	// Recorded first so the cascade anonymizes it along with the rest of the trail.
+	s.audit.Record(ctx, models.AuthEventAccountDeleted, userID, "", fmt.Sprintf("revoked %d sessions", sessions))
+	if err := s.users.DeleteAccount(ctx, userID); err != nil {
+		return err
+	}
+	log.Printf("🗑️  Deleted account %d; revoked %d sessions", userID, sessions)
+	return nil
+}
+
+func normalizeReportingCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 {
+		return "", fmt.Errorf("reporting_currency must be a 3-letter currency code")
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("reporting_currency must be a 3-letter currency code")
+		}
+	}
+	return currency, nil
+}
+
+// normalizeTimezone accepts IANA zone names such as Europe/London.
+func normalizeTimezone(zone string) (string, error) {
+	zone = strings.TrimSpace(zone)
+	if zone == "" || strings.EqualFold(zone, "local") {
+		return "", fmt.Errorf("timezone must be an IANA zone name such as Europe/London")
+	}
+	if _, err := time.LoadLocation(zone); err != nil {
+		return "", fmt.Errorf("timezone must be an IANA zone name such as Europe/London")
+	}
+	return zone, nil
+}
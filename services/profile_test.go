@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeUserRepo) UpdateProfile(ctx context.Context, user *models.User) error {
+	if _, ok := f.users[user.ID]; !ok {
+		return repository.ErrUserNotFound
+	}
+	copied := *user
+	f.users[user.ID] = &copied
+	return nil
+}
+
+func TestProfileUpdateValidatesAndReverifiesEmail(t *testing.T) {
+	now := time.Now()
+	user := &models.User{Email: "a@example.com", Name: "A", ReportingCurrency: "USD", Timezone: "UTC", EmailVerifiedAt: &now}
+	user.ID = 7
+	assert.NoError(t, user.HashPassword("password"))
+	other := &models.User{Email: "taken@example.com"}
+	other.ID = 8
+	users := &fakeUserRepo{users: map[uint]*models.User{7: user, 8: other}}
+	mail := &fakeMailer{}
+	accounts := NewAccountService(users, &fakeAccountTokens{}, &fakeRefreshTokenRepo{}, fakeRevocations{}, mail, &fakeAudit{})
+	svc := NewProfileService(users, &fakeRefreshTokenRepo{}, fakeRevocations{}, nil, accounts, newTestLoginGuard(5), &fakeAudit{})
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+
+	_, err := svc.Update(ctx, 7, &ProfileUpdate{ReportingCurrency: str("euro")})
+	assert.Error(t, err)
+	_, err = svc.Update(ctx, 7, &ProfileUpdate{Timezone: str("Mars/Olympus")})
+	assert.Error(t, err)
+
+	updated, err := svc.Update(ctx, 7, &ProfileUpdate{ReportingCurrency: str(" eur "), Timezone: str("Europe/London")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "EUR", updated.ReportingCurrency)
+	assert.Equal(t, "Europe/London", updated.Timezone)
+	currency, err := svc.ReportingCurrency(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", currency)
+
+	// Changing the email needs the password, a free address, and a fresh verification.
+	_, err = svc.Update(ctx, 7, &ProfileUpdate{Email: str("new@example.com"), CurrentPassword: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.Update(ctx, 7, &ProfileUpdate{Email: str("taken@example.com"), CurrentPassword: "password"})
+	assert.Error(t, err)
+	updated, err = svc.Update(ctx, 7, &ProfileUpdate{Email: str("New@Example.com"), CurrentPassword: "password"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "new@example.com", updated.Email)
+	assert.Nil(t, updated.EmailVerifiedAt)
+	if assert.Len(t, mail.sent, 1) {
+		assert.Equal(t, "new@example.com", mail.sent[0].To)
+	}
+}
+
+func TestDeleteAccountReauthenticatesAndRevokesSessions(t *testing.T) {
+	user := &models.User{Email: "a@example.com"}
+	user.ID = 7
+	assert.NoError(t, user.HashPassword("password"))
+	users := &fakeUserRepo{users: map[uint]*models.User{7: user}}
+	refresh := &fakeRefreshTokenRepo{}
+	revoked := fakeRevocations{}
+	svc := NewProfileService(users, refresh, revoked, NewMFAService(users, nil, nil, nil), nil, newTestLoginGuard(5), &fakeAudit{})
+	ctx := context.Background()
+
+	assert.NoError(t, refresh.Create(ctx, &models.RefreshToken{UserID: 7, AccessJTI: "live", AccessExpiresAt: time.Now().Add(time.Minute)}))
+
+	assert.ErrorIs(t, svc.Delete(ctx, 7, &DeleteAccountRequest{Password: "wrong"}), ErrInvalidCredentials)
+	assert.Contains(t, users.users, uint(7))
+	assert.False(t, revoked["live"])
+
+	assert.NoError(t, svc.Delete(ctx, 7, &DeleteAccountRequest{Password: "password"}))
+	assert.NotContains(t, users.users, uint(7))
+	assert.True(t, revoked["live"])
+	assert.NotNil(t, refresh.tokens[0].RevokedAt)
+}
+
+func TestDeleteAccountThrottlesPasswordGuessesAndNeedsTheSecondFactor(t *testing.T) {
+	user := &models.User{Email: "a@example.com", TOTPEnabled: true, TOTPSecret: "JBSWY3DPEHPK3PXP"}
+	user.ID = 7
+	assert.NoError(t, user.HashPassword("password"))
+	users := &fakeUserRepo{users: map[uint]*models.User{7: user}}
+	guard := newTestLoginGuard(3)
+	audit := &fakeAudit{}
+	now := time.Now()
+	mfa := NewMFAService(users, fakeRecoveryCodes{}, nil, newFakeLoginAttempts(&now))
+	svc := NewProfileService(users, &fakeRefreshTokenRepo{}, fakeRevocations{}, mfa, nil, guard, audit)
+	ctx := auth.WithClient(context.Background(), auth.Client{IP: "203.0.113.7"})
+
+	// The right password alone is not enough, and the wrong code counts as a failure.
+	assert.ErrorIs(t, svc.Delete(ctx, 7, &DeleteAccountRequest{Password: "password", Code: "000000"}), ErrInvalidMFACode)
+	assert.Contains(t, audit.events, models.AuthEventMFAFailure)
+	assert.Contains(t, users.users, uint(7))
+
+	assert.ErrorIs(t, svc.Delete(ctx, 7, &DeleteAccountRequest{Password: "wrong"}), ErrInvalidCredentials)
+	assert.Contains(t, audit.events, models.AuthEventLoginFailure)
+	err := svc.Delete(ctx, 7, &DeleteAccountRequest{Password: "wrong"})
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.Contains(t, audit.events, models.AuthEventLockout)
+	assert.Contains(t, users.users, uint(7))
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const defaultQuoteLock = 15 * time.Second
+
+type QuoteService interface {
+	CreateQuote(ctx context.Context, req *QuoteRequest) (*models.Quote, error)
+}
+
+type quoteService struct {
+	repo    repository.QuoteRepository
+	rates   CrossRateSource
+	pricing MarketPricing
+	lock    time.Duration
+}
+
+// NewQuoteService builds quotes off the same market pricing used for market-mode exchanges.
+// QUOTE_LOCK_SECONDS sets how long a quote stays executable.
+func NewQuoteService(repo repository.QuoteRepository, rates CrossRateSource, pricing MarketPricing) QuoteService {
+	lock := defaultQuoteLock
+	if raw := strings.TrimSpace(os.Getenv("QUOTE_LOCK_SECONDS")); raw != "" {
+		secs, err := strconv.Atoi(raw)
+		if err != nil || secs <= 0 {
+			log.Printf("Ignoring invalid QUOTE_LOCK_SECONDS %q", raw)
+		} else {
+			lock = time.Duration(secs) * time.Second
+		}
+	}
+	return &quoteService{
+		repo:    repo,
+		rates:   rates,
+		pricing: pricing,
+		lock:    lock,
+	}
+}
+
+type QuoteRequest struct {
+	UserID       uint    `json:"user_id"`
+	FromCurrency string  `json:"from_currency"`
+	FromAmount   float64 `json:"from_amount,omitempty"`
+	ToCurrency   string  `json:"to_currency"`
+	ToAmount     float64 `json:"to_amount,omitempty"`
+}
+
+func (s *quoteService) CreateQuote(ctx context.Context, req *QuoteRequest) (*models.Quote, error) {
+	if req.UserID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	from := strings.ToUpper(strings.TrimSpace(req.FromCurrency))
+	to := strings.ToUpper(strings.TrimSpace(req.ToCurrency))
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("from_currency and to_currency are required")
+	}
+	if req.FromAmount < 0 || req.ToAmount < 0 {
+		return nil, fmt.Errorf("amounts must be positive values")
+	}
+	if (req.FromAmount > 0) == (req.ToAmount > 0) {
+		return nil, fmt.Errorf("quote takes exactly one of from_amount or to_amount")
+	}
+
+	now := time.Now().UTC()
+	price, err := s.pricing.Price(ctx, s.rates, from, to, now)
+	if err != nil {
+		return nil, fmt.Errorf("price quote: %w", err)
+	}
+	if price.Rate <= 0 {
+		return nil, fmt.Errorf("price quote: non-positive rate for %s/%s", from, to)
+	}
+
+	id, err := newTradeID()
+	if err != nil {
+		return nil, fmt.Errorf("generate quote id: %w", err)
+	}
+
+	quote := &models.Quote{
+		ID:           id,
+		UserID:       req.UserID,
+		FromCurrency: from,
+		FromAmount:   req.FromAmount,
+		ToCurrency:   to,
+		ToAmount:     req.ToAmount,
+		MidRate:      price.MidRate,
+		Rate:         price.Rate,
+		SpreadBps:    price.SpreadBps,
+		QuoteTime:    price.QuoteTime,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(s.lock),
+	}
+	if quote.FromAmount > 0 {
+		quote.ToAmount = quote.FromAmount * quote.Rate
+	} else {
+		quote.FromAmount = quote.ToAmount / quote.Rate
+	}
+
+	if err := s.repo.Store(ctx, quote); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
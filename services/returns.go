@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Return periods accepted by PortfolioReturns.
+const (
+	ReturnPeriodDay   = "day"
+	ReturnPeriodMonth = "month"
+	ReturnPeriodYear  = "year"
+)
+
+// maxValuationSamples caps the valuation points behind returns and risk metrics.
+// sampledValuationSeries asks for one more to tell a range that fits from one that does not,
+// which keeps the query within the repository's 5000-row limit.
+const maxValuationSamples = 4999
+
+// ReturnPeriod holds time-weighted and money-weighted returns between two valuation points.
+// Both are plain period returns (not annualized). MWR is nil when no IRR solves the flows.
+type ReturnPeriod struct {
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	StartValue float64   `json:"start_value"`
+	EndValue   float64   `json:"end_value"`
+	NetFlows   float64   `json:"net_flows"`
+	TWR        float64   `json:"twr"`
+	MWR        *float64  `json:"mwr"`
+}
+
+type PortfolioReturns struct {
+	In      string         `json:"in"`
+	Period  string         `json:"period"`
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Total   ReturnPeriod   `json:"total"`
+	Periods []ReturnPeriod `json:"periods"`
+}
+
+// PortfolioReturns computes TWR and MWR over [from,to], broken down per day, month or year (UTC).
+// Cash flows are the ledger's adjustment entries; exchanges and fees are part of performance.
+func (s *analyticsService) PortfolioReturns(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency string, from, to time.Time, period string) (*PortfolioReturns, error) {
+	if userID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+	in := strings.ToUpper(strings.TrimSpace(inCurrency))
+	if in == "" {
+		in = "USD"
+	}
+	period, err := normalizeReturnPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hourly samples let daily periods see intraday flows; coarser periods only need daily closes.
+	sampling := "1 day"
+	if period == ReturnPeriodDay {
+		sampling = "1 hour"
+	}
+	portfolioIDs, err := resolvePortfolioIDs(ctx, s.portfolios, userID, portfolios)
+	if err != nil {
+		return nil, err
+	}
+	points, err := s.sampledValuationSeries(ctx, userID, portfolioIDs, in, from, to, sampling)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) < 2 {
+		return nil, fmt.Errorf("not enough valuation points to compute returns")
+	}
+
+	total, periods := computeReturns(points, period)
+	return &PortfolioReturns{
+		In:      in,
+		Period:  period,
+		From:    from,
+		To:      to,
+		Total:   total,
+		Periods: periods,
+	}, nil
+}
+
+// sampledValuationSeries values the portfolio at the last snapshot of each bucket in [from,to].
+// A range holding more than maxValuationSamples buckets is refused rather than silently cut
+// short, since the caller reports its results for the whole range.
+func (s *analyticsService) sampledValuationSeries(ctx context.Context, userID uint, portfolioIDs []uint, in string, from, to time.Time, bucketInterval string) ([]valuationPoint, error) {
+	times, err := s.currencyRepo.ListBucketedSnapshotTimes(ctx, bucketInterval, &from, &to, maxValuationSamples+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no price snapshots in requested time range")
+	}
+	if len(times) > maxValuationSamples {
+		return nil, fmt.Errorf("the requested range holds more than %d %s samples; narrow it or use a coarser period", maxValuationSamples, bucketInterval)
+	}
+	return s.valuationSeries(ctx, userID, portfolioIDs, in, from, to, times)
+}
+
+func normalizeReturnPeriod(period string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(period)) {
+	case "", ReturnPeriodMonth, "monthly":
+		return ReturnPeriodMonth, nil
+	case ReturnPeriodDay, "daily":
+		return ReturnPeriodDay, nil
+	case ReturnPeriodYear, "yearly":
+		return ReturnPeriodYear, nil
+	default:
+		return "", fmt.Errorf("unsupported period; use one of: day, month, year")
+	}
+}
+
+func periodKey(t time.Time, period string) string {
+	t = t.UTC()
+	switch period {
+	case ReturnPeriodDay:
+		return t.Format("2006-01-02")
+	case ReturnPeriodYear:
+		return t.Format("2006")
+	default:
+		return t.Format("2006-01")
+	}
+}
+
+// subPeriodReturns returns the flow-adjusted return between consecutive points. Flows are
+// assumed to arrive just before the valuation they are reported with.
+func subPeriodReturns(points []valuationPoint) []float64 {
+	if len(points) < 2 {
+		return nil
+	}
+	out := make([]float64, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		prev := points[i-1].Value
+		if math.Abs(prev) < lotEpsilon {
+			// Nothing was invested over this interval, so it contributes no performance.
+			out = append(out, 0)
+			continue
+		}
+		out = append(out, (points[i].Value-points[i].Flow)/prev-1)
+	}
+	return out
+}
+
+// computeReturns chains sub-period returns into the whole range and into calendar periods.
+// A sub-period belongs to the calendar period its end point falls in.
+func computeReturns(points []valuationPoint, period string) (ReturnPeriod, []ReturnPeriod) {
+	subReturns := subPeriodReturns(points)
+	total := summarizeReturns(points, subReturns, 0, len(points)-1)
+
+	var periods []ReturnPeriod
+	startIdx := 0
+	for i := 1; i < len(points); i++ {
+		last := i == len(points)-1
+		if last || periodKey(points[i+1].Time, period) != periodKey(points[i].Time, period) {
+			periods = append(periods, summarizeReturns(points, subReturns, startIdx, i))
+			startIdx = i
+		}
+	}
+	return total, periods
+}
+
+// summarizeReturns covers points[startIdx..endIdx]; points[startIdx] is the opening valuation.
+func summarizeReturns(points []valuationPoint, subReturns []float64, startIdx, endIdx int) ReturnPeriod {
+	start := points[startIdx]
+	end := points[endIdx]
+	rp := ReturnPeriod{
+		Start:      start.Time,
+		End:        end.Time,
+		StartValue: start.Value,
+		EndValue:   end.Value,
+	}
+
+	growth := 1.0
+	flows := make([]datedFlow, 0, endIdx-startIdx)
+	for i := startIdx + 1; i <= endIdx; i++ {
+		growth *= 1 + subReturns[i-1]
+		rp.NetFlows += points[i].Flow
+		if points[i].Flow != 0 {
+			flows = append(flows, datedFlow{at: points[i].Time, amount: points[i].Flow})
+		}
+	}
+	rp.TWR = growth - 1
+
+	if mwr, ok := moneyWeightedReturn(start.Value, end.Value, start.Time, end.Time, flows); ok {
+		rp.MWR = &mwr
+	}
+	return rp
+}
+
+type datedFlow struct {
+	at     time.Time
+	amount float64
+}
+
+// moneyWeightedReturn solves for the period IRR r such that the opening value and each flow,
+// compounded at r for the fraction of the period it was invested, grow into the closing value.
+func moneyWeightedReturn(startValue, endValue float64, start, end time.Time, flows []datedFlow) (float64, bool) {
+	span := end.Sub(start).Seconds()
+	if span <= 0 {
+		return 0, false
+	}
+	f := func(r float64) float64 {
+		total := startValue * (1 + r)
+		for _, fl := range flows {
+			w := end.Sub(fl.at).Seconds() / span
+			total += fl.amount * math.Pow(1+r, w)
+		}
+		return total - endValue
+	}
+
+	lo, hi := -0.9999, 10.0
+	fLo, fHi := f(lo), f(hi)
+	if math.IsNaN(fLo) || math.IsNaN(fHi) || fLo*fHi > 0 {
+		return 0, false
+	}
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		fMid := f(mid)
+		if math.Abs(fMid) < 1e-10 || (hi-lo)/2 < 1e-12 {
+			return mid, true
+		}
+		if fLo*fMid < 0 {
+			hi = mid
+		} else {
+			lo, fLo = mid, fMid
+		}
+	}
+	return (lo + hi) / 2, true
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func point(t time.Time, value, flow float64) valuationPoint {
+	return valuationPoint{PortfolioValue: PortfolioValue{Time: t, In: "USD", Value: value}, Flow: flow}
+}
+
+func TestComputeReturnsIgnoresDeposits(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2025, 1, day, 23, 0, 0, 0, time.UTC) }
+	points := []valuationPoint{
+		point(d(1), 100, 0),
+		point(d(2), 110, 0),     // +10%
+		point(d(3), 1110, 1000), // deposit, flat performance
+		point(d(4), 1221, 0),    // +10%
+	}
+
+	total, periods := computeReturns(points, ReturnPeriodDay)
+	assert.InDelta(t, 0.21, total.TWR, 1e-9)
+	assert.InDelta(t, 1000, total.NetFlows, 1e-9)
+	assert.Len(t, periods, 3)
+	assert.InDelta(t, 0.10, periods[0].TWR, 1e-9)
+	assert.InDelta(t, 0, periods[1].TWR, 1e-9)
+	assert.InDelta(t, 0.10, periods[2].TWR, 1e-9)
+
+	// Most of the money was invested for the second gain only, so MWR rewards it above TWR.
+	if assert.NotNil(t, total.MWR) {
+		assert.Greater(t, *total.MWR, total.TWR)
+	}
+}
+
+func TestMoneyWeightedReturnWithoutFlowsMatchesSimpleReturn(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mwr, ok := moneyWeightedReturn(100, 105, start, start.Add(24*time.Hour), nil)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.05, mwr, 1e-9)
+}
+
+// fakeSnapshotTimes holds one snapshot per hour for buckets hours and returns as many of them
+// as the limit allows, as the repository does.
+type fakeSnapshotTimes struct {
+	repository.CurrencyRepository
+	buckets int
+	limit   int
+}
+
+func (f *fakeSnapshotTimes) ListBucketedSnapshotTimes(ctx context.Context, bucketInterval string, from, to *time.Time, limit int) ([]time.Time, error) {
+	f.limit = limit
+	times := make([]time.Time, min(f.buckets, limit))
+	for i := range times {
+		times[i] = from.Add(time.Duration(i) * time.Hour)
+	}
+	return times, nil
+}
+
+func (f *fakeSnapshotTimes) ListRatesAtTimes(ctx context.Context, tickers []string, times []time.Time) ([]models.Currency, error) {
+	rows := make([]models.Currency, len(times))
+	for i, t := range times {
+		rows[i] = models.Currency{Ticker: "USD", Base: "USD", Rate: 1, FetchedTime: t}
+	}
+	return rows, nil
+}
+
+// fakeCashLedger holds a constant USD balance.
+type fakeCashLedger struct {
+	repository.LedgerRepository
+}
+
+func (fakeCashLedger) BalancesBefore(ctx context.Context, userID uint, portfolioIDs []uint, before time.Time) (map[string]float64, error) {
+	return map[string]float64{"USD": 100}, nil
+}
+
+func (fakeCashLedger) ListByUserBetween(ctx context.Context, userID uint, portfolioIDs []uint, from, to time.Time) ([]models.UserLedgerEntry, error) {
+	return nil, nil
+}
+
+func TestSampledValuationSeriesRefusesRangesOverTheCap(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+	ctx := context.Background()
+
+	repo := &fakeSnapshotTimes{buckets: maxValuationSamples}
+	svc := &analyticsService{currencyRepo: repo, ledgerRepo: fakeCashLedger{}}
+	points, err := svc.sampledValuationSeries(ctx, 7, nil, "USD", from, to, "1 hour")
+	if assert.NoError(t, err) {
+		assert.Len(t, points, maxValuationSamples)
+	}
+	assert.Equal(t, maxValuationSamples+1, repo.limit)
+
+	repo.buckets = maxValuationSamples + 1
+	_, err = svc.sampledValuationSeries(ctx, 7, nil, "USD", from, to, "1 hour")
+	assert.ErrorContains(t, err, "narrow it")
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+var defaultConfidenceLevels = []float64{0.95, 0.99}
+
+// RiskOptions tunes PortfolioRisk. Interval is a candle bucket (1h, 4h, 1d, ...) used to sample
+// the valuation series; RiskFreeRate is annual, e.g. 0.04 for 4%.
+type RiskOptions struct {
+	Interval         string
+	RiskFreeRate     float64
+	ConfidenceLevels []float64
+}
+
+type Drawdown struct {
+	MaxDrawdown float64    `json:"max_drawdown"` // fraction of the peak, positive
+	Peak        time.Time  `json:"peak"`
+	Trough      time.Time  `json:"trough"`
+	Recovery    *time.Time `json:"recovery,omitempty"` // first return to the peak, if any
+}
+
+// ValueAtRisk is the loss over one sampling interval not exceeded with the given confidence.
+// Fractions are positive losses; amounts apply them to the latest portfolio value.
+type ValueAtRisk struct {
+	Confidence         float64 `json:"confidence"`
+	HistoricalVaR      float64 `json:"historical_var"`
+	HistoricalCVaR     float64 `json:"historical_cvar"`
+	ParametricVaR      float64 `json:"parametric_var"`
+	ParametricCVaR     float64 `json:"parametric_cvar"`
+	HistoricalVaRValue float64 `json:"historical_var_value"`
+	ParametricVaRValue float64 `json:"parametric_var_value"`
+}
+
+type PortfolioRisk struct {
+	In                   string        `json:"in"`
+	From                 time.Time     `json:"from"`
+	To                   time.Time     `json:"to"`
+	Interval             string        `json:"interval"`
+	Samples              int           `json:"samples"`
+	LatestValue          float64       `json:"latest_value"`
+	AnnualizedReturn     float64       `json:"annualized_return"`
+	AnnualizedVolatility float64       `json:"annualized_volatility"`
+	RiskFreeRate         float64       `json:"risk_free_rate"`
+	Sharpe               *float64      `json:"sharpe"`
+	Sortino              *float64      `json:"sortino"`
+	Drawdown             Drawdown      `json:"drawdown"`
+	VaR                  []ValueAtRisk `json:"var"`
+}
+
+// PortfolioRisk computes volatility, drawdown, Sharpe/Sortino and VaR/CVaR from the flow-adjusted
+// returns of the portfolio valuation series, so deposits and withdrawals do not read as gains or losses.
+func (s *analyticsService) PortfolioRisk(ctx context.Context, userID uint, portfolios PortfolioSelector, inCurrency string, from, to time.Time, opts RiskOptions) (*PortfolioRisk, error) {
+	if userID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+	in := strings.ToUpper(strings.TrimSpace(inCurrency))
+	if in == "" {
+		in = "USD"
+	}
+	if strings.TrimSpace(opts.Interval) == "" {
+		opts.Interval = "1d"
+	}
+	step, err := parseIntervalDuration(opts.Interval)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := normalizeCandleBucket(opts.Interval)
+	if err != nil {
+		return nil, err
+	}
+	// Checked before anything is loaded; sampledValuationSeries enforces the exact count.
+	if to.Sub(from)/step >= maxValuationSamples {
+		return nil, fmt.Errorf("the requested range spans more than %d %s intervals; narrow it or use a larger interval", maxValuationSamples, opts.Interval)
+	}
+	levels := opts.ConfidenceLevels
+	if len(levels) == 0 {
+		levels = defaultConfidenceLevels
+	}
+	for _, c := range levels {
+		if c <= 0.5 || c >= 1 {
+			return nil, fmt.Errorf("confidence levels must be between 0.5 and 1")
+		}
+	}
+
+	portfolioIDs, err := resolvePortfolioIDs(ctx, s.portfolios, userID, portfolios)
+	if err != nil {
+		return nil, err
+	}
+	points, err := s.sampledValuationSeries(ctx, userID, portfolioIDs, in, from, to, bucket)
+	if err != nil {
+		return nil, err
+	}
+	returns := subPeriodReturns(points)
+	if len(returns) < 3 {
+		return nil, fmt.Errorf("not enough valuation points to compute risk metrics")
+	}
+
+	periodsPerYear := float64(365*24*time.Hour) / float64(step)
+	mean, std := meanStdDev(returns)
+	rfPerPeriod := opts.RiskFreeRate / periodsPerYear
+
+	res := &PortfolioRisk{
+		In:                   in,
+		From:                 from,
+		To:                   to,
+		Interval:             strings.ToLower(strings.TrimSpace(opts.Interval)),
+		Samples:              len(returns),
+		LatestValue:          points[len(points)-1].Value,
+		AnnualizedReturn:     mean * periodsPerYear,
+		AnnualizedVolatility: std * math.Sqrt(periodsPerYear),
+		RiskFreeRate:         opts.RiskFreeRate,
+		Drawdown:             maxDrawdown(points, returns),
+	}
+	if std > 0 {
+		sharpe := (mean - rfPerPeriod) / std * math.Sqrt(periodsPerYear)
+		res.Sharpe = &sharpe
+	}
+	if dd := downsideDeviation(returns, rfPerPeriod); dd > 0 {
+		sortino := (mean - rfPerPeriod) / dd * math.Sqrt(periodsPerYear)
+		res.Sortino = &sortino
+	}
+	for _, c := range levels {
+		v := valueAtRisk(returns, c)
+		v.HistoricalVaRValue = v.HistoricalVaR * res.LatestValue
+		v.ParametricVaRValue = v.ParametricVaR * res.LatestValue
+		res.VaR = append(res.VaR, v)
+	}
+	return res, nil
+}
+
+// parseIntervalDuration mirrors the candle buckets accepted by normalizeCandleBucket.
+func parseIntervalDuration(raw string) (time.Duration, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "1m":
+		return time.Minute, nil
+	case "5m":
+		return 5 * time.Minute, nil
+	case "15m":
+		return 15 * time.Minute, nil
+	case "30m":
+		return 30 * time.Minute, nil
+	case "1h":
+		return time.Hour, nil
+	case "4h":
+		return 4 * time.Hour, nil
+	case "1d":
+		return 24 * time.Hour, nil
+	case "1w":
+		return 7 * 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("unsupported bucket; use one of: 1m, 5m, 15m, 30m, 1h, 4h, 1d, 1w")
+	}
+}
+
+// meanStdDev returns the mean and sample standard deviation.
+func meanStdDev(xs []float64) (float64, float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	mean := 0.0
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	if len(xs) < 2 {
+		return mean, 0
+	}
+	ss := 0.0
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(xs)-1))
+}
+
+func downsideDeviation(returns []float64, target float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	ss := 0.0
+	for _, r := range returns {
+		if d := r - target; d < 0 {
+			ss += d * d
+		}
+	}
+	return math.Sqrt(ss / float64(len(returns)))
+}
+
+// maxDrawdown walks the wealth index built from returns; points[i+1] is the end of returns[i].
+func maxDrawdown(points []valuationPoint, returns []float64) Drawdown {
+	var out Drawdown
+	if len(points) == 0 {
+		return out
+	}
+	wealth := 1.0
+	peak := 1.0
+	peakTime := points[0].Time
+	var (
+		worstPeak   time.Time
+		worstTrough time.Time
+		worstPeakW  float64
+	)
+	for i, r := range returns {
+		wealth *= 1 + r
+		t := points[i+1].Time
+		if wealth >= peak {
+			peak = wealth
+			peakTime = t
+			continue
+		}
+		if dd := (peak - wealth) / peak; dd > out.MaxDrawdown {
+			out.MaxDrawdown = dd
+			worstPeak = peakTime
+			worstTrough = t
+			worstPeakW = peak
+		}
+	}
+	if out.MaxDrawdown == 0 {
+		out.Peak = peakTime
+		out.Trough = peakTime
+		return out
+	}
+	out.Peak = worstPeak
+	out.Trough = worstTrough
+
+	// Find the first time after the trough where the wealth index is back at the peak.
+	wealth = 1.0
+	for i, r := range returns {
+		wealth *= 1 + r
+		t := points[i+1].Time
+		if t.After(worstTrough) && wealth >= worstPeakW {
+			recovered := t
+			out.Recovery = &recovered
+			break
+		}
+	}
+	return out
+}
+
+// valueAtRisk computes historical and normal-parametric VaR/CVaR for one confidence level.
+func valueAtRisk(returns []float64, confidence float64) ValueAtRisk {
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+
+	tail := 1 - confidence
+	idx := int(math.Floor(tail * float64(len(sorted))))
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	histVaR := -sorted[idx]
+	tailSum := 0.0
+	for i := 0; i <= idx; i++ {
+		tailSum += sorted[i]
+	}
+	histCVaR := -tailSum / float64(idx+1)
+
+	mean, std := meanStdDev(returns)
+	z := normalQuantile(confidence)
+	paramVaR := -(mean - z*std)
+	paramCVaR := -(mean - std*normalPDF(z)/tail)
+
+	return ValueAtRisk{
+		Confidence:     confidence,
+		HistoricalVaR:  histVaR,
+		HistoricalCVaR: histCVaR,
+		ParametricVaR:  paramVaR,
+		ParametricCVaR: paramCVaR,
+	}
+}
+
+func normalPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+// normalQuantile is the inverse standard normal CDF.
+func normalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxDrawdownFindsPeakTroughAndRecovery(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2025, 3, day, 0, 0, 0, 0, time.UTC) }
+	points := []valuationPoint{
+		point(d(1), 100, 0),
+		point(d(2), 120, 0),
+		point(d(3), 90, 0),
+		point(d(4), 96, 0),
+		point(d(5), 125, 0),
+	}
+	dd := maxDrawdown(points, subPeriodReturns(points))
+	assert.InDelta(t, 0.25, dd.MaxDrawdown, 1e-9)
+	assert.Equal(t, d(2), dd.Peak)
+	assert.Equal(t, d(3), dd.Trough)
+	if assert.NotNil(t, dd.Recovery) {
+		assert.Equal(t, d(5), *dd.Recovery)
+	}
+}
+
+func TestValueAtRiskHistoricalTail(t *testing.T) {
+	returns := make([]float64, 0, 100)
+	for i := 1; i <= 100; i++ {
+		returns = append(returns, float64(i-50)/1000) // -0.049 .. 0.050
+	}
+	v := valueAtRisk(returns, 0.95)
+	assert.InDelta(t, 0.044, v.HistoricalVaR, 1e-9)
+	assert.InDelta(t, 0.0465, v.HistoricalCVaR, 1e-9)
+	assert.Greater(t, v.ParametricCVaR, v.ParametricVaR)
+	assert.InDelta(t, 1.6448536, normalQuantile(0.95), 1e-6)
+}
+
+func TestPortfolioRiskRejectsRangesOverTheSampleCap(t *testing.T) {
+	svc := &analyticsService{}
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		interval string
+		to       time.Time
+	}{
+		{"1m", from.Add(4 * 24 * time.Hour)},
+		{"15m", from.AddDate(0, 0, 60)},
+		{"1d", from.AddDate(14, 0, 0)},
+	}
+	for _, tt := range tests {
+		_, err := svc.PortfolioRisk(context.Background(), 7, PortfolioSelector{}, "USD", from, tt.to, RiskOptions{Interval: tt.interval})
+		assert.ErrorContains(t, err, "narrow it", tt.interval)
+	}
+}
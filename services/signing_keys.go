@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const (
+	defaultKeyRotation = 30 * 24 * time.Hour
+	// keyReloadInterval limits reloads triggered by tokens signed with a kid this instance has
+	// not seen yet.
+	keyReloadInterval = 10 * time.Second
+)
+
+// SigningKeyConfig controls JWT key rotation. A key stops signing once a newer one exists and
+// is pruned after Retention, by which time every access token it signed has expired.
+type SigningKeyConfig struct {
+	Algorithm string
+	Rotation  time.Duration
+	Retention time.Duration
+}
+
+// SigningKeyConfigFromEnv reads JWT_SIGNING_ALG (RS256 or EdDSA) and JWT_KEY_ROTATION,
+// falling back to RS256 and 30 days. Retention is the access-token lifetime plus an hour.
+func SigningKeyConfigFromEnv() SigningKeyConfig {
+	cfg := SigningKeyConfig{
+		Algorithm: auth.AlgRS256,
+		Rotation:  defaultKeyRotation,
+		Retention: auth.AccessTokenTTL() + time.Hour,
+	}
+	if raw := strings.TrimSpace(os.Getenv("JWT_SIGNING_ALG")); raw != "" {
+		alg, err := auth.NormalizeAlgorithm(raw)
+		if err != nil {
+			log.Printf("Ignoring invalid JWT_SIGNING_ALG %q", raw)
+		} else {
+			cfg.Algorithm = alg
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("JWT_KEY_ROTATION")); raw != "" {
+		rotation, err := time.ParseDuration(raw)
+		if err != nil || rotation <= 0 {
+			log.Printf("Ignoring invalid JWT_KEY_ROTATION %q", raw)
+		} else {
+			cfg.Rotation = rotation
+		}
+	}
+	return cfg
+}
+
+type SigningKeyService interface {
+	Rotate(ctx context.Context) error
+	Reload(ctx context.Context) error
+	JWKS() auth.JWKS
+}
+
+type signingKeyService struct {
+	repo    repository.SigningKeyRepository
+	keyring *auth.Keyring
+	cfg     SigningKeyConfig
+}
+
+// NewSigningKeyService also lets the keyring reload from storage when a token names a key it
+// does not hold yet, so a key rotated in by another instance verifies right away instead of
+// after the next scheduled Rotate.
+func NewSigningKeyService(repo repository.SigningKeyRepository, keyring *auth.Keyring, cfg SigningKeyConfig) SigningKeyService {
+	s := &signingKeyService{
+		repo:    repo,
+		keyring: keyring,
+		cfg:     cfg,
+	}
+	keyring.ReloadOnUnknownKey(s.Reload, keyReloadInterval)
+	return s
+}
+
+// Rotate reloads the shared keys into the keyring, adds a new signing key when the newest is
+// older than the rotation interval (or uses another algorithm), and prunes keys whose
+// successor has been signing for longer than the retention. It runs at startup and on a
+// schedule, which is also how every instance picks up keys created by the others.
+func (s *signingKeyService) Rotate(ctx context.Context) error {
+	keys, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if len(keys) == 0 || now.Sub(keys[0].CreatedAt) >= s.cfg.Rotation || keys[0].Algorithm != s.cfg.Algorithm {
+		key, err := auth.GenerateSigningKey(s.cfg.Algorithm)
+		if err != nil {
+			return err
+		}
+		encoded, err := key.MarshalPrivateKey()
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Create(ctx, &models.SigningKey{
+			KID:        key.ID,
+			Algorithm:  key.Algorithm,
+			PrivateKey: encoded,
+			CreatedAt:  key.CreatedAt,
+		}); err != nil {
+			return err
+		}
+		log.Printf("Rotated JWT signing key: %s (%s)", key.ID, key.Algorithm)
+		keys = append([]*auth.SigningKey{key}, keys...)
+	}
+
+	kept, pruned := pruneSigningKeys(keys, now, s.cfg.Retention)
+	if err := s.repo.Delete(ctx, pruned); err != nil {
+		return fmt.Errorf("prune signing keys: %w", err)
+	}
+	s.keyring.Replace(kept)
+	return nil
+}
+
+// Reload replaces the keyring with the stored keys, without rotating or pruning.
+func (s *signingKeyService) Reload(ctx context.Context) error {
+	keys, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		s.keyring.Replace(keys)
+	}
+	return nil
+}
+
+// load reads the shared keys, newest first.
+func (s *signingKeyService) load(ctx context.Context) ([]*auth.SigningKey, error) {
+	rows, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*auth.SigningKey, 0, len(rows))
+	for _, row := range rows {
+		key, err := auth.ParseSigningKey(row.KID, row.Algorithm, row.PrivateKey, row.CreatedAt)
+		if err != nil {
+			log.Printf("Skipping unusable signing key: %v", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *signingKeyService) JWKS() auth.JWKS {
+	return s.keyring.JWKS()
+}
+
+// pruneSigningKeys splits keys (newest first) into those still needed to verify tokens and
+// the kids of keys whose successor took over more than retention ago.
+func pruneSigningKeys(keys []*auth.SigningKey, now time.Time, retention time.Duration) ([]*auth.SigningKey, []string) {
+	for i := 1; i < len(keys); i++ {
+		if now.Sub(keys[i-1].CreatedAt) > retention {
+			var pruned []string
+			for _, key := range keys[i:] {
+				pruned = append(pruned, key.ID)
+			}
+			return keys[:i], pruned
+		}
+	}
+	return keys, nil
+}
@@ -2,31 +2,68 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/mail"
 	"strings"
+	"sync"
+	"time"
 
 	auth "github.com/ODawah/Trading-Insights/authentication"
 	"github.com/ODawah/Trading-Insights/models"
 	"github.com/ODawah/Trading-Insights/repository"
-	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected; all sessions in this family were revoked")
 )
 
 type AuthService interface {
-	Signup(ctx context.Context, req *SignupRequest) (*AuthResponse, error)
-	Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error)
+	Signup(ctx context.Context, req *SignupRequest) (*SignupResponse, error)
+	Login(ctx context.Context, req *LoginRequest) (*AuthResponse, *MFAChallenge, error)
+	LoginMFA(ctx context.Context, req *MFALoginRequest) (*AuthResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*AuthResponse, error)
+	Logout(ctx context.Context, claims *auth.Claims, refreshToken string) error
+	ChangePassword(ctx context.Context, claims *auth.Claims, req *ChangePasswordRequest) (*AuthResponse, error)
+	ValidateToken(token string) (*auth.Claims, error)
+	IsRevoked(ctx context.Context, jti string) (bool, error)
 }
 
 type authService struct {
-	userRepo repository.UserRepository
+	userRepo      repository.UserRepository
+	refreshTokens repository.RefreshTokenRepository
+	revocations   repository.RevocationRepository
+	mfa           MFAService
+	accounts      AccountService
+	guard         LoginGuard
+	audit         AuditService
+	keyring       *auth.Keyring
 }
 
-func NewAuthService(userRepo repository.UserRepository) AuthService {
+func NewAuthService(userRepo repository.UserRepository, refreshTokens repository.RefreshTokenRepository, revocations repository.RevocationRepository, mfa MFAService, accounts AccountService, guard LoginGuard, audit AuditService, keyring *auth.Keyring) AuthService {
 	return &authService{
-		userRepo: userRepo,
+		userRepo:      userRepo,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		mfa:           mfa,
+		accounts:      accounts,
+		guard:         guard,
+		audit:         audit,
+		keyring:       keyring,
 	}
 }
 
+// dummyPasswordHash is compared against when the email is unknown, so a login for a missing
+// account costs the same bcrypt time as a wrong password.
+var dummyPasswordHash = sync.OnceValue(func() *models.User {
+	user := &models.User{}
+	_ = user.HashPassword("not-a-real-password")
+	return user
+})
+
 // DTOs (Data Transfer Objects)
 type SignupRequest struct {
 	Email    string `json:"email"`
@@ -39,93 +76,378 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// SignupResponse is identical whether or not the email was already registered, so signup
+// cannot be used to discover accounts.
+type SignupResponse struct {
+	Message string `json:"message"`
+}
+
+// ChangePasswordRequest re-authenticates with the current password and, when enabled, a
+// second factor.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+	Code            string `json:"code"`
+	RecoveryCode    string `json:"recovery_code"`
+}
+
+const signupMessage = "Check your email to confirm your address, then log in."
+
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  *models.User `json:"user"`
+	Token            string       `json:"token"` // short-lived access token
+	ExpiresAt        time.Time    `json:"expires_at"`
+	RefreshToken     string       `json:"refresh_token"`
+	RefreshExpiresAt time.Time    `json:"refresh_expires_at"`
+	User             *models.User `json:"user"`
 }
 
-func (s *authService) Signup(ctx context.Context, req *SignupRequest) (*AuthResponse, error) {
+// Signup creates an account and mails a verification link. If the email is taken, the owner
+// is told by email instead and the caller gets the same response as for a new account.
+func (s *authService) Signup(ctx context.Context, req *SignupRequest) (*SignupResponse, error) {
 	log.Printf("🔐 Signup attempt for email: %s", req.Email)
 
 	// Validate input
 	if err := validateSignupRequest(req); err != nil {
 		return nil, err
 	}
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 
-	// Check if user already exists
-	existingUser, err := s.userRepo.FindByEmail(ctx, req.Email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check existing user: %v", err)
-	}
-	if existingUser != nil {
-		return nil, fmt.Errorf("user with email %s already exists", req.Email)
-	}
-
-	// Create new user
+	// Create new user. The password is hashed before the existence check so both paths take
+	// the same time.
 	user := &models.User{
-		Email:    strings.ToLower(strings.TrimSpace(req.Email)),
+		Email:    req.Email,
 		Password: req.Password,
 		Name:     strings.TrimSpace(req.Name),
+		Role:     auth.RoleUser,
 	}
-
-	// Hash password
 	if err := user.HashPassword(user.Password); err != nil {
 		return nil, fmt.Errorf("failed to hash password: %v", err)
 	}
 
+	// Check if user already exists
+	existingUser, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing user: %v", err)
+	}
+	if existingUser != nil {
+		if err := s.accounts.NotifySignupAttempt(ctx, existingUser.ID); err != nil {
+			log.Printf("⚠️  Failed to notify %s of a signup attempt: %v", existingUser.Email, err)
+		}
+		s.audit.Record(ctx, models.AuthEventSignupExisting, existingUser.ID, req.Email, "")
+		return &SignupResponse{Message: signupMessage}, nil
+	}
+
 	// Save user to database
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %v", err)
 	}
 
-	// Generate JWT token
-	token, err := auth.GenerateToken(user.ID, user.Email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %v", err)
+	// The account works before the address is verified; a failed email can be resent.
+	if err := s.accounts.SendVerification(ctx, user.ID); err != nil {
+		log.Printf("⚠️  Failed to send verification email to %s: %v", user.Email, err)
 	}
+	s.audit.Record(ctx, models.AuthEventSignup, user.ID, user.Email, "")
 
 	log.Printf("✅ User created successfully: %s (ID: %d)", user.Email, user.ID)
 
-	return &AuthResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	return &SignupResponse{Message: signupMessage}, nil
 }
 
-func (s *authService) Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error) {
+// Login checks the password. Attempts are throttled per email and per IP, and unknown emails
+// fail exactly like wrong passwords. Users with two-factor enabled get a challenge to complete
+// with LoginMFA instead of tokens; their attempt keeps counting against the email until then.
+func (s *authService) Login(ctx context.Context, req *LoginRequest) (*AuthResponse, *MFAChallenge, error) {
 	log.Printf("🔐 Login attempt for email: %s", req.Email)
 
 	// Validate input
 	if err := validateLoginRequest(req); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	ip := auth.ClientFromContext(ctx).IP
 
-	// Find user by email
-	user, err := s.userRepo.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	attempt, err := s.guard.Begin(ctx, email, ip)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("invalid email or password")
+		var throttled *TooManyAttemptsError
+		if errors.As(err, &throttled) {
+			if throttled.Locked {
+				s.audit.Record(ctx, models.AuthEventLockout, 0, email, "ip "+ip)
+			}
+			s.audit.Record(ctx, models.AuthEventLoginThrottled, 0, email, err.Error())
 		}
-		return nil, fmt.Errorf("failed to find user: %v", err)
+		return nil, nil, err
+	}
+
+	// Find user by email
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find user: %v", err)
+	}
+	if user == nil {
+		dummyPasswordHash().CheckPassword(req.Password)
+		return nil, nil, s.loginFailed(ctx, 0, email, "unknown email")
 	}
 
 	// Check password
 	if !user.CheckPassword(req.Password) {
-		log.Printf("⚠️  Failed login attempt for: %s (wrong password)", req.Email)
-		return nil, fmt.Errorf("invalid email or password")
+		return nil, nil, s.loginFailed(ctx, user.ID, email, "wrong password")
+	}
+	if err := s.guard.PasswordAccepted(ctx, attempt); err != nil {
+		log.Printf("⚠️  Failed to withdraw login attempt for %s: %v", email, err)
 	}
 
-	// Generate JWT token
-	token, err := auth.GenerateToken(user.ID, user.Email)
+	if user.TOTPEnabled {
+		challenge, err := s.mfa.StartChallenge(ctx, user.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.audit.Record(ctx, models.AuthEventMFAChallenge, user.ID, user.Email, "")
+		log.Printf("🔐 Password accepted, second factor required: %s (ID: %d)", user.Email, user.ID)
+		return nil, challenge, nil
+	}
+
+	// Issue access + refresh tokens
+	response, err := s.issueTokens(ctx, user, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %v", err)
+		return nil, nil, err
+	}
+	s.audit.Record(ctx, models.AuthEventLoginSuccess, user.ID, user.Email, "")
+	if err := s.guard.Success(ctx, email); err != nil {
+		log.Printf("⚠️  Failed to clear login failures for %s: %v", email, err)
 	}
 
 	log.Printf("✅ User logged in successfully: %s (ID: %d)", user.Email, user.ID)
 
+	return response, nil, nil
+}
+
+// loginFailed audits a failed password check and returns the one error every failed login
+// gets. The guard counted the attempt before the check.
+func (s *authService) loginFailed(ctx context.Context, userID uint, email, reason string) error {
+	log.Printf("⚠️  Failed login attempt for: %s (%s)", email, reason)
+	s.audit.Record(ctx, models.AuthEventLoginFailure, userID, email, reason)
+	return ErrInvalidCredentials
+}
+
+// LoginMFA exchanges a login challenge and a TOTP or recovery code for tokens. Wrong codes
+// count against the user's email and IP like wrong passwords, so fresh challenges do not buy
+// fresh guesses.
+func (s *authService) LoginMFA(ctx context.Context, req *MFALoginRequest) (*AuthResponse, error) {
+	userID, err := s.mfa.CompleteChallenge(ctx, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.mfaFailed(ctx, userID)
+		}
+		return nil, err
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	if err := s.guard.Success(ctx, user.Email); err != nil {
+		log.Printf("⚠️  Failed to clear login failures for %s: %v", user.Email, err)
+	}
+
+	response, err := s.issueTokens(ctx, user, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuthEventLoginSuccess, user.ID, user.Email, "second factor")
+
+	log.Printf("✅ User logged in successfully with second factor: %s (ID: %d)", user.Email, user.ID)
+
+	return response, nil
+}
+
+func (s *authService) mfaFailed(ctx context.Context, userID uint) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.audit.Record(ctx, models.AuthEventMFAFailure, userID, "", "")
+		return
+	}
+	countSecondFactorFailure(ctx, s.guard, s.audit, userID, user.Email)
+}
+
+func (s *authService) reauth() reauthenticator {
+	return reauthenticator{guard: s.guard, mfa: s.mfa, audit: s.audit}
+}
+
+// Refresh rotates a refresh token: the presented token is consumed and a new access/refresh
+// pair is issued in the same family. Presenting a token that was already consumed or revoked
+// revokes the whole family, including access tokens still live from it.
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*AuthResponse, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	stored, err := s.refreshTokens.FindByHash(ctx, auth.HashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		return nil, s.revokeFamily(ctx, stored, now)
+	}
+	if !stored.ExpiresAt.After(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+	used, err := s.refreshTokens.MarkUsed(ctx, stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		// Another request consumed it first: the same token was presented twice.
+		return nil, s.revokeFamily(ctx, stored, now)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.issueTokens(ctx, user, stored.FamilyID)
+}
+
+// Logout revokes the caller's access token and, when given, the refresh-token family it
+// belongs to so the session cannot be refreshed.
+func (s *authService) Logout(ctx context.Context, claims *auth.Claims, refreshToken string) error {
+	s.audit.Record(ctx, models.AuthEventLogout, claims.UserID, claims.Email, "")
+	if claims.ExpiresAt != nil {
+		if err := s.revocations.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			return err
+		}
+	}
+
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil
+	}
+	stored, err := s.refreshTokens.FindByHash(ctx, auth.HashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return nil
+		}
+		return err
+	}
+	if stored.UserID != claims.UserID {
+		return nil
+	}
+	err = s.revokeFamily(ctx, stored, time.Now().UTC())
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return nil
+	}
+	return err
+}
+
+// ChangePassword re-checks the current password and second factor, sets the new password and
+// signs the user out everywhere. The caller gets a fresh session so only the device that made the change stays
+// signed in.
+func (s *authService) ChangePassword(ctx context.Context, claims *auth.Claims, req *ChangePasswordRequest) (*AuthResponse, error) {
+	if req.CurrentPassword == "" {
+		return nil, fmt.Errorf("current_password is required")
+	}
+	if len(req.NewPassword) < 6 {
+		return nil, fmt.Errorf("password must be at least 6 characters long")
+	}
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	reauth := s.reauth()
+	if err := reauth.password(ctx, user, req.CurrentPassword); err != nil {
+		return nil, err
+	}
+	if err := reauth.secondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+		return nil, err
+	}
+	reauth.passed(ctx, user)
+
+	if err := user.HashPassword(req.NewPassword); err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+	if err := s.userRepo.SetPassword(ctx, user.ID, user.Password); err != nil {
+		return nil, err
+	}
+	sessions, err := revokeSessions(ctx, s.refreshTokens, s.revocations, user.ID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if claims.ExpiresAt != nil {
+		if err := s.revocations.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			return nil, err
+		}
+	}
+	s.audit.Record(ctx, models.AuthEventPasswordChange, user.ID, user.Email, fmt.Sprintf("revoked %d sessions", sessions))
+	log.Printf("🔐 Password changed for user %d; revoked %d sessions", user.ID, sessions)
+	return s.issueTokens(ctx, user, "")
+}
+
+// ValidateToken verifies an access token against the keyring.
+func (s *authService) ValidateToken(token string) (*auth.Claims, error) {
+	return s.keyring.ValidateToken(token)
+}
+
+func (s *authService) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.revocations.IsRevoked(ctx, jti)
+}
+
+// revokeFamily revokes every refresh token in stored's family and deny-lists the access tokens
+// issued with them. It returns ErrRefreshTokenReused once that is done.
+func (s *authService) revokeFamily(ctx context.Context, stored *models.RefreshToken, now time.Time) error {
+	family, err := s.refreshTokens.RevokeFamily(ctx, stored.FamilyID, now)
+	if err != nil {
+		return err
+	}
+	for _, t := range family {
+		if err := s.revocations.Revoke(ctx, t.AccessJTI, t.AccessExpiresAt); err != nil {
+			return err
+		}
+	}
+	s.audit.Record(ctx, models.AuthEventRefreshReuse, stored.UserID, "", "family "+stored.FamilyID)
+	log.Printf("⚠️  Revoked refresh token family %s for user %d", stored.FamilyID, stored.UserID)
+	return ErrRefreshTokenReused
+}
+
+// issueTokens signs an access token and stores a refresh token for it. An empty familyID
+// starts a new family (a fresh login).
+func (s *authService) issueTokens(ctx context.Context, user *models.User, familyID string) (*AuthResponse, error) {
+	token, claims, err := s.keyring.GenerateToken(user.ID, user.Email, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %v", err)
+	}
+
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if familyID == "" {
+		if familyID, err = newTradeID(); err != nil {
+			return nil, fmt.Errorf("generate token family: %w", err)
+		}
+	}
+
+	stored := &models.RefreshToken{
+		UserID:          user.ID,
+		FamilyID:        familyID,
+		TokenHash:       hash,
+		AccessJTI:       claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		ExpiresAt:       time.Now().UTC().Add(auth.RefreshTokenTTL()),
+	}
+	if err := s.refreshTokens.Create(ctx, stored); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %v", err)
+	}
+
 	return &AuthResponse{
-		Token: token,
-		User:  user,
+		Token:            token,
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+		User:             user,
 	}, nil
 }
 
@@ -143,13 +465,27 @@ func validateSignupRequest(req *SignupRequest) error {
 	if req.Name == "" {
 		return fmt.Errorf("name is required")
 	}
-	// Basic email validation
-	if !strings.Contains(req.Email, "@") {
+	if !validEmail(req.Email) {
 		return fmt.Errorf("invalid email format")
 	}
 	return nil
 }
 
+// validEmail accepts a bare addr-spec (no display name) whose domain has at least one dot.
+func validEmail(email string) bool {
+	email = strings.TrimSpace(email)
+	if len(email) > 254 {
+		return false
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return false
+	}
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	return at > 0 && strings.Contains(domain, ".") && !strings.HasPrefix(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
 func validateLoginRequest(req *LoginRequest) error {
 	if req.Email == "" {
 		return fmt.Errorf("email is required")
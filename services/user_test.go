@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	auth "github.com/ODawah/Trading-Insights/authentication"
+	"github.com/ODawah/Trading-Insights/models"
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	users map[uint]*models.User
+}
+
+func (f *fakeUserRepo) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return user, nil
+}
+
+type fakeRefreshTokenRepo struct {
+	tokens []*models.RefreshToken
+}
+
+func (f *fakeRefreshTokenRepo) Create(ctx context.Context, token *models.RefreshToken) error {
+	token.ID = uint(len(f.tokens) + 1)
+	f.tokens = append(f.tokens, token)
+	return nil
+}
+
+func (f *fakeRefreshTokenRepo) FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	for _, t := range f.tokens {
+		if t.TokenHash == hash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrRefreshTokenNotFound
+}
+
+func (f *fakeRefreshTokenRepo) MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error) {
+	t := f.tokens[id-1]
+	if t.UsedAt != nil || t.RevokedAt != nil {
+		return false, nil
+	}
+	t.UsedAt = &at
+	return true, nil
+}
+
+func (f *fakeRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID string, at time.Time) ([]models.RefreshToken, error) {
+	var out []models.RefreshToken
+	for _, t := range f.tokens {
+		if t.FamilyID == familyID {
+			t.RevokedAt = &at
+			out = append(out, *t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRefreshTokenRepo) RevokeUser(ctx context.Context, userID uint, at time.Time) ([]models.RefreshToken, error) {
+	var out []models.RefreshToken
+	for _, t := range f.tokens {
+		if t.UserID == userID {
+			t.RevokedAt = &at
+			out = append(out, *t)
+		}
+	}
+	return out, nil
+}
+
+type fakeRevocations map[string]bool
+
+func (f fakeRevocations) Revoke(ctx context.Context, jti string, until time.Time) error {
+	f[jti] = true
+	return nil
+}
+
+func (f fakeRevocations) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return f[jti], nil
+}
+
+func TestRefreshRotatesAndRevokesFamilyOnReuse(t *testing.T) {
+	key, err := auth.GenerateSigningKey(auth.AlgEdDSA)
+	assert.NoError(t, err)
+	user := &models.User{Email: "a@example.com"}
+	user.ID = 7
+	tokens := &fakeRefreshTokenRepo{}
+	revoked := fakeRevocations{}
+	svc := &authService{
+		userRepo:      &fakeUserRepo{users: map[uint]*models.User{7: user}},
+		refreshTokens: tokens,
+		revocations:   revoked,
+		audit:         &fakeAudit{},
+		keyring:       auth.NewKeyring(key),
+	}
+	ctx := context.Background()
+
+	first, err := svc.issueTokens(ctx, user, "")
+	assert.NoError(t, err)
+	second, err := svc.Refresh(ctx, first.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, tokens.tokens[0].FamilyID, tokens.tokens[1].FamilyID)
+
+	// Replaying the consumed token revokes the family, including the live access token.
+	_, err = svc.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.True(t, revoked[tokens.tokens[1].AccessJTI])
+	_, err = svc.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	_, err = svc.Refresh(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
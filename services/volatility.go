@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ODawah/Trading-Insights/repository"
+)
+
+const (
+	defaultVolatilityBucket = "1d"
+	defaultHistogramBins    = 20
+	maxHistogramBins        = 200
+)
+
+var defaultVolatilityWindows = []int{10, 30, 90}
+
+// WindowVolatility is the annualized realized volatility over the last Window candles.
+// Estimators are nil when fewer candles than the window are available.
+type WindowVolatility struct {
+	Window       int      `json:"window"`
+	CloseToClose *float64 `json:"close_to_close"`
+	Parkinson    *float64 `json:"parkinson"`
+	GarmanKlass  *float64 `json:"garman_klass"`
+}
+
+type PairVolatility struct {
+	A              string             `json:"a"`
+	B              string             `json:"b"`
+	Bucket         string             `json:"bucket"`
+	Candles        int                `json:"candles"`
+	PeriodsPerYear float64            `json:"periods_per_year"`
+	Windows        []WindowVolatility `json:"windows"`
+}
+
+type HistogramBin struct {
+	Lower     float64 `json:"lower"`
+	Upper     float64 `json:"upper"`
+	Count     int     `json:"count"`
+	Frequency float64 `json:"frequency"`
+}
+
+// ReturnDistribution describes log returns of a pair. ExcessKurtosis is 0 for a normal distribution.
+type ReturnDistribution struct {
+	A              string         `json:"a"`
+	B              string         `json:"b"`
+	Bucket         string         `json:"bucket,omitempty"` // empty means raw snapshot-to-snapshot returns
+	Samples        int            `json:"samples"`
+	Mean           float64        `json:"mean"`
+	StdDev         float64        `json:"std_dev"`
+	Skewness       float64        `json:"skewness"`
+	ExcessKurtosis float64        `json:"excess_kurtosis"`
+	Min            float64        `json:"min"`
+	Max            float64        `json:"max"`
+	Histogram      []HistogramBin `json:"histogram"`
+}
+
+// PairVolatility computes close-to-close, Parkinson and Garman-Klass volatility for A/B from
+// bucketed candles. Each window uses the most recent candles in the range.
+func (s *analyticsService) PairVolatility(ctx context.Context, currencyA, currencyB, bucket string, windows []int, from, to *time.Time, limit int) (*PairVolatility, error) {
+	if strings.TrimSpace(bucket) == "" {
+		bucket = defaultVolatilityBucket
+	}
+	step, err := parseIntervalDuration(bucket)
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) == 0 {
+		windows = defaultVolatilityWindows
+	}
+	for _, w := range windows {
+		if w < 2 {
+			return nil, fmt.Errorf("windows must be at least 2 candles")
+		}
+	}
+
+	candles, err := s.CrossCandles(ctx, currencyA, currencyB, bucket, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) < 2 {
+		return nil, fmt.Errorf("not enough candles to compute volatility")
+	}
+
+	periodsPerYear := float64(365*24*time.Hour) / float64(step)
+	annualize := math.Sqrt(periodsPerYear)
+	res := &PairVolatility{
+		A:              strings.ToUpper(strings.TrimSpace(currencyA)),
+		B:              strings.ToUpper(strings.TrimSpace(currencyB)),
+		Bucket:         strings.ToLower(strings.TrimSpace(bucket)),
+		Candles:        len(candles),
+		PeriodsPerYear: periodsPerYear,
+	}
+	for _, w := range windows {
+		wv := WindowVolatility{Window: w}
+		if w <= len(candles) {
+			recent := candles[len(candles)-w:]
+			wv.CloseToClose = scaled(closeToCloseVolatility(recent), annualize)
+			wv.Parkinson = scaled(parkinsonVolatility(recent), annualize)
+			wv.GarmanKlass = scaled(garmanKlassVolatility(recent), annualize)
+		}
+		res.Windows = append(res.Windows, wv)
+	}
+	return res, nil
+}
+
+// ReturnDistribution histograms the log returns of A/B. With an empty bucket it uses every
+// stored snapshot pair; otherwise it uses candle closes.
+func (s *analyticsService) ReturnDistribution(ctx context.Context, currencyA, currencyB, bucket string, bins int, from, to *time.Time, limit int) (*ReturnDistribution, error) {
+	a := strings.ToUpper(strings.TrimSpace(currencyA))
+	b := strings.ToUpper(strings.TrimSpace(currencyB))
+	if bins <= 0 {
+		bins = defaultHistogramBins
+	}
+	if bins > maxHistogramBins {
+		return nil, fmt.Errorf("at most %d bins are supported", maxHistogramBins)
+	}
+
+	var prices []float64
+	if strings.TrimSpace(bucket) == "" {
+		points, err := s.CrossRateHistory(ctx, a, b, from, to, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			prices = append(prices, p.Rate)
+		}
+	} else {
+		candles, err := s.CrossCandles(ctx, a, b, bucket, from, to, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candles {
+			prices = append(prices, c.Close)
+		}
+	}
+
+	returns := logReturns(prices)
+	if len(returns) < 4 {
+		return nil, fmt.Errorf("not enough samples to compute a return distribution")
+	}
+
+	mean, std := meanStdDev(returns)
+	skew, kurt := skewKurtosis(returns)
+	res := &ReturnDistribution{
+		A:              a,
+		B:              b,
+		Bucket:         strings.ToLower(strings.TrimSpace(bucket)),
+		Samples:        len(returns),
+		Mean:           mean,
+		StdDev:         std,
+		Skewness:       skew,
+		ExcessKurtosis: kurt,
+		Histogram:      histogram(returns, bins),
+	}
+	res.Min = res.Histogram[0].Lower
+	res.Max = res.Histogram[len(res.Histogram)-1].Upper
+	return res, nil
+}
+
+func scaled(v, factor float64) *float64 {
+	if math.IsNaN(v) {
+		return nil
+	}
+	out := v * factor
+	return &out
+}
+
+func logReturns(prices []float64) []float64 {
+	out := make([]float64, 0, len(prices))
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] <= 0 || prices[i] <= 0 {
+			continue
+		}
+		out = append(out, math.Log(prices[i]/prices[i-1]))
+	}
+	return out
+}
+
+// closeToCloseVolatility is the per-candle sample standard deviation of log close returns.
+func closeToCloseVolatility(candles []repository.CandleRow) float64 {
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+	returns := logReturns(closes)
+	if len(returns) < 2 {
+		return math.NaN()
+	}
+	_, std := meanStdDev(returns)
+	return std
+}
+
+// parkinsonVolatility uses the high-low range: sqrt(sum(ln(H/L)^2) / (4 N ln 2)).
+func parkinsonVolatility(candles []repository.CandleRow) float64 {
+	sum := 0.0
+	n := 0
+	for _, c := range candles {
+		if c.High <= 0 || c.Low <= 0 {
+			continue
+		}
+		hl := math.Log(c.High / c.Low)
+		sum += hl * hl
+		n++
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return math.Sqrt(sum / (4 * float64(n) * math.Ln2))
+}
+
+// garmanKlassVolatility: sqrt(mean(0.5 ln(H/L)^2 - (2 ln 2 - 1) ln(C/O)^2)).
+func garmanKlassVolatility(candles []repository.CandleRow) float64 {
+	sum := 0.0
+	n := 0
+	for _, c := range candles {
+		if c.High <= 0 || c.Low <= 0 || c.Open <= 0 || c.Close <= 0 {
+			continue
+		}
+		hl := math.Log(c.High / c.Low)
+		co := math.Log(c.Close / c.Open)
+		sum += 0.5*hl*hl - (2*math.Ln2-1)*co*co
+		n++
+	}
+	if n == 0 || sum < 0 {
+		return math.NaN()
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+// skewKurtosis returns the population skewness and excess kurtosis.
+func skewKurtosis(xs []float64) (float64, float64) {
+	n := float64(len(xs))
+	mean := 0.0
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= n
+	var m2, m3, m4 float64
+	for _, x := range xs {
+		d := x - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	m2, m3, m4 = m2/n, m3/n, m4/n
+	if m2 == 0 {
+		return 0, 0
+	}
+	return m3 / math.Pow(m2, 1.5), m4/(m2*m2) - 3
+}
+
+// histogram splits [min,max] into equal-width bins; the last bin includes max.
+func histogram(xs []float64, bins int) []HistogramBin {
+	lo, hi := xs[0], xs[0]
+	for _, x := range xs {
+		lo = math.Min(lo, x)
+		hi = math.Max(hi, x)
+	}
+	if hi == lo {
+		return []HistogramBin{{Lower: lo, Upper: hi, Count: len(xs), Frequency: 1}}
+	}
+
+	width := (hi - lo) / float64(bins)
+	out := make([]HistogramBin, bins)
+	for i := range out {
+		out[i].Lower = lo + float64(i)*width
+		out[i].Upper = lo + float64(i+1)*width
+	}
+	out[bins-1].Upper = hi
+	for _, x := range xs {
+		idx := int((x - lo) / width)
+		if idx >= bins {
+			idx = bins - 1
+		}
+		out[idx].Count++
+	}
+	for i := range out {
+		out[i].Frequency = float64(out[i].Count) / float64(len(xs))
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ODawah/Trading-Insights/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeVolatilityEstimators(t *testing.T) {
+	// Flat open/close with a constant 2% range: Garman-Klass reduces to sqrt(0.5) * ln(H/L).
+	candles := []repository.CandleRow{
+		{Open: 100, High: 101, Low: 99.01, Close: 100},
+		{Open: 100, High: 101, Low: 99.01, Close: 100},
+	}
+	hl := math.Log(101 / 99.01)
+	assert.InDelta(t, hl/math.Sqrt(4*math.Ln2), parkinsonVolatility(candles), 1e-12)
+	assert.InDelta(t, hl*math.Sqrt(0.5), garmanKlassVolatility(candles), 1e-12)
+	assert.True(t, math.IsNaN(closeToCloseVolatility(candles)))
+}
+
+func TestSkewKurtosisAndHistogram(t *testing.T) {
+	xs := []float64{-2, -1, 0, 1, 2}
+	skew, kurt := skewKurtosis(xs)
+	assert.InDelta(t, 0, skew, 1e-12)
+	assert.InDelta(t, 1.7-3, kurt, 1e-12)
+
+	skew, _ = skewKurtosis([]float64{0, 0, 0, 0, 10})
+	assert.Greater(t, skew, 0.0)
+
+	bins := histogram(xs, 4)
+	assert.Len(t, bins, 4)
+	counts := []int{bins[0].Count, bins[1].Count, bins[2].Count, bins[3].Count}
+	assert.Equal(t, []int{1, 1, 1, 2}, counts)
+	assert.Equal(t, 2.0, bins[3].Upper)
+}